@@ -0,0 +1,159 @@
+package budget
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/tiggoins/zombie-cleaner/internal/logger"
+	"github.com/tiggoins/zombie-cleaner/internal/metrics"
+)
+
+// 预算范围，用于日志和指标
+const (
+	ScopeNode    = "node"
+	ScopeCluster = "cluster"
+)
+
+// ClusterLimiter 集群级预算，由所有节点共享
+type ClusterLimiter interface {
+	// Acquire 尝试占用一次处置额度，额度不足时返回false
+	Acquire(ctx context.Context) (bool, error)
+}
+
+// TokenBucket 令牌桶，容量为每小时上限，按相同速率匀速补充
+type TokenBucket struct {
+	mu       sync.Mutex
+	capacity float64
+	tokens   float64
+	// 每秒补充的令牌数
+	rate float64
+	last time.Time
+
+	// 可替换的时间源
+	now func() time.Time
+}
+
+// NewTokenBucket 创建每小时最多perHour个令牌的令牌桶，初始为满
+func NewTokenBucket(perHour int) *TokenBucket {
+	return &TokenBucket{
+		capacity: float64(perHour),
+		tokens:   float64(perHour),
+		rate:     float64(perHour) / time.Hour.Seconds(),
+		last:     time.Now(),
+		now:      time.Now,
+	}
+}
+
+// Allow 尝试取出一个令牌
+func (b *TokenBucket) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Refund 归还一个令牌，用于本地允许但集群预算拒绝的情况
+func (b *TokenBucket) Refund() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens++
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+}
+
+func (b *TokenBucket) refill() {
+	now := b.now()
+	elapsed := now.Sub(b.last).Seconds()
+	b.last = now
+	if elapsed <= 0 {
+		return
+	}
+	b.tokens += elapsed * b.rate
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+}
+
+// Budget 组合本节点令牌桶和集群预算，任一耗尽时拒绝处置
+type Budget struct {
+	local   *TokenBucket
+	cluster ClusterLimiter
+	logger  *logger.Logger
+
+	mu        sync.Mutex
+	exhausted map[string]bool
+}
+
+// New 创建处置预算，local或cluster为nil时表示不限制对应范围
+func New(local *TokenBucket, cluster ClusterLimiter, log *logger.Logger) *Budget {
+	b := &Budget{
+		local:     local,
+		cluster:   cluster,
+		logger:    log.WithComponent("budget"),
+		exhausted: make(map[string]bool),
+	}
+	nodeName := metrics.GetNodeName()
+	metrics.RemediationBudgetExhausted.WithLabelValues(nodeName, ScopeNode).Set(0)
+	metrics.RemediationBudgetExhausted.WithLabelValues(nodeName, ScopeCluster).Set(0)
+	return b
+}
+
+// Allow 尝试占用一次处置额度，拒绝时返回耗尽的预算范围。
+// 集群预算无法访问时按耗尽处理，避免API异常时失去保护
+func (b *Budget) Allow(ctx context.Context) (bool, string) {
+	if b.local != nil {
+		if !b.local.Allow() {
+			b.setExhausted(ScopeNode, true)
+			return false, ScopeNode
+		}
+		b.setExhausted(ScopeNode, false)
+	}
+
+	if b.cluster != nil {
+		allowed, err := b.cluster.Acquire(ctx)
+		if err != nil {
+			b.logger.Warn("访问集群处置预算失败，本次按预算耗尽处理", "error", err)
+		}
+		if !allowed {
+			if b.local != nil {
+				b.local.Refund()
+			}
+			b.setExhausted(ScopeCluster, true)
+			return false, ScopeCluster
+		}
+		b.setExhausted(ScopeCluster, false)
+	}
+
+	return true, ""
+}
+
+// setExhausted 更新预算状态，状态变化时记录日志
+func (b *Budget) setExhausted(scope string, exhausted bool) {
+	b.mu.Lock()
+	changed := b.exhausted[scope] != exhausted
+	b.exhausted[scope] = exhausted
+	b.mu.Unlock()
+
+	value := 0.0
+	if exhausted {
+		value = 1
+	}
+	metrics.RemediationBudgetExhausted.WithLabelValues(metrics.GetNodeName(), scope).Set(value)
+
+	if !changed {
+		return
+	}
+	if exhausted {
+		b.logger.Warn("处置预算已耗尽，切换为只告警模式", "scope", scope)
+	} else {
+		b.logger.Info("处置预算已恢复", "scope", scope)
+	}
+}
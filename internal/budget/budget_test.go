@@ -0,0 +1,158 @@
+package budget
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tiggoins/zombie-cleaner/internal/logger"
+)
+
+// fakeClock 可手动推进的时间源
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func newTestBucket(perHour int) (*TokenBucket, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	b := NewTokenBucket(perHour)
+	b.now = clock.Now
+	b.last = clock.now
+	return b, clock
+}
+
+func TestTokenBucketRefill(t *testing.T) {
+	b, clock := newTestBucket(6)
+
+	for i := 0; i < 6; i++ {
+		if !b.Allow() {
+			t.Fatalf("第%d次Allow被拒绝, 初始应为满桶", i+1)
+		}
+	}
+	if b.Allow() {
+		t.Fatal("令牌用完后应拒绝")
+	}
+
+	// 每小时6个，即每10分钟补充1个
+	clock.now = clock.now.Add(9 * time.Minute)
+	if b.Allow() {
+		t.Fatal("补充不足一个令牌时应拒绝")
+	}
+	clock.now = clock.now.Add(time.Minute)
+	if !b.Allow() {
+		t.Fatal("补充一个令牌后应允许")
+	}
+	if b.Allow() {
+		t.Fatal("只补充了一个令牌")
+	}
+
+	// 补充不超过容量
+	clock.now = clock.now.Add(24 * time.Hour)
+	allowed := 0
+	for b.Allow() {
+		allowed++
+	}
+	if allowed != 6 {
+		t.Errorf("长时间空闲后允许%d次, 期望容量6", allowed)
+	}
+}
+
+func TestTokenBucketClockGoesBackwards(t *testing.T) {
+	b, clock := newTestBucket(1)
+	if !b.Allow() {
+		t.Fatal("初始应允许")
+	}
+	clock.now = clock.now.Add(-time.Hour)
+	if b.Allow() {
+		t.Error("时钟回拨不应补充令牌")
+	}
+}
+
+func TestTokenBucketRefund(t *testing.T) {
+	b, _ := newTestBucket(2)
+
+	b.Allow()
+	b.Allow()
+	b.Refund()
+	if !b.Allow() {
+		t.Fatal("归还后应允许")
+	}
+
+	// 归还不超过容量
+	b.Refund()
+	b.Refund()
+	b.Refund()
+	allowed := 0
+	for b.Allow() {
+		allowed++
+	}
+	if allowed != 2 {
+		t.Errorf("多次归还后允许%d次, 期望容量2", allowed)
+	}
+}
+
+// fakeCluster 返回预设结果的集群预算
+type fakeCluster struct {
+	allowed bool
+	err     error
+	calls   int
+}
+
+func (f *fakeCluster) Acquire(context.Context) (bool, error) {
+	f.calls++
+	return f.allowed, f.err
+}
+
+func TestBudgetAllow(t *testing.T) {
+	log := logger.New("error", "text")
+
+	t.Run("未配置时不限制", func(t *testing.T) {
+		b := New(nil, nil, log)
+		if allowed, _ := b.Allow(context.Background()); !allowed {
+			t.Error("未配置预算时应允许")
+		}
+	})
+
+	t.Run("本节点耗尽时不访问集群预算", func(t *testing.T) {
+		local, _ := newTestBucket(1)
+		cluster := &fakeCluster{allowed: true}
+		b := New(local, cluster, log)
+
+		if allowed, _ := b.Allow(context.Background()); !allowed {
+			t.Fatal("第一次应允许")
+		}
+		allowed, scope := b.Allow(context.Background())
+		if allowed || scope != ScopeNode {
+			t.Errorf("Allow() = (%v, %q), 期望 (false, %q)", allowed, scope, ScopeNode)
+		}
+		if cluster.calls != 1 {
+			t.Errorf("集群预算调用%d次, 期望1次", cluster.calls)
+		}
+	})
+
+	t.Run("集群拒绝时归还本节点令牌", func(t *testing.T) {
+		local, _ := newTestBucket(1)
+		cluster := &fakeCluster{allowed: false}
+		b := New(local, cluster, log)
+
+		allowed, scope := b.Allow(context.Background())
+		if allowed || scope != ScopeCluster {
+			t.Errorf("Allow() = (%v, %q), 期望 (false, %q)", allowed, scope, ScopeCluster)
+		}
+		if !local.Allow() {
+			t.Error("集群拒绝后本节点令牌应被归还")
+		}
+	})
+
+	t.Run("集群预算不可访问时按耗尽处理", func(t *testing.T) {
+		cluster := &fakeCluster{allowed: false, err: errors.New("connection refused")}
+		b := New(nil, cluster, log)
+
+		if allowed, scope := b.Allow(context.Background()); allowed || scope != ScopeCluster {
+			t.Errorf("Allow() = (%v, %q), 期望 (false, %q)", allowed, scope, ScopeCluster)
+		}
+	})
+}
@@ -0,0 +1,149 @@
+package cleaner
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/tiggoins/zombie-cleaner/internal/config"
+	"github.com/tiggoins/zombie-cleaner/internal/detector"
+	"github.com/tiggoins/zombie-cleaner/internal/kube"
+	"github.com/tiggoins/zombie-cleaner/internal/policy"
+)
+
+// 应用团队可以在Pod上设置的注解
+const (
+	// 为"true"时不处理该Pod
+	AnnotationExclude = "zombie-cleaner.io/exclude"
+	// 覆盖确认次数
+	AnnotationConfirmCount = "zombie-cleaner.io/confirm-count"
+	// 覆盖处置方式：ignore、alert、remediate
+	AnnotationAction = "zombie-cleaner.io/action"
+)
+
+// 注解值无效时记录的事件原因
+const eventReasonInvalidAnnotation = "InvalidZombieCleanerAnnotation"
+
+// 已告警的无效注解记录上限，超过后清空
+const maxWarnedAnnotations = 1000
+
+// annotationCacheEntry 通过API读取的Pod注解缓存
+type annotationCacheEntry struct {
+	annotations map[string]string
+	fetchedAt   time.Time
+}
+
+// resolvePodAnnotations 为运行时未暴露注解的Pod容器通过Kubernetes API补全注解，结果按检测间隔缓存
+func (c *Cleaner) resolvePodAnnotations(ctx context.Context, containerZombies map[string][]detector.ZombieInfo) {
+	if c.kubeClient == nil || !c.cfg().Kubernetes.AnnotationLookup {
+		return
+	}
+
+	now := time.Now()
+	for _, zombies := range containerZombies {
+		container := zombies[0].Container
+		if container.Annotations != nil || container.PodUID == "" {
+			continue
+		}
+
+		if entry, ok := c.annotationCache[container.PodUID]; ok && now.Sub(entry.fetchedAt) < c.cfg().Cleaner.CheckInterval {
+			container.Annotations = entry.annotations
+			continue
+		}
+
+		lookupCtx, cancel := context.WithTimeout(ctx, c.cfg().Kubernetes.APITimeout)
+		annotations, err := kube.GetPodAnnotations(lookupCtx, c.kubeClient, container.PodNS, container.PodName, container.PodUID)
+		cancel()
+		if err != nil {
+			c.logger.Debug("读取Pod注解失败",
+				"pod_name", container.PodName,
+				"namespace", container.PodNS,
+				"error", err)
+			continue
+		}
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		container.Annotations = annotations
+		c.annotationCache[container.PodUID] = annotationCacheEntry{annotations: annotations, fetchedAt: now}
+	}
+
+	// 清理过期缓存
+	for uid, entry := range c.annotationCache {
+		if now.Sub(entry.fetchedAt) >= c.cfg().Cleaner.CheckInterval {
+			delete(c.annotationCache, uid)
+		}
+	}
+}
+
+// applyAnnotations 把Pod注解叠加到策略决策上，返回Pod是否被注解排除。
+// 决策保留原规则名称，生效的注解记录在Overrides中。调用方需持有stateMutex
+func (c *Cleaner) applyAnnotations(container *detector.ContainerMeta, decision *policy.Decision) bool {
+	annotations := container.Annotations
+
+	if value, ok := annotations[AnnotationExclude]; ok {
+		exclude, err := strconv.ParseBool(value)
+		if err != nil {
+			c.warnInvalidAnnotation(container, AnnotationExclude, value, "必须是true或false")
+		} else if exclude {
+			return true
+		}
+	}
+
+	if value, ok := annotations[AnnotationConfirmCount]; ok {
+		count, err := strconv.Atoi(value)
+		if err != nil || count <= 0 {
+			c.warnInvalidAnnotation(container, AnnotationConfirmCount, value, "必须是大于0的整数")
+		} else {
+			decision.ConfirmCount = count
+			decision.Overrides = append(decision.Overrides, AnnotationConfirmCount)
+		}
+	}
+
+	if value, ok := annotations[AnnotationAction]; ok {
+		switch action := config.PolicyAction(value); action {
+		case config.PolicyIgnore, config.PolicyAlert, config.PolicyRemediate:
+			decision.Action = action
+			decision.Overrides = append(decision.Overrides, AnnotationAction)
+		default:
+			c.warnInvalidAnnotation(container, AnnotationAction, value, "必须是ignore、alert或remediate")
+		}
+	}
+
+	return false
+}
+
+// warnInvalidAnnotation 记录无效注解的告警日志，并在Pod上记录Warning事件，同一Pod的同一取值只告警一次
+func (c *Cleaner) warnInvalidAnnotation(container *detector.ContainerMeta, key, value, reason string) {
+	warnKey := container.PodNS + "/" + container.PodName + "/" + key + "=" + value
+	if c.warnedAnnotations[warnKey] {
+		return
+	}
+	if len(c.warnedAnnotations) >= maxWarnedAnnotations {
+		c.warnedAnnotations = make(map[string]bool)
+	}
+	c.warnedAnnotations[warnKey] = true
+
+	message := fmt.Sprintf("注解%s的值%q无效，%s，已忽略该注解", key, value, reason)
+	c.logger.Warn("Pod注解无效",
+		"pod_name", container.PodName,
+		"namespace", container.PodNS,
+		"annotation", key,
+		"value", value,
+		"reason", reason)
+
+	if c.kubeClient == nil || container.PodUID == "" {
+		return
+	}
+
+	// 持有状态锁时不做网络请求
+	namespace, name, uid := container.PodNS, container.PodName, container.PodUID
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), c.cfg().Kubernetes.APITimeout)
+		defer cancel()
+		if err := kube.RecordPodWarning(ctx, c.kubeClient, namespace, name, uid, eventReasonInvalidAnnotation, message); err != nil {
+			c.logger.Debug("记录Pod事件失败", "pod_name", name, "namespace", namespace, "error", err)
+		}
+	}()
+}
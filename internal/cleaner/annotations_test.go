@@ -0,0 +1,164 @@
+package cleaner
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/tiggoins/zombie-cleaner/internal/config"
+	"github.com/tiggoins/zombie-cleaner/internal/detector"
+	"github.com/tiggoins/zombie-cleaner/internal/logger"
+	"github.com/tiggoins/zombie-cleaner/internal/policy"
+)
+
+func newAnnotationTestCleaner() *Cleaner {
+	return &Cleaner{
+		config:            &config.Config{},
+		logger:            logger.New("error", "text"),
+		warnedAnnotations: make(map[string]bool),
+	}
+}
+
+func TestApplyAnnotations(t *testing.T) {
+	base := policy.Decision{Rule: "legacy", Action: config.PolicyRemediate, ConfirmCount: 3}
+
+	tests := []struct {
+		name          string
+		annotations   map[string]string
+		wantExcluded  bool
+		wantAction    config.PolicyAction
+		wantConfirm   int
+		wantOverrides []string
+		wantWarnings  int
+	}{
+		{
+			name:        "没有注解",
+			wantAction:  config.PolicyRemediate,
+			wantConfirm: 3,
+		},
+		{
+			name:         "排除",
+			annotations:  map[string]string{AnnotationExclude: "true"},
+			wantExcluded: true,
+			wantAction:   config.PolicyRemediate,
+			wantConfirm:  3,
+		},
+		{
+			name:        "显式不排除",
+			annotations: map[string]string{AnnotationExclude: "false"},
+			wantAction:  config.PolicyRemediate,
+			wantConfirm: 3,
+		},
+		{
+			name:         "无效的排除值",
+			annotations:  map[string]string{AnnotationExclude: "yes please"},
+			wantAction:   config.PolicyRemediate,
+			wantConfirm:  3,
+			wantWarnings: 1,
+		},
+		{
+			name:          "覆盖确认次数",
+			annotations:   map[string]string{AnnotationConfirmCount: "10"},
+			wantAction:    config.PolicyRemediate,
+			wantConfirm:   10,
+			wantOverrides: []string{AnnotationConfirmCount},
+		},
+		{
+			name:         "确认次数为0",
+			annotations:  map[string]string{AnnotationConfirmCount: "0"},
+			wantAction:   config.PolicyRemediate,
+			wantConfirm:  3,
+			wantWarnings: 1,
+		},
+		{
+			name:         "确认次数不是整数",
+			annotations:  map[string]string{AnnotationConfirmCount: "many"},
+			wantAction:   config.PolicyRemediate,
+			wantConfirm:  3,
+			wantWarnings: 1,
+		},
+		{
+			name:          "覆盖处置方式",
+			annotations:   map[string]string{AnnotationAction: "alert"},
+			wantAction:    config.PolicyAlert,
+			wantConfirm:   3,
+			wantOverrides: []string{AnnotationAction},
+		},
+		{
+			name:         "无效的处置方式",
+			annotations:  map[string]string{AnnotationAction: "delete"},
+			wantAction:   config.PolicyRemediate,
+			wantConfirm:  3,
+			wantWarnings: 1,
+		},
+		{
+			name:          "同时覆盖确认次数和处置方式",
+			annotations:   map[string]string{AnnotationConfirmCount: "5", AnnotationAction: "ignore"},
+			wantAction:    config.PolicyIgnore,
+			wantConfirm:   5,
+			wantOverrides: []string{AnnotationConfirmCount, AnnotationAction},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newAnnotationTestCleaner()
+			container := &detector.ContainerMeta{PodName: "web-0", PodNS: "prod", Annotations: tt.annotations}
+			decision := base
+
+			excluded := c.applyAnnotations(container, &decision)
+			if excluded != tt.wantExcluded {
+				t.Errorf("excluded = %v, 期望 %v", excluded, tt.wantExcluded)
+			}
+			if excluded {
+				return
+			}
+			if decision.Rule != base.Rule {
+				t.Errorf("Rule = %q, 注解不应替换原规则名称 %q", decision.Rule, base.Rule)
+			}
+			if decision.Action != tt.wantAction {
+				t.Errorf("Action = %q, 期望 %q", decision.Action, tt.wantAction)
+			}
+			if decision.ConfirmCount != tt.wantConfirm {
+				t.Errorf("ConfirmCount = %d, 期望 %d", decision.ConfirmCount, tt.wantConfirm)
+			}
+			if !reflect.DeepEqual(decision.Overrides, tt.wantOverrides) {
+				t.Errorf("Overrides = %v, 期望 %v", decision.Overrides, tt.wantOverrides)
+			}
+			if len(c.warnedAnnotations) != tt.wantWarnings {
+				t.Errorf("告警次数 = %d, 期望 %d", len(c.warnedAnnotations), tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestWarnInvalidAnnotationOncePerValue(t *testing.T) {
+	c := newAnnotationTestCleaner()
+	container := &detector.ContainerMeta{PodName: "web-0", PodNS: "prod"}
+
+	c.warnInvalidAnnotation(container, AnnotationAction, "delete", "invalid")
+	c.warnInvalidAnnotation(container, AnnotationAction, "delete", "invalid")
+	if len(c.warnedAnnotations) != 1 {
+		t.Fatalf("同一取值应只告警一次, 实际记录%d条", len(c.warnedAnnotations))
+	}
+
+	// 取值变化后再次告警
+	c.warnInvalidAnnotation(container, AnnotationAction, "destroy", "invalid")
+	// 其他Pod的相同取值单独告警
+	c.warnInvalidAnnotation(&detector.ContainerMeta{PodName: "web-1", PodNS: "prod"}, AnnotationAction, "delete", "invalid")
+	if len(c.warnedAnnotations) != 3 {
+		t.Errorf("告警记录 = %d, 期望3", len(c.warnedAnnotations))
+	}
+}
+
+func TestWarnInvalidAnnotationBounded(t *testing.T) {
+	c := newAnnotationTestCleaner()
+	container := &detector.ContainerMeta{PodName: "web-0", PodNS: "prod"}
+
+	for i := 0; i < maxWarnedAnnotations+5; i++ {
+		c.warnInvalidAnnotation(container, AnnotationConfirmCount, strconv.Itoa(i), "invalid")
+	}
+	if len(c.warnedAnnotations) > maxWarnedAnnotations {
+		t.Errorf("告警记录 = %d, 超过上限 %d", len(c.warnedAnnotations), maxWarnedAnnotations)
+	}
+}
@@ -2,80 +2,211 @@ package cleaner
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"regexp"
 	"sync"
+	"syscall"
 	"time"
 
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/tiggoins/zombie-cleaner/internal/budget"
 	"github.com/tiggoins/zombie-cleaner/internal/config"
 	"github.com/tiggoins/zombie-cleaner/internal/detector"
+	"github.com/tiggoins/zombie-cleaner/internal/kube"
 	"github.com/tiggoins/zombie-cleaner/internal/logger"
 	"github.com/tiggoins/zombie-cleaner/internal/metrics"
+	"github.com/tiggoins/zombie-cleaner/internal/policy"
+	"github.com/tiggoins/zombie-cleaner/internal/process"
+	"github.com/tiggoins/zombie-cleaner/internal/runtime"
+	"github.com/tiggoins/zombie-cleaner/internal/state"
+	"github.com/tiggoins/zombie-cleaner/internal/systemd"
+	"github.com/tiggoins/zombie-cleaner/internal/workqueue"
 )
 
 // 容器状态跟踪
 type ContainerState struct {
-	ContainerID   string
+	ContainerID string
+	// 当前僵尸进程中最大的连续检测次数
 	DetectionCount int
-	LastDetected  time.Time
-	InProgress    bool
-	PodName       string
-	Namespace     string
+	// 当前僵尸进程的确认情况，按PID和启动时间标识
+	Zombies      map[process.Ref]*ZombieSighting
+	LastDetected time.Time
+	InProgress   bool
+	PodName      string
+	Namespace    string
+	// 正在执行的处置步骤
+	CurrentStep config.RemediationAction
+	// 处置步骤执行记录
+	Remediation []RemediationRecord
+	// 容器init进程不回收僵尸进程，已记录过Pod事件
+	MissingReaper bool
+	// 本次处置已占用预算，重试时不再重复占用，处置结束后重置
+	BudgetCharged bool
 }
 
 type Cleaner struct {
-	config   *config.Config
+	// 当前生效的配置、处置策略和白名单，热加载时整体替换
+	config *config.Config
+	// 处置策略
+	policy *policy.Engine
+	// 白名单正则表达式
+	whitelistRegexes []*regexp.Regexp
+	configMu         sync.RWMutex
+	// 检测间隔变化时通知Start重置定时器
+	intervalChanged chan struct{}
+
 	logger   *logger.Logger
 	detector *detector.Detector
 
+	// Kubernetes客户端，未启用Kubernetes相关功能时为nil
+	kubeClient kubernetes.Interface
+	// Kubernetes处置后端，未启用时为nil
+	podRemediator *kube.PodRemediator
+
+	// 通过API读取的Pod注解缓存，按Pod UID索引
+	annotationCache map[string]annotationCacheEntry
+	// 已告警的无效注解
+	warnedAnnotations map[string]bool
+
 	// 状态跟踪
 	containerStates map[string]*ContainerState
 	stateMutex      sync.RWMutex
 
-	// 白名单正则表达式
-	whitelistRegexes []*regexp.Regexp
+	// 容器状态持久化存储，未配置state_file时为nil
+	stateStore   state.Store
+	persistMutex sync.Mutex
+
+	// 处置预算，未配置时为nil
+	budget *budget.Budget
+
+	// 处置工作队列，worker数量为max_concurrent_containers
+	queue *workqueue.Queue[pendingRemediation]
+
+	// 重启宿主机僵尸进程所属的systemd unit
+	units systemd.Manager
+	// 各unit上一次重启的时间，只在检测循环中访问
+	unitRestarts map[string]time.Time
+
+	// stuckProcesses 返回最近一次检测发现的D状态进程，测试中可替换
+	stuckProcesses func() []detector.StuckProcess
+
+	// signal 通过进程句柄向进程发送信号，测试中可替换
+	signal func(ref process.Ref, sig syscall.Signal) error
+
+	// executeStep 执行单个处置步骤，测试中可替换
+	executeStep func(ctx context.Context, step config.RemediationStep, containerID string, zombies []detector.ZombieInfo) (string, error)
 
 	// 控制通道
 	stopChan chan struct{}
 }
 
 func New(cfg *config.Config, log *logger.Logger) (*Cleaner, error) {
-	det, err := detector.New(cfg.Cleaner.ContainerTimeout, cfg.Cleaner.ContainerRuntime, log)
+	det, err := detector.New(&cfg.Cleaner, log)
 	if err != nil {
 		return nil, fmt.Errorf("创建检测器失败: %w", err)
 	}
 
+	policyEngine, err := policy.New(&cfg.Cleaner)
+	if err != nil {
+		return nil, fmt.Errorf("加载处置策略失败: %w", err)
+	}
+
 	c := &Cleaner{
 		config:          cfg,
+		policy:          policyEngine,
 		logger:          log.WithComponent("cleaner"),
 		detector:        det,
 		containerStates: make(map[string]*ContainerState),
 		stopChan:        make(chan struct{}),
+		intervalChanged: make(chan struct{}, 1),
+
+		annotationCache:   make(map[string]annotationCacheEntry),
+		warnedAnnotations: make(map[string]bool),
+
+		units:        systemd.NewClient(cfg.Cleaner.HostZombies.SystemdSocket),
+		unitRestarts: make(map[string]time.Time),
+	}
+
+	c.executeStep = c.runStep
+	c.stuckProcesses = det.StuckProcesses
+	c.signal = func(ref process.Ref, sig syscall.Signal) error {
+		return process.Signal(det.ProcRoot(), ref, sig)
 	}
 
-	for _, pattern := range cfg.Cleaner.WhitelistPatterns {
-		regex, err := regexp.Compile(pattern)
+	c.whitelistRegexes = compileWhitelist(cfg.Cleaner.WhitelistPatterns, log)
+
+	if cfg.Cleaner.RemediationBackend == config.BackendKubernetes || cfg.Kubernetes.AnnotationLookup {
+		client, err := kube.NewClient(cfg.Kubernetes)
 		if err != nil {
-			log.Warn("白名单模式编译失败", "pattern", pattern, "error", err)
-			continue
+			log.Warn("创建Kubernetes客户端失败，Kubernetes相关功能不可用，删除容器将直接使用容器运行时", "error", err)
+		} else {
+			c.kubeClient = client
 		}
-		c.whitelistRegexes = append(c.whitelistRegexes, regex)
+	}
+	if c.kubeClient != nil && cfg.Cleaner.RemediationBackend == config.BackendKubernetes {
+		c.podRemediator = kube.NewPodRemediator(c.kubeClient, cfg.Kubernetes.EvictionGracePeriod, log)
 	}
 
+	var (
+		localBudget   *budget.TokenBucket
+		clusterBudget budget.ClusterLimiter
+	)
+	if cfg.Cleaner.Budget.MaxRemediationsPerHour > 0 {
+		localBudget = budget.NewTokenBucket(cfg.Cleaner.Budget.MaxRemediationsPerHour)
+	}
+	if cfg.Cleaner.Budget.Cluster.Enabled {
+		if c.kubeClient == nil {
+			client, err := kube.NewClient(cfg.Kubernetes)
+			if err != nil {
+				return nil, fmt.Errorf("启用集群处置预算需要Kubernetes客户端: %w", err)
+			}
+			c.kubeClient = client
+		}
+		clusterCfg := cfg.Cleaner.Budget.Cluster
+		clusterBudget = kube.NewConfigMapBudget(c.kubeClient, clusterCfg.ConfigMapNamespace, clusterCfg.ConfigMapName, clusterCfg.MaxRemediationsPerHour)
+	}
+	if localBudget != nil || clusterBudget != nil {
+		c.budget = budget.New(localBudget, clusterBudget, log)
+	}
+
+	// 恢复重启前的确认计数和处置记录
+	if cfg.Cleaner.StateFile != "" {
+		c.stateStore = state.NewFileStore(cfg.Cleaner.StateFile)
+		c.restoreStates()
+	}
+
+	setConfigHash(cfg.Hash())
+
+	// worker使用独立的上下文，停止时由Stop等待执行中的处置完成
+	c.queue = workqueue.New(workqueue.Options{
+		Name:       "remediation",
+		Workers:    cfg.Cleaner.MaxConcurrentContainers,
+		MaxRetries: cfg.Cleaner.RemediationRetries,
+		Backoff:    cfg.Cleaner.RemediationRetryBackoff,
+	}, c.remediate, c.finishRemediation, log)
+
 	return c, nil
 }
 
 func (c *Cleaner) Start(ctx context.Context) {
+	cfg := c.cfg()
 	c.logger.Info("启动僵尸进程清理器",
-		"check_interval", c.config.Cleaner.CheckInterval,
-		"confirm_count", c.config.Cleaner.ConfirmCount,
-		"dry_run", c.config.Cleaner.DryRun)
+		"check_interval", cfg.Cleaner.CheckInterval,
+		"confirm_count", cfg.Cleaner.ConfirmCount,
+		"min_zombie_age", cfg.Cleaner.MinZombieAge,
+		"dry_run", cfg.Cleaner.DryRun)
 
-	ticker := time.NewTicker(c.config.Cleaner.CheckInterval)
+	ticker := time.NewTicker(cfg.Cleaner.CheckInterval)
 	defer ticker.Stop()
 
+	// 启用事件驱动检测时，定时检测作为一致性检查保留
+	c.detector.StartEventWatcher(ctx)
+	eventTriggers := c.detector.EventTriggers()
+
 	// 立即执行一次检测
-	c.runCheck(ctx)
+	c.runCheck(ctx, true)
 
 	for {
 		select {
@@ -86,7 +217,12 @@ func (c *Cleaner) Start(ctx context.Context) {
 			c.logger.Info("收到停止信号，停止清理器")
 			return
 		case <-ticker.C:
-			c.runCheck(ctx)
+			c.runCheck(ctx, true)
+		case <-c.intervalChanged:
+			ticker.Reset(c.cfg().Cleaner.CheckInterval)
+		case <-eventTriggers:
+			metrics.EventTriggeredChecks.WithLabelValues(metrics.GetNodeName()).Inc()
+			c.runCheck(ctx, false)
 		}
 	}
 }
@@ -95,20 +231,11 @@ func (c *Cleaner) Stop(ctx context.Context) {
 	c.logger.Info("正在停止清理器...")
 	close(c.stopChan)
 
-	// 等待当前操作完成，使用RWMutex的RLock来避免阻塞其他读操作
-	done := make(chan struct{})
-	go func() {
-		c.stateMutex.RLock()
-		defer c.stateMutex.RUnlock()
-		close(done)
-	}()
+	// 丢弃尚未开始的处置，等待执行中的处置完成，ctx到期后取消
+	c.queue.Shutdown(ctx)
+	c.logger.Info("清理器已停止")
 
-	select {
-	case <-done:
-		c.logger.Info("清理器已停止")
-	case <-ctx.Done():
-		c.logger.Warn("停止清理器超时")
-	}
+	c.persistStates()
 
 	// 关闭容器运行时连接
 	if c.detector.ContainerRuntime != nil {
@@ -125,7 +252,9 @@ func (c *Cleaner) Stop(ctx context.Context) {
 	}
 }
 
-func (c *Cleaner) runCheck(ctx context.Context) {
+// runCheck 执行一次检测周期。periodic为false表示由进程事件触发，
+// 这类检测只能开始确认，不推进确认计数，确认时间仍由定时检测决定
+func (c *Cleaner) runCheck(ctx context.Context, periodic bool) {
 	c.logger.Debug("开始检测周期")
 
 	zombies, err := c.detector.DetectZombies(ctx)
@@ -136,31 +265,70 @@ func (c *Cleaner) runCheck(ctx context.Context) {
 	}
 
 	// 清理超时容器的shim进程
-	c.killTimeoutContainerShims()
+	c.killTimeoutContainerShims(ctx)
 
 	if len(zombies) == 0 {
 		c.logger.Debug("未发现僵尸进程")
+		c.handleHostZombies(ctx, nil, periodic)
+		c.detector.ExcludeFromTrigger(nil)
 		c.cleanupOldStates()
+		c.persistStates()
 		return
 	}
 
 	// 按容器分组处理僵尸进程
 	containerZombies := make(map[string][]detector.ZombieInfo)
+	var hostZombies []detector.ZombieInfo
+	// 不会被处置的僵尸进程，不计入事件触发阈值
+	unactionable := make(map[int]bool)
 	for _, zombie := range zombies {
 		if zombie.IsInContainer {
 			containerID := zombie.Container.ID
 			containerZombies[containerID] = append(containerZombies[containerID], zombie)
+		} else {
+			hostZombies = append(hostZombies, zombie)
+			unactionable[zombie.PID] = true
 		}
 	}
 
-	c.processContainerZombies(ctx, containerZombies)
+	c.handleHostZombies(ctx, hostZombies, periodic)
+	c.resolvePodAnnotations(ctx, containerZombies)
+	c.processContainerZombies(containerZombies, periodic, unactionable)
+	c.detector.ExcludeFromTrigger(unactionable)
 	c.cleanupOldStates()
+	c.persistStates()
 }
 
-func (c *Cleaner) processContainerZombies(ctx context.Context, containerZombies map[string][]detector.ZombieInfo) {
+// errRemediationBlocked 处置被PodDisruptionBudget阻止，不重试
+var errRemediationBlocked = errors.New("处置被PodDisruptionBudget阻止")
+
+// pendingRemediation 已达到确认次数、等待执行的处置
+type pendingRemediation struct {
+	containerID string
+	state       *ContainerState
+	zombies     []detector.ZombieInfo
+	decision    policy.Decision
+}
+
+func (c *Cleaner) processContainerZombies(containerZombies map[string][]detector.ZombieInfo, periodic bool, unactionable map[int]bool) {
+	pending := c.updateContainerStates(containerZombies, periodic, unactionable)
+	// PID耗尽风险高的容器优先处置
+	sortByRisk(pending)
+
+	// 入队在状态锁之外进行，worker结束处置时需要获取状态锁
+	for _, p := range pending {
+		c.launchRemediation(p)
+	}
+}
+
+// updateContainerStates 更新容器确认计数，返回需要处置的容器。返回的容器已标记为处理中。
+// 不会被处置的容器的僵尸进程PID记录到unactionable中
+func (c *Cleaner) updateContainerStates(containerZombies map[string][]detector.ZombieInfo, periodic bool, unactionable map[int]bool) []pendingRemediation {
 	c.stateMutex.Lock()
 	defer c.stateMutex.Unlock()
 
+	var pending []pendingRemediation
+
 	for containerID, zombies := range containerZombies {
 		if len(zombies) == 0 {
 			continue
@@ -171,6 +339,7 @@ func (c *Cleaner) processContainerZombies(ctx context.Context, containerZombies
 
 		// 检查白名单
 		if c.isWhitelisted(container.PodName) {
+			markUnactionable(unactionable, zombies)
 			c.logger.Debug("容器在白名单中，跳过清理",
 				"container_id", containerID,
 				"pod_name", container.PodName,
@@ -178,6 +347,35 @@ func (c *Cleaner) processContainerZombies(ctx context.Context, containerZombies
 			continue
 		}
 
+		// 按策略规则决定处置方式
+		decision := c.currentPolicy().Evaluate(policy.Input{
+			Namespace:   container.PodNS,
+			Labels:      container.Labels,
+			Image:       container.Image,
+			Command:     container.Comm,
+			ZombieCount: len(zombies),
+		})
+		// Pod注解叠加在策略之上
+		if c.applyAnnotations(container, &decision) {
+			markUnactionable(unactionable, zombies)
+			c.logger.Debug("Pod注解排除了该容器，跳过清理",
+				"container_id", containerID,
+				"pod_name", container.PodName,
+				"namespace", container.PodNS)
+			continue
+		}
+		if decision.Action == config.PolicyIgnore {
+			markUnactionable(unactionable, zombies)
+			c.logger.Debug("策略规则忽略该容器",
+				"container_id", containerID,
+				"pod_name", container.PodName,
+				"namespace", container.PodNS,
+				"policy_rule", decision.Rule,
+				"annotation_overrides", decision.Overrides)
+			metrics.PolicyDecisions.WithLabelValues(metrics.GetNodeName(), decision.Rule, string(decision.Action)).Inc()
+			continue
+		}
+
 		// 更新状态
 		state, exists := c.containerStates[containerID]
 		if !exists {
@@ -195,108 +393,192 @@ func (c *Cleaner) processContainerZombies(ctx context.Context, containerZombies
 			continue
 		}
 
-		state.LastDetected = time.Now()
-		state.DetectionCount++
+		now := time.Now()
+		state.LastDetected = now
+		age := state.observeZombies(zombies, periodic, now)
+		cleanerCfg := c.cfg().Cleaner
+		risk := zombies[0].Risk
+		highRisk := cleanerCfg.Risk.Enabled && risk != nil && risk.Score >= float64(cleanerCfg.Risk.Threshold)
+		trend := detector.TrendStable
+		if risk != nil {
+			trend = risk.Trend
+		}
+		escalate := escalated(cleanerCfg.Trend.Escalate, trend, periodic, state.DetectionCount, age, cleanerCfg.MinZombieAge)
 
 		c.logger.Info("更新容器僵尸进程状态",
 			"container_id", containerID,
 			"pod_name", container.PodName,
 			"namespace", container.PodNS,
 			"detection_count", state.DetectionCount,
-			"confirm_threshold", c.config.Cleaner.ConfirmCount,
+			"confirm_threshold", decision.ConfirmCount,
+			"zombie_age", age.Round(time.Second),
+			"min_zombie_age", cleanerCfg.MinZombieAge,
+			"risk", risk,
+			"policy_rule", decision.Rule,
+			"annotation_overrides", decision.Overrides,
+			"policy_action", decision.Action,
+			"stuck_processes", len(c.stuckInContainer(containerID)),
 			"zombie_pids", c.getZombiePIDs(zombies))
 
-		// 检查是否达到确认次数
-		if state.DetectionCount >= c.config.Cleaner.ConfirmCount {
-			// 检查是否有PPID为1的僵尸进程
-			hasOrphanZombies := false
-			for _, zombie := range zombies {
-				if zombie.PPID == 1 {
-					hasOrphanZombies = true
-					c.logger.Warn("发现PPID为1的孤儿僵尸进程，无法直接清理",
-						"container_id", containerID,
-						"pod_name", container.PodName,
-						"namespace", container.PodNS,
-						"zombie_pid", zombie.PID,
-						"detection_count", state.DetectionCount)
-				}
+		// 检查同一批僵尸进程是否持续存在到确认次数或最小存在时长，PID耗尽风险高时立即确认，
+		// 数量持续增长、突增时在僵尸进程再次出现后提前确认
+		if highRisk || escalate || confirmed(state.DetectionCount, decision.ConfirmCount, age, cleanerCfg.MinZombieAge) {
+			hostInit, containerInit := orphanParents(zombies)
+			if containerInit {
+				// 容器init进程不回收僵尸进程，按孤儿僵尸进程策略处置
+				c.reportMissingReaper(state, container, zombies)
+				decision = c.currentPolicy().EvaluateMissingReaper(decision)
 			}
 
-			if hasOrphanZombies {
-				// 对于包含孤儿僵尸进程的容器，只记录日志，不执行清理操作
-				c.logger.Warn("容器包含孤儿僵尸进程，跳过清理操作",
+			if hostInit && !containerInit {
+				markUnactionable(unactionable, zombies)
+				// 宿主机init进程持有的僵尸进程无法通过处置容器清理，只记录日志
+				c.logger.Warn("容器包含由宿主机init进程持有的僵尸进程，跳过清理操作",
 					"container_id", containerID,
 					"pod_name", container.PodName,
 					"namespace", container.PodNS,
 					"detection_count", state.DetectionCount)
-				// 重置计数器，避免重复报告
-				state.DetectionCount = 0
+				// 重置确认，避免重复报告
+				state.resetConfirmation(now)
+			} else if decision.Action == config.PolicyIgnore {
+				// 孤儿僵尸进程策略为ignore
+				markUnactionable(unactionable, zombies)
+				c.logger.Debug("孤儿僵尸进程策略忽略该容器",
+					"container_id", containerID,
+					"pod_name", container.PodName,
+					"namespace", container.PodNS)
+				metrics.PolicyDecisions.WithLabelValues(metrics.GetNodeName(), decision.Rule, string(decision.Action)).Inc()
+				state.resetConfirmation(now)
+			} else if decision.Action == config.PolicyAlert {
+				markUnactionable(unactionable, zombies)
+				c.logger.Warn("容器僵尸进程已确认，策略规则为只告警",
+					"container_id", containerID,
+					"pod_name", container.PodName,
+					"namespace", container.PodNS,
+					"detection_count", state.DetectionCount,
+					"zombie_age", age.Round(time.Second),
+					"high_risk", highRisk,
+					"trend", trend,
+					"policy_rule", decision.Rule,
+					"annotation_overrides", decision.Overrides)
+				metrics.PolicyDecisions.WithLabelValues(metrics.GetNodeName(), decision.Rule, string(decision.Action)).Inc()
+				// 重置确认，避免重复报告
+				state.resetConfirmation(now)
 			} else {
-				c.logger.Warn("容器僵尸进程确认次数达到阈值，开始清理",
+				c.logger.Warn("容器僵尸进程已确认，开始清理",
 					"container_id", containerID,
 					"pod_name", container.PodName,
 					"namespace", container.PodNS,
-					"detection_count", state.DetectionCount)
-
-				// 异步清理，避免阻塞其他容器的处理
-				go c.cleanupContainer(ctx, containerID, state, zombies)
+					"detection_count", state.DetectionCount,
+					"zombie_age", age.Round(time.Second),
+					"high_risk", highRisk,
+					"trend", trend,
+					"policy_rule", decision.Rule,
+					"annotation_overrides", decision.Overrides)
+				metrics.PolicyDecisions.WithLabelValues(metrics.GetNodeName(), decision.Rule, string(decision.Action)).Inc()
+
+				state.InProgress = true
+				pending = append(pending, pendingRemediation{
+					containerID: containerID,
+					state:       state,
+					zombies:     zombies,
+					decision:    decision,
+				})
 			}
 		}
 	}
+
+	return pending
 }
 
-func (c *Cleaner) cleanupContainer(ctx context.Context, containerID string, state *ContainerState, zombies []detector.ZombieInfo) {
-	c.stateMutex.Lock()
-	state.InProgress = true
-	c.stateMutex.Unlock()
+func markUnactionable(unactionable map[int]bool, zombies []detector.ZombieInfo) {
+	for _, zombie := range zombies {
+		unactionable[zombie.PID] = true
+	}
+}
 
-	defer func() {
-		c.stateMutex.Lock()
-		delete(c.containerStates, containerID)
-		c.stateMutex.Unlock()
-	}()
+// launchRemediation 将处置加入工作队列，由worker异步执行处置阶梯。
+// 处置预算在执行破坏性步骤之前检查
+func (c *Cleaner) launchRemediation(p pendingRemediation) {
+	if c.queue.Add(p.containerID, p) {
+		return
+	}
+	c.logger.Debug("容器已在处置队列中或队列已关闭，跳过", "container_id", p.containerID)
+}
 
-	containerLog := c.logger.WithContainer(containerID, state.PodName, state.Namespace)
+// remediate 执行一次处置阶梯。返回错误时由工作队列按退避时间重试
+func (c *Cleaner) remediate(ctx context.Context, p pendingRemediation) error {
+	containerLog := c.logger.WithContainer(p.containerID, p.state.PodName, p.state.Namespace)
 
-	if c.config.Cleaner.DryRun {
-		containerLog.Info("干跑模式：模拟清理容器", "zombie_count", len(zombies))
-		return
+	// 重试前僵尸进程可能已被回收
+	if !c.anyZombieAlive(p.zombies) {
+		containerLog.Info("僵尸进程已被回收，无需处置")
+		return nil
 	}
 
-	containerLog.Info("开始清理容器", "zombie_count", len(zombies))
+	if c.cfg().Cleaner.DryRun {
+		containerLog.Info("干跑模式：模拟清理容器", "zombie_count", len(p.zombies))
+		c.runRemediationLadder(ctx, p.containerID, p.state, p.zombies, p.decision.Steps)
+		return nil
+	}
 
-	// 首先尝试删除容器
-	if err := c.removeContainer(ctx, containerID); err != nil {
-		containerLog.Error("删除容器失败，尝试强制清理", "error", err)
+	var containerRuntime string
+	if p.zombies[0].Container != nil {
+		containerRuntime = p.zombies[0].Container.Runtime
+	}
+	containerLog.Info("开始清理容器", "zombie_count", len(p.zombies), "runtime", containerRuntime)
 
-		// 如果删除失败，尝试kill container-shim或containerd-shim
-		if c.detector.ContainerRuntime != nil {
-			if err := c.detector.ContainerRuntime.KillContainerShim(containerID); err != nil {
-				containerLog.Error("清理container-shim失败", "error", err)
-				metrics.CleanupFailures.WithLabelValues(metrics.GetNodeName(), "cleanup_failed").Inc()
-				return
-			}
-		} else {
-			containerLog.Error("没有可用的容器运行时，无法清理shim进程")
-			metrics.CleanupFailures.WithLabelValues(metrics.GetNodeName(), "cleanup_failed").Inc()
-			return
-		}
+	switch result := c.runRemediationLadder(ctx, p.containerID, p.state, p.zombies, p.decision.Steps); result {
+	case StepResultResolved, StepResultEvicted:
+		metrics.ContainersCleaned.WithLabelValues(metrics.GetNodeName(), p.state.Namespace, p.state.PodName).Inc()
+		containerLog.Info("容器清理完成")
+		return nil
+	case StepResultThrottled:
+		containerLog.Warn("处置预算已耗尽，未执行破坏性处置步骤，本次只告警")
+		return nil
+	case StepResultBlocked:
+		// 重试同样会被PDB拒绝，等待重新确认后再处置
+		return workqueue.Permanent(errRemediationBlocked)
+	default:
+		return fmt.Errorf("处置阶梯结束时僵尸进程仍然存在: %s", result)
+	}
+}
+
+// finishRemediation 处置最终结束（成功、重试次数用尽或因停止被丢弃）后重置容器状态
+func (c *Cleaner) finishRemediation(p pendingRemediation, err error) {
+	switch {
+	case err == nil:
+	case errors.Is(err, workqueue.ErrShuttingDown):
+		c.logger.Warn("清理器停止，处置未完成", "container_id", p.containerID, "error", err)
+	case errors.Is(err, errRemediationBlocked):
+		c.logger.WithContainer(p.containerID, p.state.PodName, p.state.Namespace).
+			Info("处置被PodDisruptionBudget阻止，不再重试，重新确认后再处置")
+	default:
+		c.logger.WithContainer(p.containerID, p.state.PodName, p.state.Namespace).
+			Error("处置重试次数已用尽，僵尸进程仍然存在", "error", err)
+		metrics.CleanupFailures.WithLabelValues(metrics.GetNodeName(), "cleanup_failed").Inc()
 	}
 
-	metrics.ContainersCleaned.WithLabelValues(metrics.GetNodeName(), state.Namespace, state.PodName).Inc()
-	containerLog.Info("容器清理完成")
+	c.stateMutex.Lock()
+	p.state.InProgress = false
+	p.state.CurrentStep = ""
+	p.state.BudgetCharged = false
+	// 重置确认，预算耗尽或处置失败时需要重新确认
+	p.state.resetConfirmation(time.Now())
+	c.stateMutex.Unlock()
+	c.persistStates()
 }
 
-func (c *Cleaner) removeContainer(ctx context.Context, containerID string) error {
+func (c *Cleaner) removeContainer(ctx context.Context, containerID string, timeout time.Duration) error {
 	// 设置超时
-	timeoutCtx, cancel := context.WithTimeout(ctx, c.config.Cleaner.ContainerTimeout)
+	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	c.logger.Info("尝试删除容器", "container_id", containerID)
 
 	// 使用容器运行时接口删除容器
 	if c.detector.ContainerRuntime != nil {
-		if err := c.detector.ContainerRuntime.RemoveContainer(timeoutCtx, containerID, c.config.Cleaner.ContainerTimeout); err != nil {
+		if err := c.detector.ContainerRuntime.RemoveContainer(timeoutCtx, containerID, timeout); err != nil {
 			if timeoutCtx.Err() == context.DeadlineExceeded {
 				metrics.ContainerOperationTimeouts.WithLabelValues(metrics.GetNodeName(), "remove").Inc()
 			}
@@ -309,7 +591,42 @@ func (c *Cleaner) removeContainer(ctx context.Context, containerID string) error
 	return fmt.Errorf("没有可用的容器运行时")
 }
 
+// removeContainerOrPod 启用Kubernetes后端时驱逐容器所属的Pod，API Server不可达时回退到运行时删除。
+// evicted为true表示驱逐已被API Server接受，Pod的终止交由kubelet完成
+func (c *Cleaner) removeContainerOrPod(ctx context.Context, container *detector.ContainerMeta, timeout time.Duration) (evicted bool, err error) {
+	if c.podRemediator == nil || container.PodUID == "" {
+		return false, c.removeContainer(ctx, container.ID, timeout)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, c.cfg().Kubernetes.APITimeout)
+	defer cancel()
+
+	err = c.podRemediator.EvictPod(timeoutCtx, container.PodNS, container.PodName, container.PodUID)
+	if err == nil {
+		metrics.PodEvictions.WithLabelValues(metrics.GetNodeName(), "kubernetes").Inc()
+		return true, nil
+	}
+	// PDB拒绝时不能回退到运行时删除，否则会绕过PDB
+	if errors.Is(err, kube.ErrEvictionBlocked) || !kube.IsUnreachable(err) {
+		return false, err
+	}
+
+	c.logger.Warn("Kubernetes API不可达，回退到容器运行时删除",
+		"container_id", container.ID,
+		"pod_name", container.PodName,
+		"namespace", container.PodNS,
+		"error", err)
+	if err := c.removeContainer(ctx, container.ID, timeout); err != nil {
+		return false, err
+	}
+	metrics.PodEvictions.WithLabelValues(metrics.GetNodeName(), "runtime_fallback").Inc()
+	return false, nil
+}
+
 func (c *Cleaner) isWhitelisted(podName string) bool {
+	c.configMu.RLock()
+	defer c.configMu.RUnlock()
+
 	for _, regex := range c.whitelistRegexes {
 		if regex.MatchString(podName) {
 			return true
@@ -331,7 +648,7 @@ func (c *Cleaner) cleanupOldStates() {
 	defer c.stateMutex.Unlock()
 
 	now := time.Now()
-	cleanupThreshold := c.config.Cleaner.CheckInterval * 3 // 3个检测周期后清理
+	cleanupThreshold := c.cfg().Cleaner.CheckInterval * 3 // 3个检测周期后清理
 
 	for containerID, state := range c.containerStates {
 		if !state.InProgress && now.Sub(state.LastDetected) > cleanupThreshold {
@@ -341,17 +658,29 @@ func (c *Cleaner) cleanupOldStates() {
 	}
 }
 
-func (c *Cleaner) killTimeoutContainerShims() {
-	// 如果有detector，获取超时容器并清理它们的shim进程
-	if c.detector != nil && c.detector.ContainerRuntime != nil {
-		timeoutContainers := c.detector.GetTimeoutContainers()
-		for _, containerID := range timeoutContainers {
-			c.logger.Warn("发现inspect超时容器，尝试清理shim进程", "container_id", containerID)
-			if err := c.detector.ContainerRuntime.KillContainerShim(containerID); err != nil {
-				c.logger.Error("清理shim进程失败", "container_id", containerID, "error", err)
-			} else {
-				c.logger.Info("成功清理shim进程", "container_id", containerID)
-			}
+// killTimeoutContainerShims 清理inspect超时容器的shim进程，参数取自kill_shim步骤的配置
+func (c *Cleaner) killTimeoutContainerShims(ctx context.Context) {
+	if c.detector == nil || c.detector.ContainerRuntime == nil {
+		return
+	}
+	cfg := c.cfg().Cleaner
+	opts := shimKillOptions(config.KillShimStep(cfg.RemediationSteps))
+	for _, containerID := range c.detector.GetTimeoutContainers() {
+		if cfg.DryRun {
+			c.logger.Info("干跑模式：跳过清理inspect超时容器的shim进程", "container_id", containerID)
+			continue
+		}
+		if stuck, blocked := c.shimKillBlocked(containerID); blocked {
+			c.logger.Warn("inspect超时容器内有不可中断（D状态）进程，kill shim无法清理，跳过",
+				"container_id", containerID,
+				"stuck_processes", stuckSummary(stuck))
+			continue
+		}
+		c.logger.Warn("发现inspect超时容器，尝试清理shim进程", "container_id", containerID)
+		if err := c.detector.ContainerRuntime.KillContainerShim(ctx, runtime.ContainerMeta{ID: containerID}, opts); err != nil {
+			c.logger.Error("清理shim进程失败", "container_id", containerID, "error", err)
+		} else {
+			c.logger.Info("成功清理shim进程", "container_id", containerID)
 		}
 	}
 }
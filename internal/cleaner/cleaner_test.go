@@ -0,0 +1,71 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tiggoins/zombie-cleaner/internal/config"
+	"github.com/tiggoins/zombie-cleaner/internal/logger"
+	"github.com/tiggoins/zombie-cleaner/internal/workqueue"
+)
+
+func TestFinishRemediationResetsState(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{name: "处置成功"},
+		{name: "重试次数用尽", err: errors.New("处置阶梯结束时僵尸进程仍然存在: unresolved")},
+		{name: "清理器停止", err: workqueue.ErrShuttingDown},
+		{name: "被PDB阻止", err: errRemediationBlocked},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Cleaner{config: &config.Config{}, logger: logger.New("error", "text")}
+			state := &ContainerState{
+				ContainerID:    "c1",
+				DetectionCount: 3,
+				InProgress:     true,
+				CurrentStep:    config.ActionRemoveContainer,
+				BudgetCharged:  true,
+			}
+
+			c.finishRemediation(pendingRemediation{containerID: "c1", state: state}, tt.err)
+
+			if state.InProgress || state.CurrentStep != "" || state.DetectionCount != 0 || state.BudgetCharged {
+				t.Errorf("结束后状态 = %+v, 期望重置InProgress、CurrentStep、DetectionCount和BudgetCharged", state)
+			}
+		})
+	}
+}
+
+func TestLaunchRemediationDeduplicates(t *testing.T) {
+	c := &Cleaner{config: &config.Config{}, logger: logger.New("error", "text")}
+
+	started := make(chan string, 4)
+	release := make(chan struct{})
+	c.queue = workqueue.New(workqueue.Options{Name: "test", Workers: 2}, func(ctx context.Context, p pendingRemediation) error {
+		started <- p.containerID
+		<-release
+		return nil
+	}, c.finishRemediation, c.logger)
+	defer c.queue.Shutdown(context.Background())
+
+	state := &ContainerState{ContainerID: "c1", InProgress: true}
+	c.launchRemediation(pendingRemediation{containerID: "c1", state: state})
+	c.launchRemediation(pendingRemediation{containerID: "c1", state: state})
+
+	<-started
+	select {
+	case id := <-started:
+		t.Fatalf("同一容器被重复处置: %s", id)
+	case <-time.After(20 * time.Millisecond):
+	}
+	if c.queue.Len() != 1 {
+		t.Errorf("队列长度 = %d, 期望1", c.queue.Len())
+	}
+	close(release)
+}
@@ -0,0 +1,88 @@
+package cleaner
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/tiggoins/zombie-cleaner/internal/detector"
+	"github.com/tiggoins/zombie-cleaner/internal/process"
+)
+
+// ZombieSighting 单个僵尸进程的确认情况
+type ZombieSighting struct {
+	// 连续被定时检测发现的次数
+	Count int
+	// 本轮确认开始的时间，首次发现时取僵尸进程的FirstSeen，确认被消耗后重置为当前时间
+	Since time.Time
+}
+
+// observeZombies 按僵尸进程身份更新确认情况：只有同一僵尸进程在连续检测中都出现才累计次数，
+// 短生命周期子进程每次被碰巧检测到时都是新的僵尸进程，不会累计。
+// 已消失的僵尸进程不再参与确认。DetectionCount更新为当前僵尸进程中最大的连续检测次数，
+// 返回当前僵尸进程中本轮确认的最长存在时长
+func (s *ContainerState) observeZombies(zombies []detector.ZombieInfo, periodic bool, now time.Time) time.Duration {
+	sightings := make(map[process.Ref]*ZombieSighting, len(zombies))
+	var (
+		count  int
+		oldest time.Duration
+	)
+	for _, zombie := range zombies {
+		ref := zombie.Ref()
+		sighting, ok := s.Zombies[ref]
+		if !ok {
+			since := zombie.FirstSeen
+			if since.IsZero() {
+				since = now
+			}
+			sighting = &ZombieSighting{Since: since}
+		}
+		// 事件触发的检测只能开始确认，不推进确认次数
+		if periodic || sighting.Count == 0 {
+			sighting.Count++
+		}
+		sightings[ref] = sighting
+
+		count = max(count, sighting.Count)
+		oldest = max(oldest, now.Sub(sighting.Since))
+	}
+	s.Zombies = sightings
+	s.DetectionCount = count
+	return oldest
+}
+
+// resetConfirmation 消耗本轮确认（告警或处置结束后），仍然存在的僵尸进程需要重新确认
+func (s *ContainerState) resetConfirmation(now time.Time) {
+	s.DetectionCount = 0
+	for _, sighting := range s.Zombies {
+		sighting.Count = 0
+		sighting.Since = now
+	}
+}
+
+// confirmed 判断是否达到确认条件：连续检测次数达到确认次数，或僵尸进程存在时长达到min_zombie_age
+func confirmed(count, confirmCount int, age, minAge time.Duration) bool {
+	return count >= confirmCount || (minAge > 0 && age >= minAge)
+}
+
+// escalated 判断是否按趋势提前确认。只在周期检测中生效，且至少有一个僵尸进程在连续两次检测中都出现
+// （或存在时长达到min_zombie_age），首次发现或事件触发的检测不会仅凭趋势就处置
+func escalated(enabled bool, trend detector.Trend, periodic bool, count int, age, minAge time.Duration) bool {
+	if !enabled || trend == detector.TrendStable || !periodic {
+		return false
+	}
+	return count >= 2 || (minAge > 0 && age >= minAge)
+}
+
+// sortByRisk 按PID耗尽风险评分从高到低排序，没有评分的容器排在最后
+func sortByRisk(pending []pendingRemediation) {
+	score := func(p pendingRemediation) float64 {
+		if len(p.zombies) == 0 || p.zombies[0].Risk == nil {
+			return -1
+		}
+		return p.zombies[0].Risk.Score
+	}
+	slices.SortStableFunc(pending, func(a, b pendingRemediation) int {
+		return cmp.Compare(score(b), score(a))
+	})
+}
@@ -0,0 +1,281 @@
+package cleaner
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/tiggoins/zombie-cleaner/internal/config"
+	"github.com/tiggoins/zombie-cleaner/internal/detector"
+	"github.com/tiggoins/zombie-cleaner/internal/logger"
+	"github.com/tiggoins/zombie-cleaner/internal/policy"
+)
+
+func TestObserveZombies(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	state := &ContainerState{ContainerID: "c1"}
+
+	cycles := []struct {
+		name      string
+		offset    time.Duration
+		periodic  bool
+		zombies   []detector.ZombieInfo
+		reset     bool
+		wantCount int
+		wantAge   time.Duration
+	}{
+		{
+			name:      "首次发现",
+			periodic:  true,
+			zombies:   []detector.ZombieInfo{{PID: 100, StartTime: 1}},
+			wantCount: 1,
+		},
+		{
+			name:      "事件触发的检测不推进次数",
+			offset:    time.Minute,
+			zombies:   []detector.ZombieInfo{{PID: 100, StartTime: 1}},
+			wantCount: 1,
+			wantAge:   time.Minute,
+		},
+		{
+			name:     "同一僵尸进程持续存在，新僵尸进程从1开始",
+			offset:   5 * time.Minute,
+			periodic: true,
+			zombies: []detector.ZombieInfo{
+				{PID: 100, StartTime: 1},
+				{PID: 101, StartTime: 2, FirstSeen: start.Add(4 * time.Minute)},
+			},
+			wantCount: 2,
+			wantAge:   5 * time.Minute,
+		},
+		{
+			name:      "PID被复用后重新计数",
+			offset:    10 * time.Minute,
+			periodic:  true,
+			zombies:   []detector.ZombieInfo{{PID: 100, StartTime: 3}},
+			wantCount: 1,
+		},
+		{
+			name:      "消耗确认后重新计数和计时",
+			offset:    15 * time.Minute,
+			periodic:  true,
+			zombies:   []detector.ZombieInfo{{PID: 100, StartTime: 3}},
+			reset:     true,
+			wantCount: 2,
+			wantAge:   5 * time.Minute,
+		},
+		{
+			name:      "确认被消耗的僵尸进程重新开始",
+			offset:    20 * time.Minute,
+			periodic:  true,
+			zombies:   []detector.ZombieInfo{{PID: 100, StartTime: 3}},
+			wantCount: 1,
+			wantAge:   5 * time.Minute,
+		},
+	}
+
+	for _, cycle := range cycles {
+		now := start.Add(cycle.offset)
+		age := state.observeZombies(cycle.zombies, cycle.periodic, now)
+		if state.DetectionCount != cycle.wantCount || age != cycle.wantAge {
+			t.Errorf("%s: DetectionCount = %d, 存在时长 = %v, 期望 %d, %v", cycle.name, state.DetectionCount, age, cycle.wantCount, cycle.wantAge)
+		}
+		if len(state.Zombies) != len(cycle.zombies) {
+			t.Errorf("%s: 跟踪的僵尸进程数量 = %d, 期望 %d", cycle.name, len(state.Zombies), len(cycle.zombies))
+		}
+		if cycle.reset {
+			state.resetConfirmation(now)
+		}
+	}
+}
+
+func TestUpdateContainerStatesConfirmsPersistentZombies(t *testing.T) {
+	container := &detector.ContainerMeta{ID: "c1", PodName: "web-0", PodNS: "prod"}
+	zombie := func(pid int, startTime uint64, age time.Duration) detector.ZombieInfo {
+		return detector.ZombieInfo{
+			PID:           pid,
+			StartTime:     startTime,
+			PPID:          10,
+			Container:     container,
+			IsInContainer: true,
+			FirstSeen:     time.Now().Add(-age),
+			Age:           age,
+		}
+	}
+
+	highRisk := func(z detector.ZombieInfo) detector.ZombieInfo {
+		z.Risk = &detector.Risk{Score: 90, Zombies: 1}
+		return z
+	}
+
+	growing := func(z detector.ZombieInfo) detector.ZombieInfo {
+		z.Risk = &detector.Risk{Score: 10, Zombies: 5, GrowthRate: 1, Trend: detector.TrendGrowing}
+		return z
+	}
+
+	tests := []struct {
+		name          string
+		minAge        time.Duration
+		riskEnabled   bool
+		trendEscalate bool
+		// 所有检测周期都由进程事件触发
+		eventTriggered bool
+		// 每个检测周期发现的僵尸进程
+		cycles [][]detector.ZombieInfo
+		// 第几个周期（从1开始）确认，0表示不确认
+		wantConfirmedAt int
+	}{
+		{
+			name: "每次都是新的短生命周期僵尸进程",
+			cycles: [][]detector.ZombieInfo{
+				{zombie(100, 1, 0)},
+				{zombie(101, 2, 0)},
+				{zombie(102, 3, 0)},
+				{zombie(103, 4, 0)},
+			},
+		},
+		{
+			name: "同一僵尸进程连续出现",
+			cycles: [][]detector.ZombieInfo{
+				{zombie(100, 1, 0)},
+				{zombie(100, 1, 0), zombie(101, 2, 0)},
+				{zombie(100, 1, 0)},
+			},
+			wantConfirmedAt: 3,
+		},
+		{
+			name:   "僵尸进程存在时长超过min_zombie_age",
+			minAge: 10 * time.Minute,
+			cycles: [][]detector.ZombieInfo{
+				{zombie(100, 1, 15*time.Minute)},
+			},
+			wantConfirmedAt: 1,
+		},
+		{
+			name:   "存在时长未达到min_zombie_age",
+			minAge: 10 * time.Minute,
+			cycles: [][]detector.ZombieInfo{
+				{zombie(100, 1, time.Minute)},
+				{zombie(101, 2, time.Minute)},
+			},
+		},
+		{
+			name:        "PID耗尽风险达到阈值时立即确认",
+			riskEnabled: true,
+			cycles: [][]detector.ZombieInfo{
+				{highRisk(zombie(100, 1, 0))},
+			},
+			wantConfirmedAt: 1,
+		},
+		{
+			name: "未启用风险评分时仍按确认次数",
+			cycles: [][]detector.ZombieInfo{
+				{highRisk(zombie(100, 1, 0))},
+				{highRisk(zombie(100, 1, 0))},
+				{highRisk(zombie(100, 1, 0))},
+			},
+			wantConfirmedAt: 3,
+		},
+		{
+			name:          "僵尸进程数量持续增长时在再次出现后提前确认",
+			trendEscalate: true,
+			cycles: [][]detector.ZombieInfo{
+				{growing(zombie(100, 1, 0))},
+				{growing(zombie(100, 1, 0))},
+			},
+			wantConfirmedAt: 2,
+		},
+		{
+			name:          "持续增长但每次都是新的僵尸进程",
+			trendEscalate: true,
+			cycles: [][]detector.ZombieInfo{
+				{growing(zombie(100, 1, 0))},
+				{growing(zombie(101, 2, 0))},
+				{growing(zombie(102, 3, 0))},
+			},
+		},
+		{
+			name:           "事件触发的检测不按趋势提前确认",
+			trendEscalate:  true,
+			eventTriggered: true,
+			cycles: [][]detector.ZombieInfo{
+				{growing(zombie(100, 1, 0))},
+				{growing(zombie(100, 1, 0))},
+				{growing(zombie(100, 1, 0))},
+			},
+		},
+		{
+			name:          "数量稳定时按确认次数",
+			trendEscalate: true,
+			cycles: [][]detector.ZombieInfo{
+				{zombie(100, 1, 0)},
+				{zombie(100, 1, 0)},
+				{zombie(100, 1, 0)},
+			},
+			wantConfirmedAt: 3,
+		},
+		{
+			name: "未启用提前确认时仍按确认次数",
+			cycles: [][]detector.ZombieInfo{
+				{growing(zombie(100, 1, 0))},
+				{growing(zombie(100, 1, 0))},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{Cleaner: config.CleanerConfig{
+				ConfirmCount:     3,
+				MinZombieAge:     tt.minAge,
+				Risk:             config.RiskConfig{Enabled: tt.riskEnabled, Threshold: 80},
+				Trend:            config.TrendConfig{Escalate: tt.trendEscalate},
+				RemediationSteps: config.DefaultRemediationSteps(),
+			}}
+			engine, err := policy.New(&cfg.Cleaner)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c := &Cleaner{
+				config:          cfg,
+				policy:          engine,
+				logger:          logger.New("error", "text"),
+				containerStates: make(map[string]*ContainerState),
+			}
+
+			confirmedAt := 0
+			for i, zombies := range tt.cycles {
+				pending := c.updateContainerStates(map[string][]detector.ZombieInfo{"c1": zombies}, !tt.eventTriggered, make(map[int]bool))
+				if len(pending) > 0 {
+					confirmedAt = i + 1
+					break
+				}
+			}
+			if confirmedAt != tt.wantConfirmedAt {
+				t.Errorf("在第%d个周期确认, 期望 %d", confirmedAt, tt.wantConfirmedAt)
+			}
+		})
+	}
+}
+
+func TestSortByRisk(t *testing.T) {
+	withRisk := func(id string, score float64) pendingRemediation {
+		zombie := detector.ZombieInfo{PID: 1}
+		if score >= 0 {
+			zombie.Risk = &detector.Risk{Score: score}
+		}
+		return pendingRemediation{containerID: id, zombies: []detector.ZombieInfo{zombie}}
+	}
+	pending := []pendingRemediation{withRisk("low", 10), withRisk("none", -1), withRisk("high", 95), withRisk("mid", 50)}
+
+	sortByRisk(pending)
+
+	var got []string
+	for _, p := range pending {
+		got = append(got, p.containerID)
+	}
+	want := []string{"high", "mid", "low", "none"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("处置顺序 = %v, 期望 %v", got, want)
+	}
+}
@@ -0,0 +1,143 @@
+package cleaner
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/tiggoins/zombie-cleaner/internal/config"
+	"github.com/tiggoins/zombie-cleaner/internal/detector"
+	"github.com/tiggoins/zombie-cleaner/internal/metrics"
+	"github.com/tiggoins/zombie-cleaner/internal/process"
+)
+
+// 宿主机僵尸进程处理动作
+const (
+	hostActionSigchldParent = "sigchld_parent"
+	hostActionRestartUnit   = "restart_unit"
+)
+
+// 宿主机僵尸进程处理动作的结果
+const (
+	hostResultSuccess  = "success"
+	hostResultFailed   = "failed"
+	hostResultDryRun   = "dry_run"
+	hostResultCooldown = "cooldown"
+)
+
+// hostZombieGroup 同一父进程的宿主机僵尸进程
+type hostZombieGroup struct {
+	parent     process.Ref
+	parentComm string
+	unit       string
+	zombies    []detector.ZombieInfo
+	// 组内僵尸进程的最长存在时长
+	oldest time.Duration
+}
+
+// groupHostZombies 按父进程分组，按父进程PID排序
+func groupHostZombies(zombies []detector.ZombieInfo) []*hostZombieGroup {
+	index := make(map[process.Ref]*hostZombieGroup)
+	var groups []*hostZombieGroup
+	for _, zombie := range zombies {
+		parent := process.Ref{PID: zombie.PPID, StartTime: zombie.ParentStartTime}
+		g, ok := index[parent]
+		if !ok {
+			g = &hostZombieGroup{parent: parent, parentComm: zombie.ParentComm, unit: zombie.SystemdUnit}
+			index[parent] = g
+			groups = append(groups, g)
+		}
+		g.zombies = append(g.zombies, zombie)
+		g.oldest = max(g.oldest, zombie.Age)
+	}
+	slices.SortFunc(groups, func(a, b *hostZombieGroup) int {
+		return cmp.Compare(a.parent.PID, b.parent.PID)
+	})
+	return groups
+}
+
+// handleHostZombies 按父进程和systemd unit上报宿主机僵尸进程，定时检测中对存在时长达到min_age的分组执行配置的处理动作
+func (c *Cleaner) handleHostZombies(ctx context.Context, zombies []detector.ZombieInfo, periodic bool) {
+	nodeName := metrics.GetNodeName()
+	metrics.HostZombies.Reset()
+
+	cfg := c.cfg().Cleaner
+	restarted := make(map[string]bool)
+	for _, g := range groupHostZombies(zombies) {
+		metrics.HostZombies.WithLabelValues(nodeName, g.parentComm, g.unit).Add(float64(len(g.zombies)))
+		c.logger.Info("宿主机僵尸进程",
+			"parent_pid", g.parent.PID,
+			"parent_comm", g.parentComm,
+			"systemd_unit", g.unit,
+			"count", len(g.zombies),
+			"zombie_age", g.oldest.Round(time.Second),
+			"zombie_pids", c.getZombiePIDs(g.zombies))
+
+		if !periodic || g.oldest < cfg.HostZombies.MinAge {
+			continue
+		}
+		// 宿主机init会自行回收子进程，不向其发送信号
+		if cfg.HostZombies.SigchldParent && g.parent.PID != 1 {
+			c.signalHostParent(g, cfg.DryRun)
+		}
+		// 同一unit下的多个父进程只重启一次
+		if g.unit != "" && !restarted[g.unit] && slices.Contains(cfg.HostZombies.RestartUnits, g.unit) {
+			restarted[g.unit] = true
+			c.restartHostUnit(ctx, g, &cfg)
+		}
+	}
+}
+
+// signalHostParent 向宿主机僵尸进程的父进程发送SIGCHLD
+func (c *Cleaner) signalHostParent(g *hostZombieGroup, dryRun bool) {
+	result := hostResultSuccess
+	defer func() {
+		metrics.HostZombieActions.WithLabelValues(metrics.GetNodeName(), hostActionSigchldParent, result).Inc()
+	}()
+
+	if dryRun {
+		result = hostResultDryRun
+		c.logger.Info("[干跑模式] 将向宿主机僵尸进程的父进程发送SIGCHLD", "parent_pid", g.parent.PID, "parent_comm", g.parentComm)
+		return
+	}
+	if err := c.signal(g.parent, syscall.SIGCHLD); err != nil && !errors.Is(err, process.ErrGone) {
+		result = hostResultFailed
+		c.logger.Warn("向宿主机僵尸进程的父进程发送SIGCHLD失败", "parent_pid", g.parent.PID, "parent_comm", g.parentComm, "error", err)
+		return
+	}
+	c.logger.Info("已向宿主机僵尸进程的父进程发送SIGCHLD", "parent_pid", g.parent.PID, "parent_comm", g.parentComm)
+}
+
+// restartHostUnit 重启宿主机僵尸进程父进程所属的systemd unit，同一unit在restart_cooldown内只重启一次
+func (c *Cleaner) restartHostUnit(ctx context.Context, g *hostZombieGroup, cfg *config.CleanerConfig) {
+	result := hostResultSuccess
+	defer func() {
+		metrics.HostZombieActions.WithLabelValues(metrics.GetNodeName(), hostActionRestartUnit, result).Inc()
+	}()
+
+	now := time.Now()
+	if last, ok := c.unitRestarts[g.unit]; ok && now.Sub(last) < cfg.HostZombies.RestartCooldown {
+		result = hostResultCooldown
+		c.logger.Debug("systemd unit最近已重启，跳过", "systemd_unit", g.unit, "last_restart", last)
+		return
+	}
+	c.unitRestarts[g.unit] = now
+
+	if cfg.DryRun {
+		result = hostResultDryRun
+		c.logger.Info("[干跑模式] 将重启systemd unit", "systemd_unit", g.unit, "parent_pid", g.parent.PID, "count", len(g.zombies))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.HostZombies.RestartTimeout)
+	defer cancel()
+	if err := c.units.RestartUnit(ctx, g.unit); err != nil {
+		result = hostResultFailed
+		c.logger.Error("重启systemd unit失败", "systemd_unit", g.unit, "error", err)
+		return
+	}
+	c.logger.Warn("已重启持有僵尸进程的systemd unit", "systemd_unit", g.unit, "parent_pid", g.parent.PID, "parent_comm", g.parentComm, "count", len(g.zombies))
+}
@@ -0,0 +1,109 @@
+package cleaner
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/tiggoins/zombie-cleaner/internal/config"
+	"github.com/tiggoins/zombie-cleaner/internal/detector"
+	"github.com/tiggoins/zombie-cleaner/internal/logger"
+)
+
+// fakeUnits 记录重启的systemd unit
+type fakeUnits struct {
+	restarted []string
+	// 每次重启请求剩余的超时时间
+	timeouts []time.Duration
+}
+
+func (f *fakeUnits) RestartUnit(ctx context.Context, unit string) error {
+	f.restarted = append(f.restarted, unit)
+	if deadline, ok := ctx.Deadline(); ok {
+		f.timeouts = append(f.timeouts, time.Until(deadline))
+	}
+	return nil
+}
+
+func hostZombie(pid, ppid int, comm, unit string, age time.Duration) detector.ZombieInfo {
+	return detector.ZombieInfo{PID: pid, PPID: ppid, ParentStartTime: uint64(ppid), ParentComm: comm, SystemdUnit: unit, Age: age}
+}
+
+func TestGroupHostZombies(t *testing.T) {
+	groups := groupHostZombies([]detector.ZombieInfo{
+		hostZombie(300, 20, "agent", "agent.service", time.Minute),
+		hostZombie(100, 10, "sshd", "ssh.service", 0),
+		hostZombie(301, 20, "agent", "agent.service", time.Hour),
+	})
+
+	if len(groups) != 2 {
+		t.Fatalf("分组数量 = %d, 期望 2", len(groups))
+	}
+	if groups[0].parent.PID != 10 || groups[1].parent.PID != 20 {
+		t.Errorf("分组应按父进程PID排序: %d, %d", groups[0].parent.PID, groups[1].parent.PID)
+	}
+	if len(groups[1].zombies) != 2 || groups[1].oldest != time.Hour || groups[1].unit != "agent.service" {
+		t.Errorf("agent分组 = %+v", groups[1])
+	}
+}
+
+func TestHandleHostZombiesRestartsUnits(t *testing.T) {
+	zombies := []detector.ZombieInfo{
+		hostZombie(100, 10, "agent", "agent.service", time.Hour),
+		// 同一unit下的另一个父进程
+		hostZombie(101, 11, "agent", "agent.service", time.Hour),
+		// 未列入restart_units
+		hostZombie(200, 20, "sshd", "ssh.service", time.Hour),
+		// 存在时长不足
+		hostZombie(300, 30, "backup", "backup.service", time.Minute),
+	}
+
+	tests := []struct {
+		name     string
+		periodic bool
+		dryRun   bool
+		// 连续执行的检测次数
+		checks int
+		want   []string
+	}{
+		{name: "定时检测中重启", periodic: true, checks: 1, want: []string{"agent.service"}},
+		{name: "冷却期内不重复重启", periodic: true, checks: 3, want: []string{"agent.service"}},
+		{name: "事件触发的检测不执行动作", checks: 1},
+		{name: "干跑模式", periodic: true, dryRun: true, checks: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			units := &fakeUnits{}
+			c := &Cleaner{
+				config: &config.Config{Cleaner: config.CleanerConfig{
+					DryRun:           tt.dryRun,
+					ContainerTimeout: time.Second,
+					HostZombies: config.HostZombieConfig{
+						RestartUnits:    []string{"agent.service", "backup.service"},
+						MinAge:          10 * time.Minute,
+						RestartCooldown: time.Hour,
+						RestartTimeout:  time.Minute,
+					},
+				}},
+				logger:       logger.New("error", "text"),
+				units:        units,
+				unitRestarts: make(map[string]time.Time),
+			}
+
+			for i := 0; i < tt.checks; i++ {
+				c.handleHostZombies(context.Background(), zombies, tt.periodic)
+			}
+			if !reflect.DeepEqual(units.restarted, tt.want) {
+				t.Errorf("重启的unit = %v, 期望 %v", units.restarted, tt.want)
+			}
+			for _, timeout := range units.timeouts {
+				// 使用restart_timeout而不是容器操作超时时间
+				if timeout <= time.Second || timeout > time.Minute {
+					t.Errorf("重启请求的超时时间 = %v, 期望不超过restart_timeout且大于container_timeout", timeout)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package cleaner
+
+import (
+	"context"
+
+	"github.com/tiggoins/zombie-cleaner/internal/detector"
+	"github.com/tiggoins/zombie-cleaner/internal/kube"
+	"github.com/tiggoins/zombie-cleaner/internal/metrics"
+)
+
+// 容器init进程不回收僵尸进程时记录的事件原因
+const eventReasonMissingInitReaper = "MissingInitReaper"
+
+// missingReaperAdvice 缺少init reaper时的修复建议
+const missingReaperAdvice = "容器的init进程（PID 1）不回收已退出的子进程，建议使用tini等init进程作为入口（如docker run --init或ENTRYPOINT [\"tini\", \"--\"]），或在Pod中设置shareProcessNamespace: true由pause进程回收"
+
+// orphanParents 返回僵尸进程中是否有父进程为宿主机init或容器init的
+func orphanParents(zombies []detector.ZombieInfo) (hostInit, containerInit bool) {
+	for _, zombie := range zombies {
+		switch zombie.ParentKind {
+		case detector.ParentHostInit:
+			hostInit = true
+		case detector.ParentContainerInit:
+			containerInit = true
+		}
+	}
+	return hostInit, containerInit
+}
+
+// reportMissingReaper 记录容器缺少init reaper的告警，每个容器只在首次确认时记录一次Pod事件
+func (c *Cleaner) reportMissingReaper(state *ContainerState, container *detector.ContainerMeta, zombies []detector.ZombieInfo) {
+	var pids []int
+	for _, zombie := range zombies {
+		if zombie.ParentKind == detector.ParentContainerInit {
+			pids = append(pids, zombie.PID)
+		}
+	}
+	c.logger.Warn("容器的init进程不回收僵尸进程，缺少init reaper",
+		"container_id", state.ContainerID,
+		"pod_name", container.PodName,
+		"namespace", container.PodNS,
+		"zombie_pids", pids,
+		"advice", missingReaperAdvice)
+	metrics.MissingInitReaper.WithLabelValues(metrics.GetNodeName(), container.PodNS, container.PodName).Inc()
+
+	if state.MissingReaper {
+		return
+	}
+	state.MissingReaper = true
+
+	if c.kubeClient == nil || container.PodUID == "" {
+		return
+	}
+	// 持有状态锁时不做网络请求
+	namespace, name, uid := container.PodNS, container.PodName, container.PodUID
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), c.cfg().Kubernetes.APITimeout)
+		defer cancel()
+		if err := kube.RecordPodWarning(ctx, c.kubeClient, namespace, name, uid, eventReasonMissingInitReaper, missingReaperAdvice); err != nil {
+			c.logger.Debug("记录Pod事件失败", "pod_name", name, "namespace", namespace, "error", err)
+		}
+	}()
+}
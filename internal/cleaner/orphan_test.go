@@ -0,0 +1,87 @@
+package cleaner
+
+import (
+	"testing"
+
+	"github.com/tiggoins/zombie-cleaner/internal/config"
+	"github.com/tiggoins/zombie-cleaner/internal/detector"
+	"github.com/tiggoins/zombie-cleaner/internal/logger"
+	"github.com/tiggoins/zombie-cleaner/internal/policy"
+)
+
+func TestUpdateContainerStatesOrphanZombies(t *testing.T) {
+	container := &detector.ContainerMeta{ID: "c1", PodName: "web-0", PodNS: "prod"}
+	zombie := func(pid int, kind detector.ParentKind) detector.ZombieInfo {
+		return detector.ZombieInfo{PID: pid, StartTime: uint64(pid), PPID: 10, ParentKind: kind, Container: container, IsInContainer: true}
+	}
+
+	tests := []struct {
+		name    string
+		orphans config.OrphanPolicy
+		zombies []detector.ZombieInfo
+		// 期望处置时使用的规则，为空表示不处置
+		wantRule          string
+		wantMissingReaper bool
+	}{
+		{
+			name:     "普通父进程按全局策略处置",
+			orphans:  config.OrphanPolicy{Action: config.PolicyAlert},
+			zombies:  []detector.ZombieInfo{zombie(100, detector.ParentProcess)},
+			wantRule: policy.DefaultRuleName,
+		},
+		{
+			name:              "容器init不回收时默认只告警",
+			orphans:           config.OrphanPolicy{Action: config.PolicyAlert},
+			zombies:           []detector.ZombieInfo{zombie(100, detector.ParentProcess), zombie(101, detector.ParentContainerInit)},
+			wantMissingReaper: true,
+		},
+		{
+			name:              "容器init不回收时按孤儿策略处置",
+			orphans:           config.OrphanPolicy{Action: config.PolicyRemediate, RemediationSteps: []config.RemediationAction{config.ActionStopContainer}},
+			zombies:           []detector.ZombieInfo{zombie(101, detector.ParentContainerInit)},
+			wantRule:          policy.MissingReaperRuleName,
+			wantMissingReaper: true,
+		},
+		{
+			name:    "宿主机init持有的僵尸进程不处置",
+			orphans: config.OrphanPolicy{Action: config.PolicyRemediate},
+			zombies: []detector.ZombieInfo{zombie(100, detector.ParentHostInit)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{Cleaner: config.CleanerConfig{
+				ConfirmCount:     1,
+				Orphans:          tt.orphans,
+				RemediationSteps: config.DefaultRemediationSteps(),
+			}}
+			engine, err := policy.New(&cfg.Cleaner)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c := &Cleaner{
+				config:          cfg,
+				policy:          engine,
+				logger:          logger.New("error", "text"),
+				containerStates: make(map[string]*ContainerState),
+			}
+
+			unactionable := make(map[int]bool)
+			pending := c.updateContainerStates(map[string][]detector.ZombieInfo{"c1": tt.zombies}, true, unactionable)
+
+			var rule string
+			if len(pending) > 0 {
+				rule = pending[0].decision.Rule
+			} else if len(unactionable) != len(tt.zombies) {
+				t.Errorf("不处置的僵尸进程应排除在事件触发阈值之外, 实际排除%d个", len(unactionable))
+			}
+			if rule != tt.wantRule {
+				t.Errorf("处置规则 = %q, 期望 %q", rule, tt.wantRule)
+			}
+			if got := c.containerStates["c1"].MissingReaper; got != tt.wantMissingReaper {
+				t.Errorf("MissingReaper = %v, 期望 %v", got, tt.wantMissingReaper)
+			}
+		})
+	}
+}
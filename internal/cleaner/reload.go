@@ -0,0 +1,122 @@
+package cleaner
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/tiggoins/zombie-cleaner/internal/config"
+	"github.com/tiggoins/zombie-cleaner/internal/logger"
+	"github.com/tiggoins/zombie-cleaner/internal/metrics"
+	"github.com/tiggoins/zombie-cleaner/internal/policy"
+)
+
+// 配置热加载结果，用于日志和指标
+const (
+	ReloadSuccess   = "success"
+	ReloadFailed    = "failed"
+	ReloadUnchanged = "unchanged"
+)
+
+// cfg 返回当前生效的配置
+func (c *Cleaner) cfg() *config.Config {
+	c.configMu.RLock()
+	defer c.configMu.RUnlock()
+	return c.config
+}
+
+func (c *Cleaner) currentPolicy() *policy.Engine {
+	c.configMu.RLock()
+	defer c.configMu.RUnlock()
+	return c.policy
+}
+
+// ReloadFrom 通过loader重新合并配置文件、环境变量和命令行参数，校验失败时保留当前配置。
+// trigger为触发方式，只用于日志
+func (c *Cleaner) ReloadFrom(loader *config.Loader, trigger string) error {
+	next, _, err := loader.Load()
+	if err != nil {
+		c.logger.Error("重新加载配置失败，继续使用当前配置", "trigger", trigger, "path", loader.Path, "error", err)
+		metrics.ConfigReloads.WithLabelValues(metrics.GetNodeName(), ReloadFailed).Inc()
+		return err
+	}
+	return c.Reload(next, trigger)
+}
+
+// Reload 替换当前配置：重新编译白名单、重建处置策略、调整worker数量和检测间隔。
+// 需要重启才能生效的配置项保持原值
+func (c *Cleaner) Reload(next *config.Config, trigger string) error {
+	current := c.cfg()
+	merged, ignored := config.MergeReload(current, next)
+	if len(ignored) > 0 {
+		c.logger.Warn("以下配置项需要重启才能生效，保持原值", "trigger", trigger, "fields", ignored)
+	}
+
+	oldHash, newHash := current.Hash(), merged.Hash()
+	if oldHash == newHash {
+		c.logger.Info("配置未变化", "trigger", trigger, "config_hash", oldHash)
+		metrics.ConfigReloads.WithLabelValues(metrics.GetNodeName(), ReloadUnchanged).Inc()
+		return nil
+	}
+
+	policyEngine, err := policy.New(&merged.Cleaner)
+	if err != nil {
+		err = fmt.Errorf("加载处置策略失败: %w", err)
+		c.logger.Error("重新加载配置失败，继续使用当前配置", "trigger", trigger, "error", err)
+		metrics.ConfigReloads.WithLabelValues(metrics.GetNodeName(), ReloadFailed).Inc()
+		return err
+	}
+	whitelist := compileWhitelist(merged.Cleaner.WhitelistPatterns, c.logger)
+
+	c.configMu.Lock()
+	c.config = merged
+	c.policy = policyEngine
+	c.whitelistRegexes = whitelist
+	c.configMu.Unlock()
+
+	if c.queue != nil {
+		c.queue.Reconfigure(merged.Cleaner.MaxConcurrentContainers, merged.Cleaner.RemediationRetries, merged.Cleaner.RemediationRetryBackoff)
+	}
+	if c.detector != nil {
+		c.detector.SetRiskConfig(merged.Cleaner.Risk)
+		c.detector.SetTrendConfig(merged.Cleaner.Trend)
+		c.detector.SetStuckProcessConfig(merged.Cleaner.StuckProcesses)
+	}
+	if merged.Cleaner.CheckInterval != current.Cleaner.CheckInterval {
+		select {
+		case c.intervalChanged <- struct{}{}:
+		default:
+		}
+	}
+
+	setConfigHash(newHash)
+	metrics.ConfigReloads.WithLabelValues(metrics.GetNodeName(), ReloadSuccess).Inc()
+	c.logger.Info("配置已重新加载",
+		"trigger", trigger,
+		"old_config_hash", oldHash,
+		"config_hash", newHash,
+		"check_interval", merged.Cleaner.CheckInterval,
+		"confirm_count", merged.Cleaner.ConfirmCount,
+		"max_concurrent_containers", merged.Cleaner.MaxConcurrentContainers,
+		"dry_run", merged.Cleaner.DryRun)
+	return nil
+}
+
+// setConfigHash 导出当前生效配置的摘要，同一时刻只保留一个取值
+func setConfigHash(hash string) {
+	metrics.ConfigInfo.Reset()
+	metrics.ConfigInfo.WithLabelValues(metrics.GetNodeName(), hash).Set(1)
+}
+
+// compileWhitelist 编译白名单正则表达式，编译失败的模式记录日志后跳过
+func compileWhitelist(patterns []string, log *logger.Logger) []*regexp.Regexp {
+	var regexes []*regexp.Regexp
+	for _, pattern := range patterns {
+		regex, err := regexp.Compile(pattern)
+		if err != nil {
+			log.Warn("白名单模式编译失败", "pattern", pattern, "error", err)
+			continue
+		}
+		regexes = append(regexes, regex)
+	}
+	return regexes
+}
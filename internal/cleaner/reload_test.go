@@ -0,0 +1,114 @@
+package cleaner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tiggoins/zombie-cleaner/internal/config"
+	"github.com/tiggoins/zombie-cleaner/internal/logger"
+	"github.com/tiggoins/zombie-cleaner/internal/policy"
+)
+
+func newReloadTestCleaner(t *testing.T) *Cleaner {
+	t.Helper()
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Cleaner.WhitelistPatterns = []string{"^kube-system-.*"}
+	engine, err := policy.New(&cfg.Cleaner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logger.New("error", "text")
+	return &Cleaner{
+		config:           cfg,
+		policy:           engine,
+		whitelistRegexes: compileWhitelist(cfg.Cleaner.WhitelistPatterns, log),
+		intervalChanged:  make(chan struct{}, 1),
+		logger:           log,
+	}
+}
+
+func writeReloadConfig(t *testing.T, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReloadFromAppliesNewConfig(t *testing.T) {
+	c := newReloadTestCleaner(t)
+	path := writeReloadConfig(t, `
+cleaner:
+  check_interval: 1m
+  confirm_count: 5
+  whitelist_patterns:
+    - "^monitoring-.*"
+  container_runtime: containerd
+`)
+
+	if err := c.ReloadFrom(&config.Loader{Path: path}, "test"); err != nil {
+		t.Fatalf("ReloadFrom返回错误: %v", err)
+	}
+
+	cfg := c.cfg()
+	if cfg.Cleaner.CheckInterval != time.Minute || cfg.Cleaner.ConfirmCount != 5 {
+		t.Errorf("CheckInterval = %v, ConfirmCount = %d", cfg.Cleaner.CheckInterval, cfg.Cleaner.ConfirmCount)
+	}
+	if c.isWhitelisted("kube-system-dns") || !c.isWhitelisted("monitoring-agent") {
+		t.Error("白名单应替换为新配置")
+	}
+	if got := c.currentPolicy().Evaluate(policy.Input{}).ConfirmCount; got != 5 {
+		t.Errorf("处置策略的确认次数 = %d, 期望5", got)
+	}
+	// 运行时需要重启才能切换
+	if cfg.Cleaner.ContainerRuntime != config.RuntimeDocker {
+		t.Errorf("ContainerRuntime = %s, 应保持原值", cfg.Cleaner.ContainerRuntime)
+	}
+	select {
+	case <-c.intervalChanged:
+	default:
+		t.Error("检测间隔变化后应通知重置定时器")
+	}
+}
+
+func TestReloadFromKeepsConfigOnError(t *testing.T) {
+	c := newReloadTestCleaner(t)
+	before := c.cfg()
+
+	path := writeReloadConfig(t, "cleaner:\n  confirm_count: 0\n  whitelist_patterns: [\"^web-.*\"]\n")
+	if err := c.ReloadFrom(&config.Loader{Path: path}, "test"); err == nil {
+		t.Fatal("校验失败时ReloadFrom应返回错误")
+	}
+	if c.cfg() != before {
+		t.Error("校验失败时应保留原配置")
+	}
+	if !c.isWhitelisted("kube-system-dns") || c.isWhitelisted("web-0") {
+		t.Error("校验失败时应保留原白名单")
+	}
+}
+
+func TestReloadUnchanged(t *testing.T) {
+	c := newReloadTestCleaner(t)
+	before := c.cfg()
+
+	next := *before
+	// 只修改需要重启的配置项，生效配置不变
+	next.Logger.Level = "debug"
+	if err := c.Reload(&next, "test"); err != nil {
+		t.Fatalf("Reload返回错误: %v", err)
+	}
+	if c.cfg() != before {
+		t.Error("生效配置未变化时不应替换")
+	}
+	select {
+	case <-c.intervalChanged:
+		t.Error("检测间隔未变化时不应通知")
+	default:
+	}
+}
@@ -0,0 +1,326 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"syscall"
+	"time"
+
+	"github.com/tiggoins/zombie-cleaner/internal/config"
+	"github.com/tiggoins/zombie-cleaner/internal/detector"
+	"github.com/tiggoins/zombie-cleaner/internal/kube"
+	"github.com/tiggoins/zombie-cleaner/internal/metrics"
+	"github.com/tiggoins/zombie-cleaner/internal/process"
+	"github.com/tiggoins/zombie-cleaner/internal/runtime"
+)
+
+// 处置步骤执行结果
+const (
+	// 执行后僵尸进程已全部消失
+	StepResultResolved = "resolved"
+	// 执行成功但僵尸进程仍然存在
+	StepResultUnresolved = "unresolved"
+	// 执行失败
+	StepResultFailed = "failed"
+	// 不满足执行条件而跳过
+	StepResultSkipped = "skipped"
+	// 干跑模式下仅模拟执行
+	StepResultDryRun = "dry_run"
+	// 被PodDisruptionBudget阻止，不再继续升级
+	StepResultBlocked = "blocked"
+	// Pod驱逐已被接受，由kubelet完成优雅终止，不再继续升级
+	StepResultEvicted = "evicted"
+	// 处置预算已耗尽，不再执行破坏性步骤
+	StepResultThrottled = "throttled"
+)
+
+const (
+	// 等待僵尸进程消失时的复查间隔
+	zombieRecheckInterval = time.Second
+	// 每个容器保留的处置记录数量上限
+	maxRemediationRecords = 20
+)
+
+// RemediationRecord 单个处置步骤的执行记录
+type RemediationRecord struct {
+	Action    config.RemediationAction
+	StartedAt time.Time
+	Duration  time.Duration
+	Result    string
+	Error     string
+}
+
+// runRemediationLadder 按顺序执行已启用的处置步骤，僵尸进程消失后立即停止升级。
+// 返回阶梯的最终结果：resolved、evicted（驱逐已被接受）、blocked、throttled或unresolved
+func (c *Cleaner) runRemediationLadder(ctx context.Context, containerID string, state *ContainerState, zombies []detector.ZombieInfo, steps []config.RemediationStep) string {
+	containerLog := c.logger.WithContainer(containerID, state.PodName, state.Namespace)
+	nodeName := metrics.GetNodeName()
+	// 每次处置最多占用一次预算，重试同一次处置时不再重复占用
+	c.stateMutex.RLock()
+	budgetCharged := state.BudgetCharged
+	c.stateMutex.RUnlock()
+
+	for _, step := range steps {
+		if !step.Enabled {
+			continue
+		}
+		if ctx.Err() != nil {
+			return StepResultUnresolved
+		}
+		// 通过Kubernetes处置时不能绕过kubelet直接停止容器，由驱逐完成优雅终止
+		if step.Action == config.ActionStopContainer && c.podRemediator != nil {
+			containerLog.Debug("已启用Kubernetes处置后端，跳过运行时停止容器步骤")
+			continue
+		}
+		// 发送信号的步骤无害，预算只限制停止、删除容器和kill shim
+		if isDestructive(step.Action) && !budgetCharged {
+			if scope, allowed := c.chargeBudget(ctx); !allowed {
+				containerLog.Warn("处置预算已耗尽，停止升级", "action", step.Action, "budget_scope", scope)
+				metrics.RemediationsThrottled.WithLabelValues(nodeName, scope).Inc()
+				metrics.RemediationSteps.WithLabelValues(nodeName, string(step.Action), StepResultThrottled).Inc()
+				return StepResultThrottled
+			}
+			budgetCharged = true
+			c.stateMutex.Lock()
+			state.BudgetCharged = true
+			c.stateMutex.Unlock()
+		}
+
+		c.stateMutex.Lock()
+		state.CurrentStep = step.Action
+		c.stateMutex.Unlock()
+
+		record := RemediationRecord{Action: step.Action, StartedAt: time.Now()}
+		result, err := c.executeStep(ctx, step, containerID, zombies)
+		record.Duration = time.Since(record.StartedAt)
+		record.Result = result
+		if err != nil {
+			record.Error = err.Error()
+		}
+
+		c.stateMutex.Lock()
+		state.Remediation = append(state.Remediation, record)
+		if len(state.Remediation) > maxRemediationRecords {
+			state.Remediation = state.Remediation[len(state.Remediation)-maxRemediationRecords:]
+		}
+		c.stateMutex.Unlock()
+
+		metrics.RemediationSteps.WithLabelValues(nodeName, string(step.Action), result).Inc()
+
+		if err != nil {
+			containerLog.Warn("处置步骤执行失败",
+				"action", step.Action,
+				"result", result,
+				"duration", record.Duration,
+				"error", err)
+		} else {
+			containerLog.Info("处置步骤执行完成",
+				"action", step.Action,
+				"result", result,
+				"duration", record.Duration)
+		}
+
+		if result == StepResultResolved {
+			return StepResultResolved
+		}
+		if result == StepResultEvicted {
+			// 继续升级到kill shim会打断kubelet的优雅终止
+			containerLog.Info("Pod驱逐已被接受，由kubelet完成优雅终止，停止升级")
+			return StepResultEvicted
+		}
+		if result == StepResultBlocked {
+			// 继续升级到运行时删除或kill shim会绕过PDB
+			containerLog.Warn("处置被PodDisruptionBudget阻止，停止升级")
+			return StepResultBlocked
+		}
+	}
+
+	return StepResultUnresolved
+}
+
+// isDestructive 判断处置步骤是否会终止容器
+func isDestructive(action config.RemediationAction) bool {
+	switch action {
+	case config.ActionStopContainer, config.ActionRemoveContainer, config.ActionKillShim:
+		return true
+	}
+	return false
+}
+
+// chargeBudget 占用一次处置预算，拒绝时返回耗尽的预算范围。
+// 未配置预算时直接允许；干跑模式不占用预算，避免影响其他节点的真实处置
+func (c *Cleaner) chargeBudget(ctx context.Context) (string, bool) {
+	if c.budget == nil || c.cfg().Cleaner.DryRun {
+		return "", true
+	}
+	allowed, scope := c.budget.Allow(ctx)
+	return scope, allowed
+}
+
+// runStep 执行单个处置步骤，并在步骤超时时间内等待僵尸进程消失
+func (c *Cleaner) runStep(ctx context.Context, step config.RemediationStep, containerID string, zombies []detector.ZombieInfo) (string, error) {
+	if c.cfg().Cleaner.DryRun {
+		c.logger.Info("干跑模式：模拟执行处置步骤", "container_id", containerID, "action", step.Action)
+		return StepResultDryRun, nil
+	}
+
+	switch step.Action {
+	case config.ActionSigchldParent:
+		// SIGCHLD对父进程无害，只排除宿主机init
+		parents := zombieParents(zombies, false)
+		if len(parents) == 0 {
+			return StepResultSkipped, nil
+		}
+		if err := c.signalProcesses(parents, syscall.SIGCHLD); err != nil {
+			return StepResultFailed, err
+		}
+	case config.ActionSignalParent:
+		// 只向容器内的父进程发送信号，避免误伤shim等宿主机进程
+		parents := zombieParents(zombies, true)
+		if len(parents) == 0 {
+			return StepResultSkipped, nil
+		}
+		sig, err := config.ParseSignal(step.Signal)
+		if err != nil {
+			return StepResultFailed, err
+		}
+		if err := c.signalProcesses(parents, sig); err != nil {
+			return StepResultFailed, err
+		}
+	case config.ActionStopContainer:
+		if c.detector.ContainerRuntime == nil {
+			return StepResultFailed, errors.New("没有可用的容器运行时")
+		}
+		// 留出容器操作超时时间，让运行时在优雅期后完成强制终止
+		stopCtx, cancel := context.WithTimeout(ctx, step.Timeout+c.cfg().Cleaner.ContainerTimeout)
+		err := c.detector.ContainerRuntime.StopContainer(stopCtx, containerID, step.Timeout)
+		if stopCtx.Err() == context.DeadlineExceeded {
+			metrics.ContainerOperationTimeouts.WithLabelValues(metrics.GetNodeName(), "stop").Inc()
+		}
+		cancel()
+		if err != nil {
+			return StepResultFailed, err
+		}
+	case config.ActionRemoveContainer:
+		if len(zombies) == 0 || zombies[0].Container == nil {
+			return StepResultSkipped, nil
+		}
+		evicted, err := c.removeContainerOrPod(ctx, zombies[0].Container, step.Timeout)
+		if err != nil {
+			if errors.Is(err, kube.ErrEvictionBlocked) {
+				return StepResultBlocked, err
+			}
+			return StepResultFailed, err
+		}
+		if evicted {
+			// Pod在优雅终止期内仍可能存在，至少等待宽限期加一次API超时
+			timeout := c.cfg().Kubernetes.EvictionGracePeriod + c.cfg().Kubernetes.APITimeout
+			if step.Timeout > timeout {
+				timeout = step.Timeout
+			}
+			if c.waitZombiesGone(ctx, zombies, timeout) {
+				return StepResultResolved, nil
+			}
+			return StepResultEvicted, nil
+		}
+	case config.ActionKillShim:
+		if c.detector.ContainerRuntime == nil {
+			return StepResultFailed, errors.New("没有可用的容器运行时，无法清理shim进程")
+		}
+		if stuck, blocked := c.shimKillBlocked(containerID); blocked {
+			return StepResultSkipped, fmt.Errorf("容器内有%d个不可中断（D状态）进程，kill shim无法清理: %v", len(stuck), stuckSummary(stuck))
+		}
+		container := runtime.ContainerMeta{ID: containerID}
+		if len(zombies) > 0 && zombies[0].Container != nil {
+			container = *zombies[0].Container
+		}
+		if err := c.detector.ContainerRuntime.KillContainerShim(ctx, container, shimKillOptions(step)); err != nil {
+			if errors.Is(err, runtime.ErrShimShared) {
+				// 未启用force时不影响同一shim下的其他容器
+				return StepResultSkipped, err
+			}
+			return StepResultFailed, err
+		}
+	default:
+		return StepResultSkipped, fmt.Errorf("未知的处置步骤: %s", step.Action)
+	}
+
+	if c.waitZombiesGone(ctx, zombies, step.Timeout) {
+		return StepResultResolved, nil
+	}
+	return StepResultUnresolved, nil
+}
+
+// shimKillOptions 根据kill_shim步骤的配置生成清理shim的参数
+func shimKillOptions(step config.RemediationStep) runtime.ShimKillOptions {
+	return runtime.ShimKillOptions{Force: step.Force, GracePeriod: step.GracePeriod}
+}
+
+// zombieParents 返回僵尸进程去重后的父进程，inContainerOnly为true时只保留容器PID树内的父进程
+func zombieParents(zombies []detector.ZombieInfo, inContainerOnly bool) []process.Ref {
+	seen := make(map[int]bool)
+	var parents []process.Ref
+	for _, zombie := range zombies {
+		ppid := zombie.PPID
+		if ppid <= 1 || seen[ppid] {
+			continue
+		}
+		if inContainerOnly && (zombie.Container == nil || !zombie.Container.PIDSet[ppid]) {
+			continue
+		}
+		seen[ppid] = true
+		parents = append(parents, process.Ref{PID: ppid, StartTime: zombie.ParentStartTime})
+	}
+	return parents
+}
+
+// signalProcesses 通过进程句柄向一组进程发送信号，全部失败时返回错误。
+// 进程已退出或PID已被复用时跳过，其僵尸子进程会被init接管回收；无法确认身份的进程不发送破坏性信号
+func (c *Cleaner) signalProcesses(refs []process.Ref, sig syscall.Signal) error {
+	var errs []error
+	for _, ref := range refs {
+		err := c.signal(ref, sig)
+		if errors.Is(err, process.ErrUnverified) {
+			c.logger.Warn("父进程不在检测快照中，无法确认身份，拒绝发送信号", "pid", ref.PID, "signal", sig.String())
+		}
+		if err != nil && !errors.Is(err, process.ErrGone) {
+			errs = append(errs, fmt.Errorf("向进程%d发送%s失败: %w", ref.PID, sig, err))
+		}
+	}
+	if len(errs) > 0 && len(errs) == len(refs) {
+		return errors.Join(errs...)
+	}
+	return nil
+}
+
+// waitZombiesGone 在timeout内周期性复查，所有僵尸进程都被回收时返回true
+func (c *Cleaner) waitZombiesGone(ctx context.Context, zombies []detector.ZombieInfo, timeout time.Duration) bool {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(zombieRecheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if !c.anyZombieAlive(zombies) {
+			return true
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-deadline.C:
+			return !c.anyZombieAlive(zombies)
+		case <-ticker.C:
+		}
+	}
+}
+
+// anyZombieAlive 判断是否仍有僵尸进程未被回收，PID被复用后的新进程不算
+func (c *Cleaner) anyZombieAlive(zombies []detector.ZombieInfo) bool {
+	for _, zombie := range zombies {
+		if c.detector.IsZombie(zombie.Ref()) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,411 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/tiggoins/zombie-cleaner/internal/budget"
+	"github.com/tiggoins/zombie-cleaner/internal/config"
+	"github.com/tiggoins/zombie-cleaner/internal/detector"
+	"github.com/tiggoins/zombie-cleaner/internal/kube"
+	"github.com/tiggoins/zombie-cleaner/internal/logger"
+	"github.com/tiggoins/zombie-cleaner/internal/process"
+	"github.com/tiggoins/zombie-cleaner/internal/runtime"
+)
+
+func TestZombieParents(t *testing.T) {
+	container := &detector.ContainerMeta{ID: "c1", PIDSet: map[int]bool{100: true, 101: true}}
+	zombies := []detector.ZombieInfo{
+		{PID: 200, PPID: 100, ParentStartTime: 1100, Container: container},
+		// 同一父进程只保留一次
+		{PID: 201, PPID: 100, ParentStartTime: 1100, Container: container},
+		// 父进程为宿主机init
+		{PID: 202, PPID: 1, Container: container},
+		// 父进程在容器PID树外（如shim）
+		{PID: 203, PPID: 50, Container: container},
+		{PID: 204, PPID: 101, ParentStartTime: 1101, Container: container},
+		// 未归属容器
+		{PID: 205, PPID: 60},
+	}
+
+	tests := []struct {
+		name            string
+		inContainerOnly bool
+		want            []process.Ref
+	}{
+		{
+			name:            "所有父进程",
+			inContainerOnly: false,
+			want:            []process.Ref{{PID: 100, StartTime: 1100}, {PID: 50}, {PID: 101, StartTime: 1101}, {PID: 60}},
+		},
+		{
+			name:            "只保留容器内父进程",
+			inContainerOnly: true,
+			want:            []process.Ref{{PID: 100, StartTime: 1100}, {PID: 101, StartTime: 1101}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := zombieParents(zombies, tt.inContainerOnly)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("zombieParents() = %v, 期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunStepSignalParentRefusesUnverifiedParent(t *testing.T) {
+	cmd := exec.Command("sleep", "60")
+	if err := cmd.Start(); err != nil {
+		t.Skipf("无法启动sleep: %v", err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+
+	c := &Cleaner{
+		config: &config.Config{},
+		logger: logger.New("error", "text"),
+		signal: func(ref process.Ref, sig syscall.Signal) error {
+			return process.Signal(process.DefaultRoot, ref, sig)
+		},
+	}
+	parent := cmd.Process.Pid
+	// 父进程不在检测快照中，ParentStartTime为0
+	zombies := []detector.ZombieInfo{{
+		PID:       parent + 1,
+		PPID:      parent,
+		Container: &runtime.ContainerMeta{ID: "app", PIDSet: map[int]bool{parent: true}},
+	}}
+	step := config.RemediationStep{Action: config.ActionSignalParent, Signal: "SIGKILL", Timeout: time.Second}
+	result, err := c.runStep(context.Background(), step, "app", zombies)
+	if result != StepResultFailed || !errors.Is(err, process.ErrUnverified) {
+		t.Fatalf("runStep() = %s, %v, 期望 %s和ErrUnverified", result, err, StepResultFailed)
+	}
+	if err := cmd.Process.Signal(syscall.Signal(0)); err != nil {
+		t.Errorf("无法确认身份的父进程不应被终止: %v", err)
+	}
+}
+
+// newLadderTestCleaner 创建处置步骤执行结果由results决定的清理器，并记录执行过的步骤
+func newLadderTestCleaner(results map[config.RemediationAction]string, executed *[]config.RemediationAction) *Cleaner {
+	c := &Cleaner{
+		config: &config.Config{},
+		logger: logger.New("error", "text"),
+	}
+	c.executeStep = func(_ context.Context, step config.RemediationStep, _ string, _ []detector.ZombieInfo) (string, error) {
+		*executed = append(*executed, step.Action)
+		result := results[step.Action]
+		if result == StepResultFailed || result == StepResultBlocked {
+			return result, errors.New("step failed")
+		}
+		return result, nil
+	}
+	return c
+}
+
+func TestRunRemediationLadder(t *testing.T) {
+	steps := []config.RemediationStep{
+		{Action: config.ActionSigchldParent, Enabled: true, Timeout: time.Second},
+		{Action: config.ActionSignalParent, Enabled: false, Timeout: time.Second},
+		{Action: config.ActionStopContainer, Enabled: true, Timeout: time.Second},
+		{Action: config.ActionRemoveContainer, Enabled: true, Timeout: time.Second},
+		{Action: config.ActionKillShim, Enabled: true, Timeout: time.Second},
+	}
+
+	tests := []struct {
+		name         string
+		results      map[config.RemediationAction]string
+		wantResult   string
+		wantExecuted []config.RemediationAction
+	}{
+		{
+			name:         "第一步解决后停止升级",
+			results:      map[config.RemediationAction]string{config.ActionSigchldParent: StepResultResolved},
+			wantResult:   StepResultResolved,
+			wantExecuted: []config.RemediationAction{config.ActionSigchldParent},
+		},
+		{
+			name: "失败后继续升级",
+			results: map[config.RemediationAction]string{
+				config.ActionSigchldParent:   StepResultUnresolved,
+				config.ActionStopContainer:   StepResultFailed,
+				config.ActionRemoveContainer: StepResultResolved,
+			},
+			wantResult:   StepResultResolved,
+			wantExecuted: []config.RemediationAction{config.ActionSigchldParent, config.ActionStopContainer, config.ActionRemoveContainer},
+		},
+		{
+			name: "被PDB阻止后停止升级",
+			results: map[config.RemediationAction]string{
+				config.ActionSigchldParent:   StepResultUnresolved,
+				config.ActionStopContainer:   StepResultUnresolved,
+				config.ActionRemoveContainer: StepResultBlocked,
+			},
+			wantResult:   StepResultBlocked,
+			wantExecuted: []config.RemediationAction{config.ActionSigchldParent, config.ActionStopContainer, config.ActionRemoveContainer},
+		},
+		{
+			name:         "全部未解决",
+			results:      map[config.RemediationAction]string{},
+			wantResult:   StepResultUnresolved,
+			wantExecuted: []config.RemediationAction{config.ActionSigchldParent, config.ActionStopContainer, config.ActionRemoveContainer, config.ActionKillShim},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var executed []config.RemediationAction
+			c := newLadderTestCleaner(tt.results, &executed)
+			state := &ContainerState{ContainerID: "c1"}
+
+			result := c.runRemediationLadder(context.Background(), "c1", state, nil, steps)
+			if result != tt.wantResult {
+				t.Errorf("result = %q, 期望 %q", result, tt.wantResult)
+			}
+			if !reflect.DeepEqual(executed, tt.wantExecuted) {
+				t.Errorf("执行的步骤 = %v, 期望 %v", executed, tt.wantExecuted)
+			}
+			if len(state.Remediation) != len(tt.wantExecuted) {
+				t.Errorf("处置记录数 = %d, 期望 %d", len(state.Remediation), len(tt.wantExecuted))
+			}
+		})
+	}
+}
+
+func TestRunRemediationLadderStopsOnCancel(t *testing.T) {
+	var executed []config.RemediationAction
+	c := newLadderTestCleaner(map[config.RemediationAction]string{}, &executed)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	steps := []config.RemediationStep{{Action: config.ActionSigchldParent, Enabled: true, Timeout: time.Second}}
+	if result := c.runRemediationLadder(ctx, "c1", &ContainerState{}, nil, steps); result != StepResultUnresolved {
+		t.Errorf("上下文取消后result = %q, 期望 %q", result, StepResultUnresolved)
+	}
+	if len(executed) != 0 {
+		t.Errorf("上下文取消后不应执行步骤: %v", executed)
+	}
+}
+
+func TestRunRemediationLadderKubernetesBackend(t *testing.T) {
+	steps := []config.RemediationStep{
+		{Action: config.ActionStopContainer, Enabled: true, Timeout: time.Second},
+		{Action: config.ActionRemoveContainer, Enabled: true, Timeout: time.Second},
+		{Action: config.ActionKillShim, Enabled: true, Timeout: time.Second},
+	}
+
+	var executed []config.RemediationAction
+	c := newLadderTestCleaner(map[config.RemediationAction]string{
+		config.ActionRemoveContainer: StepResultEvicted,
+	}, &executed)
+	c.podRemediator = kube.NewPodRemediator(fake.NewSimpleClientset(), time.Second, c.logger)
+
+	if result := c.runRemediationLadder(context.Background(), "c1", &ContainerState{}, nil, steps); result != StepResultEvicted {
+		t.Errorf("result = %q, 期望 %q", result, StepResultEvicted)
+	}
+	// 跳过运行时停止，驱逐后不再升级到kill shim
+	want := []config.RemediationAction{config.ActionRemoveContainer}
+	if !reflect.DeepEqual(executed, want) {
+		t.Errorf("执行的步骤 = %v, 期望 %v", executed, want)
+	}
+}
+
+func TestRunRemediationLadderBudget(t *testing.T) {
+	steps := []config.RemediationStep{
+		{Action: config.ActionSigchldParent, Enabled: true, Timeout: time.Second},
+		{Action: config.ActionStopContainer, Enabled: true, Timeout: time.Second},
+		{Action: config.ActionRemoveContainer, Enabled: true, Timeout: time.Second},
+	}
+
+	results := map[config.RemediationAction]string{}
+	var executed []config.RemediationAction
+	c := newLadderTestCleaner(results, &executed)
+	// 每小时只允许一次破坏性处置
+	c.budget = budget.New(budget.NewTokenBucket(1), nil, c.logger)
+
+	// SIGCHLD即可解决时不占用预算
+	results[config.ActionSigchldParent] = StepResultResolved
+	if result := c.runRemediationLadder(context.Background(), "c1", &ContainerState{}, nil, steps); result != StepResultResolved {
+		t.Fatalf("result = %q, 期望 %q", result, StepResultResolved)
+	}
+
+	// 升级到破坏性步骤时占用一次预算，同一次处置的后续步骤不再重复占用
+	results[config.ActionSigchldParent] = StepResultUnresolved
+	results[config.ActionRemoveContainer] = StepResultResolved
+	executed = nil
+	if result := c.runRemediationLadder(context.Background(), "c2", &ContainerState{}, nil, steps); result != StepResultResolved {
+		t.Fatalf("result = %q, 期望 %q", result, StepResultResolved)
+	}
+	want := []config.RemediationAction{config.ActionSigchldParent, config.ActionStopContainer, config.ActionRemoveContainer}
+	if !reflect.DeepEqual(executed, want) {
+		t.Errorf("执行的步骤 = %v, 期望 %v", executed, want)
+	}
+
+	// 重试同一次处置时不再占用预算
+	retried := &ContainerState{}
+	results[config.ActionRemoveContainer] = StepResultUnresolved
+	c.budget = budget.New(budget.NewTokenBucket(1), nil, c.logger)
+	for attempt := 0; attempt < 3; attempt++ {
+		if result := c.runRemediationLadder(context.Background(), "c2", retried, nil, steps); result != StepResultUnresolved {
+			t.Fatalf("第%d次尝试result = %q, 期望 %q", attempt+1, result, StepResultUnresolved)
+		}
+	}
+	if !retried.BudgetCharged {
+		t.Error("占用预算后BudgetCharged应为true")
+	}
+
+	// 预算耗尽后只执行无害步骤
+	executed = nil
+	if result := c.runRemediationLadder(context.Background(), "c3", &ContainerState{}, nil, steps); result != StepResultThrottled {
+		t.Fatalf("result = %q, 期望 %q", result, StepResultThrottled)
+	}
+	want = []config.RemediationAction{config.ActionSigchldParent}
+	if !reflect.DeepEqual(executed, want) {
+		t.Errorf("预算耗尽后执行的步骤 = %v, 期望 %v", executed, want)
+	}
+}
+
+// shimRuntime 只实现KillContainerShim的运行时，记录收到的参数
+type shimRuntime struct {
+	runtime.ContainerRuntimeInterface
+	err       error
+	container runtime.ContainerMeta
+	opts      runtime.ShimKillOptions
+}
+
+func (r *shimRuntime) KillContainerShim(_ context.Context, container runtime.ContainerMeta, opts runtime.ShimKillOptions) error {
+	r.container, r.opts = container, opts
+	return r.err
+}
+
+func TestRunStepKillShim(t *testing.T) {
+	container := &detector.ContainerMeta{ID: "c1", PID: 4242}
+	// PID不存在，视为僵尸进程已消失
+	zombies := []detector.ZombieInfo{{PID: 1 << 30, Container: container}}
+	step := config.RemediationStep{Action: config.ActionKillShim, Enabled: true, Timeout: time.Second, Force: true, GracePeriod: 3 * time.Second}
+
+	tests := []struct {
+		name       string
+		err        error
+		wantResult string
+	}{
+		{name: "清理成功", wantResult: StepResultResolved},
+		{name: "shim被其他容器共享", err: runtime.ErrShimShared, wantResult: StepResultSkipped},
+		{name: "清理失败", err: errors.New("permission denied"), wantResult: StepResultFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := &shimRuntime{err: tt.err}
+			c := &Cleaner{
+				config:   &config.Config{},
+				logger:   logger.New("error", "text"),
+				detector: &detector.Detector{ContainerRuntime: rt},
+			}
+			result, _ := c.runStep(context.Background(), step, "c1", zombies)
+			if result != tt.wantResult {
+				t.Errorf("runStep() = %s, 期望 %s", result, tt.wantResult)
+			}
+			if rt.container.PID != 4242 {
+				t.Errorf("传给运行时的容器init进程 = %d, 期望 4242", rt.container.PID)
+			}
+			if want := (runtime.ShimKillOptions{Force: true, GracePeriod: 3 * time.Second}); rt.opts != want {
+				t.Errorf("清理参数 = %+v, 期望 %+v", rt.opts, want)
+			}
+		})
+	}
+}
+
+func TestRunStepKillShimSkipsStuckContainer(t *testing.T) {
+	zombies := []detector.ZombieInfo{{PID: 1 << 30, Container: &detector.ContainerMeta{ID: "c1"}}}
+	step := config.RemediationStep{Action: config.ActionKillShim, Enabled: true, Timeout: time.Second}
+	stuck := []detector.StuckProcess{{PID: 500, TID: 500, Comm: "cp", WChan: "rpc_wait_bit_killable", CgroupPath: "/kubepods/pod1/c1"}}
+
+	tests := []struct {
+		name         string
+		skipKillShim bool
+		wantResult   string
+		wantKilled   bool
+	}{
+		{name: "容器内有D状态进程时跳过", skipKillShim: true, wantResult: StepResultSkipped},
+		{name: "未启用跳过", skipKillShim: false, wantResult: StepResultResolved, wantKilled: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := &shimRuntime{}
+			cfg := &config.Config{}
+			cfg.Cleaner.StuckProcesses.SkipKillShim = tt.skipKillShim
+			c := &Cleaner{
+				config:         cfg,
+				logger:         logger.New("error", "text"),
+				detector:       &detector.Detector{ContainerRuntime: rt},
+				stuckProcesses: func() []detector.StuckProcess { return stuck },
+			}
+			result, err := c.runStep(context.Background(), step, "c1", zombies)
+			if result != tt.wantResult {
+				t.Errorf("runStep() = %s (%v), 期望 %s", result, err, tt.wantResult)
+			}
+			if killed := rt.container.ID != ""; killed != tt.wantKilled {
+				t.Errorf("是否调用了kill shim = %v, 期望 %v", killed, tt.wantKilled)
+			}
+		})
+	}
+}
+
+// removeRuntime 只实现RemoveContainer的运行时，记录删除的容器
+type removeRuntime struct {
+	runtime.ContainerRuntimeInterface
+	removed []string
+}
+
+func (r *removeRuntime) RemoveContainer(_ context.Context, containerID string, _ time.Duration) error {
+	r.removed = append(r.removed, containerID)
+	return nil
+}
+
+func TestRunStepRemoveContainerBlockedByPDB(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		if action.GetSubresource() != "eviction" {
+			return false, nil, nil
+		}
+		return true, nil, apierrors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 10)
+	})
+
+	rt := &removeRuntime{}
+	cfg := &config.Config{}
+	cfg.Kubernetes.APITimeout = time.Second
+	c := &Cleaner{
+		config:   cfg,
+		logger:   logger.New("error", "text"),
+		detector: &detector.Detector{ContainerRuntime: rt},
+	}
+	c.podRemediator = kube.NewPodRemediator(client, time.Second, c.logger)
+
+	container := &detector.ContainerMeta{ID: "c1", PodName: "web-0", PodNS: "prod", PodUID: "uid-1"}
+	zombies := []detector.ZombieInfo{{PID: 1 << 30, Container: container}}
+	step := config.RemediationStep{Action: config.ActionRemoveContainer, Enabled: true, Timeout: time.Second}
+
+	result, err := c.runStep(context.Background(), step, "c1", zombies)
+	if result != StepResultBlocked || !errors.Is(err, kube.ErrEvictionBlocked) {
+		t.Errorf("runStep() = %s, %v, 期望 %s和ErrEvictionBlocked", result, err, StepResultBlocked)
+	}
+	// PDB拒绝时不能回退到运行时删除
+	if len(rt.removed) != 0 {
+		t.Errorf("PDB拒绝后仍通过运行时删除了容器: %v", rt.removed)
+	}
+}
@@ -0,0 +1,108 @@
+package cleaner
+
+import (
+	"github.com/tiggoins/zombie-cleaner/internal/config"
+	"github.com/tiggoins/zombie-cleaner/internal/process"
+	"github.com/tiggoins/zombie-cleaner/internal/state"
+)
+
+// restoreStates 从状态存储恢复容器状态。
+// 这里不列举容器校验存活：列举时共享的超时会让后续容器被误记为inspect超时，进而被kill shim。
+// 已不存在的容器不会再被检测到，恢复的状态会在cleanupOldStates中按LastDetected自然过期。
+// 在New中调用，此时尚未开始检测，不需要加锁
+func (c *Cleaner) restoreStates() {
+	if c.stateStore == nil {
+		return
+	}
+
+	records, err := c.stateStore.Load()
+	if err != nil {
+		c.logger.Warn("加载容器状态失败，从空状态开始", "error", err)
+		return
+	}
+	if len(records) == 0 {
+		return
+	}
+
+	for _, record := range records {
+		c.containerStates[record.ContainerID] = stateFromRecord(record)
+	}
+
+	c.logger.Info("已恢复容器状态", "restored", len(c.containerStates))
+}
+
+// persistStates 把当前容器状态写入状态存储
+func (c *Cleaner) persistStates() {
+	if c.stateStore == nil {
+		return
+	}
+
+	// 在生成快照之前加锁，保证快照按生成顺序写入，避免旧快照覆盖新快照
+	c.persistMutex.Lock()
+	defer c.persistMutex.Unlock()
+
+	c.stateMutex.RLock()
+	records := make([]state.ContainerRecord, 0, len(c.containerStates))
+	for _, s := range c.containerStates {
+		records = append(records, recordFromState(s))
+	}
+	c.stateMutex.RUnlock()
+
+	if err := c.stateStore.Save(records); err != nil {
+		c.logger.Warn("保存容器状态失败", "error", err)
+	}
+}
+
+func recordFromState(s *ContainerState) state.ContainerRecord {
+	record := state.ContainerRecord{
+		ContainerID:    s.ContainerID,
+		PodName:        s.PodName,
+		Namespace:      s.Namespace,
+		DetectionCount: s.DetectionCount,
+		LastDetected:   s.LastDetected,
+	}
+	for ref, sighting := range s.Zombies {
+		record.Zombies = append(record.Zombies, state.ZombieRecord{
+			PID:       ref.PID,
+			StartTime: ref.StartTime,
+			Count:     sighting.Count,
+			Since:     sighting.Since,
+		})
+	}
+	for _, r := range s.Remediation {
+		record.Remediation = append(record.Remediation, state.RemediationRecord{
+			Action:    string(r.Action),
+			StartedAt: r.StartedAt,
+			Duration:  r.Duration,
+			Result:    r.Result,
+			Error:     r.Error,
+		})
+	}
+	return record
+}
+
+func stateFromRecord(record state.ContainerRecord) *ContainerState {
+	s := &ContainerState{
+		ContainerID:    record.ContainerID,
+		PodName:        record.PodName,
+		Namespace:      record.Namespace,
+		DetectionCount: record.DetectionCount,
+		LastDetected:   record.LastDetected,
+	}
+	if len(record.Zombies) > 0 {
+		s.Zombies = make(map[process.Ref]*ZombieSighting, len(record.Zombies))
+		for _, z := range record.Zombies {
+			s.Zombies[process.Ref{PID: z.PID, StartTime: z.StartTime}] = &ZombieSighting{Count: z.Count, Since: z.Since}
+		}
+	}
+	for _, r := range record.Remediation {
+		s.Remediation = append(s.Remediation, RemediationRecord{
+			Action:    config.RemediationAction(r.Action),
+			StartedAt: r.StartedAt,
+			Duration:  r.Duration,
+			Result:    r.Result,
+			Error:     r.Error,
+		})
+	}
+	return s
+}
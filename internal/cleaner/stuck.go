@@ -0,0 +1,40 @@
+package cleaner
+
+import (
+	"time"
+
+	"github.com/tiggoins/zombie-cleaner/internal/detector"
+)
+
+// stuckInContainer 返回最近一次检测中属于该容器的D状态进程
+func (c *Cleaner) stuckInContainer(containerID string) []detector.StuckProcess {
+	if c.stuckProcesses == nil {
+		return nil
+	}
+	var stuck []detector.StuckProcess
+	for _, sp := range c.stuckProcesses() {
+		if sp.InContainer(containerID) {
+			stuck = append(stuck, sp)
+		}
+	}
+	return stuck
+}
+
+// shimKillBlocked 判断容器内是否有D状态进程导致kill shim无法生效。
+// D状态进程不响应SIGKILL，shim退出后这些进程仍然存在，僵尸进程也不会被回收
+func (c *Cleaner) shimKillBlocked(containerID string) ([]detector.StuckProcess, bool) {
+	if !c.cfg().Cleaner.StuckProcesses.SkipKillShim {
+		return nil, false
+	}
+	stuck := c.stuckInContainer(containerID)
+	return stuck, len(stuck) > 0
+}
+
+// stuckSummary 日志中展示的D状态进程信息
+func stuckSummary(stuck []detector.StuckProcess) []string {
+	summary := make([]string, 0, len(stuck))
+	for _, sp := range stuck {
+		summary = append(summary, sp.Comm+"@"+sp.WChan+"("+sp.Duration.Round(time.Second).String()+")")
+	}
+	return summary
+}
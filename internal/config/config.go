@@ -1,10 +1,11 @@
 package config
 
 import (
-	"os"
+	"fmt"
+	"regexp"
 	"time"
 
-	"gopkg.in/yaml.v3"
+	"github.com/tiggoins/zombie-cleaner/internal/systemd"
 )
 
 type ContainerRuntime string
@@ -12,12 +13,42 @@ type ContainerRuntime string
 const (
 	RuntimeDocker     ContainerRuntime = "docker"
 	RuntimeContainerd ContainerRuntime = "containerd"
+	RuntimeCRI        ContainerRuntime = "cri"
+	RuntimeAuto       ContainerRuntime = "auto" // 探测节点上所有可用的运行时并同时使用
+)
+
+type AttributionMode string
+
+const (
+	AttributionPIDTree AttributionMode = "pidtree"
+	AttributionCgroup  AttributionMode = "cgroup"
+)
+
+type RemediationBackend string
+
+const (
+	// 通过容器运行时直接停止/删除容器
+	BackendRuntime RemediationBackend = "runtime"
+	// 通过Kubernetes API驱逐或删除Pod，API Server不可达时回退到运行时
+	BackendKubernetes RemediationBackend = "kubernetes"
 )
 
 type Config struct {
-	Cleaner CleanerConfig `yaml:"cleaner"`
-	Metrics MetricsConfig `yaml:"metrics"`
-	Logger  LoggerConfig  `yaml:"logger"`
+	Cleaner    CleanerConfig    `yaml:"cleaner"`
+	Metrics    MetricsConfig    `yaml:"metrics"`
+	Logger     LoggerConfig     `yaml:"logger"`
+	Kubernetes KubernetesConfig `yaml:"kubernetes"`
+}
+
+type KubernetesConfig struct {
+	// kubeconfig路径，为空时使用集群内ServiceAccount
+	Kubeconfig string `yaml:"kubeconfig"`
+	// API请求超时时间
+	APITimeout time.Duration `yaml:"api_timeout"`
+	// 驱逐/删除Pod时的优雅终止时间
+	EvictionGracePeriod time.Duration `yaml:"eviction_grace_period"`
+	// 运行时未暴露Pod注解时，是否通过Kubernetes API读取
+	AnnotationLookup bool `yaml:"annotation_lookup"`
 }
 
 type MetricsConfig struct {
@@ -33,30 +64,256 @@ type LoggerConfig struct {
 type CleanerConfig struct {
 	// 检测间隔
 	CheckInterval time.Duration `yaml:"check_interval"`
-	// 确认次数 - 连续几次发现僵尸进程才执行清理
+	// 确认次数 - 同一僵尸进程（按PID和启动时间识别）连续几次被发现才执行清理
 	ConfirmCount int `yaml:"confirm_count"`
+	// 僵尸进程存在时长达到该值时不必等待确认次数即确认，为0时只按确认次数确认
+	MinZombieAge time.Duration `yaml:"min_zombie_age"`
+	// PID耗尽风险评分
+	Risk RiskConfig `yaml:"risk"`
+	// 僵尸进程数量趋势
+	Trend TrendConfig `yaml:"trend"`
 	// 容器操作超时时间
 	ContainerTimeout time.Duration `yaml:"container_timeout"`
-	// 最大并发处理容器数量
+	// 最大并发处理容器数量，即处置worker数量
 	MaxConcurrentContainers int `yaml:"max_concurrent_containers"`
+	// 处置阶梯结束后僵尸进程仍然存在时的最大重试次数，为0时不重试
+	RemediationRetries int `yaml:"remediation_retries"`
+	// 第一次重试前的等待时间，之后每次翻倍
+	RemediationRetryBackoff time.Duration `yaml:"remediation_retry_backoff"`
 	// 白名单容器名称模式
 	WhitelistPatterns []string `yaml:"whitelist_patterns"`
 	// 是否启用干跑模式（只检测不清理）
 	DryRun bool `yaml:"dry_run"`
-	// 容器运行时类型 ("docker", "containerd",默认为"docker")
+	// 容器运行时类型 ("docker", "containerd", "cri", "auto",默认为"docker")
+	// auto探测runtimes中配置的Docker、containerd、CRI-O和CRI运行时，同时使用所有可用的运行时
 	ContainerRuntime ContainerRuntime `yaml:"container_runtime"`
+	// 已废弃，改用runtimes.cri.endpoint；非空时覆盖runtimes.cri.endpoint
+	CRIEndpoint string `yaml:"cri_endpoint"`
+	// 各容器运行时的连接参数
+	Runtimes RuntimesConfig `yaml:"runtimes"`
+	// 僵尸进程归属方式 ("pidtree", "cgroup",默认为"pidtree")
+	// cgroup模式按/proc/<pid>/cgroup匹配容器，匹配失败时回退到PID树
+	AttributionMode AttributionMode `yaml:"attribution_mode"`
+	// 是否启用事件驱动检测（订阅内核netlink proc connector，定时轮询保留为一致性检查）
+	EventDetection bool `yaml:"event_detection"`
+	// 事件模式下未回收僵尸进程达到该数量时立即触发一次检测
+	EventTriggerThreshold int `yaml:"event_trigger_threshold"`
+	// 两次事件触发检测之间的最小间隔
+	EventMinTriggerInterval time.Duration `yaml:"event_min_trigger_interval"`
+	// 处置阶梯，按顺序执行已启用的步骤，每步之后复查僵尸进程是否已消失
+	RemediationSteps []RemediationStep `yaml:"remediation_steps"`
+	// 删除容器步骤使用的后端 ("runtime", "kubernetes",默认为"runtime")
+	RemediationBackend RemediationBackend `yaml:"remediation_backend"`
+	// 处置策略规则
+	Policy PolicyConfig `yaml:"policy"`
+	// 容器init进程不回收僵尸进程（缺少init reaper）时的处置策略
+	Orphans OrphanPolicy `yaml:"orphans"`
+	// 宿主机僵尸进程（不属于任何容器）的处理
+	HostZombies HostZombieConfig `yaml:"host_zombies"`
+	// 不可中断（D状态）进程的检测
+	StuckProcesses StuckProcessConfig `yaml:"stuck_processes"`
+	// 容器状态持久化文件路径，为空时不持久化，重启后确认计数从0开始
+	StateFile string `yaml:"state_file"`
+	// 处置预算，预算耗尽时只告警不处置
+	Budget BudgetConfig `yaml:"budget"`
+}
+
+// RuntimesConfig 各容器运行时的连接参数。container_runtime为auto时按这些地址探测
+type RuntimesConfig struct {
+	Docker     DockerRuntimeConfig     `yaml:"docker"`
+	Containerd ContainerdRuntimeConfig `yaml:"containerd"`
+	// 通用CRI运行时，仅在container_runtime为"cri"或"auto"时使用
+	CRI CRIRuntimeConfig `yaml:"cri"`
+	// CRI-O，container_runtime为auto时探测
+	CRIO CRIRuntimeConfig `yaml:"crio"`
+}
+
+// DockerRuntimeConfig Docker守护进程连接参数
+type DockerRuntimeConfig struct {
+	// 守护进程地址，如unix:///var/run/docker.sock或tcp://host:2376，为空时使用DOCKER_HOST环境变量或默认socket
+	Host string `yaml:"host"`
+	// API版本，为空时与守护进程协商
+	APIVersion string `yaml:"api_version"`
+	// 连接TCP地址时的TLS证书
+	TLS TLSConfig `yaml:"tls"`
+	// 连接超时时间
+	DialTimeout time.Duration `yaml:"dial_timeout"`
+}
+
+// TLSConfig TLS证书文件，均为空时不使用TLS
+type TLSConfig struct {
+	CAFile   string `yaml:"ca_file"`
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+}
+
+// Enabled 是否配置了TLS
+func (t TLSConfig) Enabled() bool {
+	return t.CAFile != "" || t.CertFile != "" || t.KeyFile != ""
+}
+
+// ContainerdRuntimeConfig containerd连接参数
+type ContainerdRuntimeConfig struct {
+	// socket路径
+	Address string `yaml:"address"`
+	// 扫描的containerd命名空间，Kubernetes使用k8s.io，Docker使用moby
+	Namespaces []string `yaml:"namespaces"`
+	// 连接超时时间
+	DialTimeout time.Duration `yaml:"dial_timeout"`
 }
 
-func Load(configFile string) *Config {
-	cfg := &Config{
+// CRIRuntimeConfig CRI RuntimeService连接参数
+type CRIRuntimeConfig struct {
+	// 服务地址，支持"unix:///path"或直接的socket路径
+	Endpoint string `yaml:"endpoint"`
+	// 连接超时时间
+	DialTimeout time.Duration `yaml:"dial_timeout"`
+}
+
+// RiskConfig PID耗尽风险评分。评分综合僵尸进程数量、增长速度和剩余PID余量，取值0-100
+type RiskConfig struct {
+	// 是否按风险评分触发处置：评分达到threshold时不必等待确认次数，同一周期内优先处置评分高的容器。
+	// 未启用时仍然计算评分并记录到日志和指标中
+	Enabled bool `yaml:"enabled"`
+	// 触发处置的风险评分
+	Threshold int `yaml:"threshold"`
+	// 僵尸进程数量达到该值时，僵尸进程数量一项为满分
+	ZombieSaturation int `yaml:"zombie_saturation"`
+	// 按当前增长速度预计耗尽PID的时间短于该值时，增长速度一项开始计入评分
+	ExhaustionHorizon time.Duration `yaml:"exhaustion_horizon"`
+	// cgroup文件系统挂载点，用于读取容器cgroup的pids.current和pids.max
+	CgroupRoot string `yaml:"cgroup_root"`
+}
+
+// TrendConfig 按滑动窗口内每个容器的僵尸进程数量判断趋势：稳定（stable）、持续增长（growing）或突增（spiking）
+type TrendConfig struct {
+	// 是否按趋势提前确认：持续增长或突增的容器中同一僵尸进程在周期检测中至少出现两次
+	// （或存在时长达到min_zombie_age）即确认，不必等满确认次数。未启用时仍然计算趋势并记录到日志和指标中
+	Escalate bool `yaml:"escalate"`
+	// 滑动窗口长度，增长速度按窗口内的采样计算
+	Window time.Duration `yaml:"window"`
+	// 窗口内至少有3次采样、数量整体上升且增加量达到该值时判定为持续增长
+	GrowthMinIncrease int `yaml:"growth_min_increase"`
+	// 相邻两次采样之间增加量达到该值时判定为突增
+	SpikeIncrease int `yaml:"spike_increase"`
+}
+
+// HostZombieConfig 宿主机僵尸进程按父进程和父进程所属的systemd unit分组上报，可选地向父进程发送SIGCHLD或重启unit。
+// 处理动作只在定时检测中、僵尸进程存在时长达到min_age后执行，干跑模式下只记录日志
+type HostZombieConfig struct {
+	// 是否向宿主机僵尸进程的父进程发送SIGCHLD（不向宿主机init发送）
+	SigchldParent bool `yaml:"sigchld_parent"`
+	// 允许重启的systemd service，父进程属于这些service时重启service，为空时不重启
+	RestartUnits []string `yaml:"restart_units"`
+	// 僵尸进程存在时长达到该值后才执行处理动作
+	MinAge time.Duration `yaml:"min_age"`
+	// 同一个unit两次重启之间的最小间隔
+	RestartCooldown time.Duration `yaml:"restart_cooldown"`
+	// 提交重启任务的超时时间，包括连接systemd和等待RestartUnit返回，不包括unit重启本身
+	RestartTimeout time.Duration `yaml:"restart_timeout"`
+	// systemd私有D-Bus socket路径，重启unit时使用
+	SystemdSocket string `yaml:"systemd_socket"`
+}
+
+// StuckProcessConfig 检测处于不可中断睡眠（D状态）的进程，如阻塞在失效NFS挂载上的进程。
+// 这类进程无法被信号终止，停止容器或kill shim都无法清理，还会导致容器inspect超时
+type StuckProcessConfig struct {
+	// 是否检测D状态进程，启用后读取其wchan和内核调用栈（需要CAP_SYS_ADMIN，不可读时省略）
+	Enabled bool `yaml:"enabled"`
+	// 在D状态持续该时长后才上报，短暂的磁盘IO等待不计入
+	MinAge time.Duration `yaml:"min_age"`
+	// 是否同时检查每个线程，主线程之外的线程阻塞时也能发现，节点线程很多时开销较大
+	ScanThreads bool `yaml:"scan_threads"`
+	// 容器内有D状态进程时是否跳过kill_shim（包括inspect超时容器的shim清理）
+	SkipKillShim bool `yaml:"skip_kill_shim"`
+}
+
+// BudgetConfig 处置预算
+type BudgetConfig struct {
+	// 本节点每小时最多执行破坏性处置的次数，为0时不限制
+	MaxRemediationsPerHour int `yaml:"max_remediations_per_hour"`
+	// 集群级预算，通过ConfigMap在所有节点间协调
+	Cluster ClusterBudgetConfig `yaml:"cluster"`
+}
+
+// ClusterBudgetConfig 集群级处置预算
+type ClusterBudgetConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// 全集群每小时最多执行破坏性处置的次数
+	MaxRemediationsPerHour int `yaml:"max_remediations_per_hour"`
+	// 记录预算使用情况的ConfigMap
+	ConfigMapNamespace string `yaml:"configmap_namespace"`
+	ConfigMapName      string `yaml:"configmap_name"`
+}
+
+// Default 返回内置默认配置
+func Default() *Config {
+	return &Config{
 		Cleaner: CleanerConfig{
 			CheckInterval:           5 * time.Minute,
 			ConfirmCount:            3,
 			ContainerTimeout:        10 * time.Second,
 			MaxConcurrentContainers: 10,
+			RemediationRetries:      2,
+			RemediationRetryBackoff: 30 * time.Second,
 			WhitelistPatterns:       []string{},
 			DryRun:                  false,
 			ContainerRuntime:        RuntimeDocker,
+			Runtimes: RuntimesConfig{
+				Docker: DockerRuntimeConfig{
+					DialTimeout: 10 * time.Second,
+				},
+				Containerd: ContainerdRuntimeConfig{
+					Address:     "/run/containerd/containerd.sock",
+					Namespaces:  []string{"k8s.io"},
+					DialTimeout: 10 * time.Second,
+				},
+				CRI: CRIRuntimeConfig{
+					Endpoint:    "unix:///run/containerd/containerd.sock",
+					DialTimeout: 10 * time.Second,
+				},
+				CRIO: CRIRuntimeConfig{
+					Endpoint:    "unix:///var/run/crio/crio.sock",
+					DialTimeout: 10 * time.Second,
+				},
+			},
+			AttributionMode:         AttributionPIDTree,
+			EventDetection:          false,
+			EventTriggerThreshold:   50,
+			EventMinTriggerInterval: 30 * time.Second,
+			RemediationSteps:        DefaultRemediationSteps(),
+			RemediationBackend:      BackendRuntime,
+			Orphans: OrphanPolicy{
+				Action: PolicyAlert,
+			},
+			StuckProcesses: StuckProcessConfig{
+				MinAge:       time.Minute,
+				SkipKillShim: true,
+			},
+			HostZombies: HostZombieConfig{
+				MinAge:          10 * time.Minute,
+				RestartCooldown: time.Hour,
+				RestartTimeout:  10 * time.Second,
+				SystemdSocket:   systemd.DefaultSocket,
+			},
+			Risk: RiskConfig{
+				Threshold:         80,
+				ZombieSaturation:  100,
+				ExhaustionHorizon: time.Hour,
+				CgroupRoot:        "/sys/fs/cgroup",
+			},
+			Trend: TrendConfig{
+				Escalate:          true,
+				Window:            15 * time.Minute,
+				GrowthMinIncrease: 3,
+				SpikeIncrease:     20,
+			},
+			Budget: BudgetConfig{
+				Cluster: ClusterBudgetConfig{
+					ConfigMapNamespace: "kube-system",
+					ConfigMapName:      "zombie-cleaner-budget",
+				},
+			},
 		},
 		Metrics: MetricsConfig{
 			Enabled: true,
@@ -66,38 +323,209 @@ func Load(configFile string) *Config {
 			Level:  "info",
 			Format: "json",
 		},
+		Kubernetes: KubernetesConfig{
+			APITimeout:          10 * time.Second,
+			EvictionGracePeriod: 30 * time.Second,
+		},
 	}
+}
 
-	// 如果配置文件存在，则加载
-	if _, err := os.Stat(configFile); err == nil {
-		data, err := os.ReadFile(configFile)
-		if err != nil {
-			panic("读取配置文件失败")
-		}
-
-		if err := yaml.Unmarshal(data, cfg); err != nil {
-			panic("解析配置文件失败")
-		}
-	}
-
-	cfg.validate()
-	return cfg
+// Load 依次合并默认配置、配置文件和ZOMBIE_CLEANER_*环境变量并校验，文件不存在时跳过
+func Load(configFile string) (*Config, error) {
+	cfg, _, err := (&Loader{Path: configFile}).Load()
+	return cfg, err
 }
 
-func (c *Config) validate() {
+// validate 校验全部配置项，返回包含每个错误路径的*ValidationError
+func (c *Config) validate() error {
+	errs := &ValidationError{}
 	if c.Cleaner.CheckInterval <= 0 {
-		panic("检测间隔必须大于0")
+		errs.add("cleaner.check_interval", "检测间隔必须大于0")
 	}
 	if c.Cleaner.ConfirmCount <= 0 {
-		panic("确认次数必须大于0")
+		errs.add("cleaner.confirm_count", "确认次数必须大于0")
+	}
+	if c.Cleaner.MinZombieAge < 0 {
+		errs.add("cleaner.min_zombie_age", "僵尸进程最小存在时长不能为负数")
+	}
+	c.Cleaner.Risk.validate(errs)
+	c.Cleaner.Trend.validate(errs)
+	c.Cleaner.HostZombies.validate(errs)
+	if c.Cleaner.StuckProcesses.MinAge < 0 {
+		errs.add("cleaner.stuck_processes.min_age", "D状态进程最小持续时长不能为负数")
 	}
 	if c.Cleaner.ContainerTimeout <= 0 {
-		panic("容器超时时间必须大于0")
+		errs.add("cleaner.container_timeout", "容器超时时间必须大于0")
 	}
 	if c.Cleaner.MaxConcurrentContainers <= 0 {
 		c.Cleaner.MaxConcurrentContainers = 10
 	}
-	if c.Cleaner.ContainerRuntime != RuntimeContainerd && c.Cleaner.ContainerRuntime != RuntimeDocker {
-		panic("容器运行时必须是docker或containerd")
+	if c.Cleaner.RemediationRetries < 0 {
+		errs.add("cleaner.remediation_retries", "处置重试次数不能为负数")
+	}
+	if c.Cleaner.RemediationRetries > 0 && c.Cleaner.RemediationRetryBackoff <= 0 {
+		errs.add("cleaner.remediation_retry_backoff", "处置重试等待时间必须大于0")
+	}
+	for i, pattern := range c.Cleaner.WhitelistPatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			errs.add(fmt.Sprintf("cleaner.whitelist_patterns[%d]", i), "白名单正则表达式无效: %v", err)
+		}
+	}
+	if c.Cleaner.CRIEndpoint != "" {
+		c.Cleaner.Runtimes.CRI.Endpoint = c.Cleaner.CRIEndpoint
+	}
+	c.Cleaner.Runtimes.validate(c.Cleaner.ContainerRuntime, errs)
+	switch c.Cleaner.ContainerRuntime {
+	case RuntimeDocker, RuntimeContainerd, RuntimeCRI, RuntimeAuto:
+	default:
+		errs.add("cleaner.container_runtime", "容器运行时必须是docker、containerd、cri或auto，实际为%q", c.Cleaner.ContainerRuntime)
+	}
+	if c.Cleaner.AttributionMode != AttributionPIDTree && c.Cleaner.AttributionMode != AttributionCgroup {
+		errs.add("cleaner.attribution_mode", "僵尸进程归属方式必须是pidtree或cgroup，实际为%q", c.Cleaner.AttributionMode)
+	}
+	if c.Cleaner.EventDetection {
+		if c.Cleaner.EventTriggerThreshold <= 0 {
+			errs.add("cleaner.event_trigger_threshold", "事件触发阈值必须大于0")
+		}
+		if c.Cleaner.EventMinTriggerInterval <= 0 {
+			errs.add("cleaner.event_min_trigger_interval", "事件触发最小间隔必须大于0")
+		}
+	}
+	errs.merge(validateRemediationSteps(c.Cleaner.RemediationSteps))
+	errs.merge(validatePolicy(c.Cleaner.Policy, c.Cleaner.RemediationSteps))
+	errs.merge(validateOrphanPolicy(c.Cleaner.Orphans, c.Cleaner.RemediationSteps))
+	if c.Cleaner.RemediationBackend != BackendRuntime && c.Cleaner.RemediationBackend != BackendKubernetes {
+		errs.add("cleaner.remediation_backend", "处置后端必须是runtime或kubernetes，实际为%q", c.Cleaner.RemediationBackend)
+	}
+	if c.Cleaner.Budget.MaxRemediationsPerHour < 0 {
+		errs.add("cleaner.budget.max_remediations_per_hour", "本节点每小时处置次数上限不能为负数")
+	}
+	if c.Cleaner.Budget.Cluster.Enabled {
+		if c.Cleaner.Budget.Cluster.MaxRemediationsPerHour <= 0 {
+			errs.add("cleaner.budget.cluster.max_remediations_per_hour", "集群每小时处置次数上限必须大于0")
+		}
+		if c.Cleaner.Budget.Cluster.ConfigMapNamespace == "" {
+			errs.add("cleaner.budget.cluster.configmap_namespace", "集群处置预算的ConfigMap命名空间不能为空")
+		}
+		if c.Cleaner.Budget.Cluster.ConfigMapName == "" {
+			errs.add("cleaner.budget.cluster.configmap_name", "集群处置预算的ConfigMap名称不能为空")
+		}
+	}
+	if c.Cleaner.RemediationBackend == BackendKubernetes || c.Kubernetes.AnnotationLookup || c.Cleaner.Budget.Cluster.Enabled {
+		if c.Kubernetes.APITimeout <= 0 {
+			errs.add("kubernetes.api_timeout", "Kubernetes API超时时间必须大于0")
+		}
+		if c.Kubernetes.EvictionGracePeriod < 0 {
+			errs.add("kubernetes.eviction_grace_period", "Pod优雅终止时间不能为负数")
+		}
+	}
+	switch c.Logger.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		errs.add("logger.level", "日志级别必须是debug、info、warn或error，实际为%q", c.Logger.Level)
+	}
+	switch c.Logger.Format {
+	case "json", "text":
+	default:
+		errs.add("logger.format", "日志格式必须是json或text，实际为%q", c.Logger.Format)
+	}
+	if c.Metrics.Enabled && (c.Metrics.Port <= 0 || c.Metrics.Port > 65535) {
+		errs.add("metrics.port", "指标端口必须在1-65535之间，实际为%d", c.Metrics.Port)
+	}
+	return errs.err()
+}
+
+// validate 校验风险评分参数，未启用时也会计算评分，因此总是校验
+func (r *RiskConfig) validate(errs *ValidationError) {
+	if r.Threshold <= 0 || r.Threshold > 100 {
+		errs.add("cleaner.risk.threshold", "风险评分阈值必须在1-100之间，实际为%d", r.Threshold)
+	}
+	if r.ZombieSaturation <= 0 {
+		errs.add("cleaner.risk.zombie_saturation", "僵尸进程数量饱和值必须大于0")
+	}
+	if r.ExhaustionHorizon <= 0 {
+		errs.add("cleaner.risk.exhaustion_horizon", "PID耗尽预警时间必须大于0")
+	}
+	if r.CgroupRoot == "" {
+		errs.add("cleaner.risk.cgroup_root", "cgroup挂载点不能为空")
+	}
+}
+
+// validate 校验趋势参数，未启用提前确认时也会计算趋势，因此总是校验
+func (t *TrendConfig) validate(errs *ValidationError) {
+	if t.Window <= 0 {
+		errs.add("cleaner.trend.window", "趋势滑动窗口长度必须大于0")
+	}
+	if t.GrowthMinIncrease <= 0 {
+		errs.add("cleaner.trend.growth_min_increase", "判定持续增长的增加量必须大于0")
+	}
+	if t.SpikeIncrease <= 0 {
+		errs.add("cleaner.trend.spike_increase", "判定突增的增加量必须大于0")
+	}
+}
+
+// validate 校验宿主机僵尸进程的处理参数
+func (h *HostZombieConfig) validate(errs *ValidationError) {
+	if h.MinAge < 0 {
+		errs.add("cleaner.host_zombies.min_age", "宿主机僵尸进程最小存在时长不能为负数")
+	}
+	if h.RestartCooldown < 0 {
+		errs.add("cleaner.host_zombies.restart_cooldown", "systemd unit重启间隔不能为负数")
+	}
+	if h.RestartTimeout <= 0 {
+		errs.add("cleaner.host_zombies.restart_timeout", "systemd unit重启超时时间必须大于0")
+	}
+	for i, unit := range h.RestartUnits {
+		if !systemd.ValidUnitName(unit) {
+			errs.add(fmt.Sprintf("cleaner.host_zombies.restart_units[%d]", i), "只能重启systemd service，实际为%q", unit)
+		}
+	}
+	if len(h.RestartUnits) > 0 && h.SystemdSocket == "" {
+		errs.add("cleaner.host_zombies.systemd_socket", "配置了restart_units时systemd socket路径不能为空")
+	}
+}
+
+// validate 校验container_runtime会用到的运行时的连接参数
+func (r *RuntimesConfig) validate(mode ContainerRuntime, errs *ValidationError) {
+	uses := func(runtime ContainerRuntime) bool { return mode == runtime || mode == RuntimeAuto }
+
+	if uses(RuntimeDocker) {
+		tls := r.Docker.TLS
+		if tls.CertFile != "" && tls.KeyFile == "" {
+			errs.add("cleaner.runtimes.docker.tls.cert_file", "配置cert_file时必须同时配置key_file")
+		}
+		if tls.KeyFile != "" && tls.CertFile == "" {
+			errs.add("cleaner.runtimes.docker.tls.key_file", "配置key_file时必须同时配置cert_file")
+		}
+		if r.Docker.DialTimeout <= 0 {
+			errs.add("cleaner.runtimes.docker.dial_timeout", "连接超时时间必须大于0")
+		}
+	}
+	if uses(RuntimeContainerd) {
+		if r.Containerd.Address == "" {
+			errs.add("cleaner.runtimes.containerd.address", "containerd socket路径不能为空")
+		}
+		if len(r.Containerd.Namespaces) == 0 {
+			errs.add("cleaner.runtimes.containerd.namespaces", "至少需要一个containerd命名空间")
+		}
+		for i, ns := range r.Containerd.Namespaces {
+			if ns == "" {
+				errs.add(fmt.Sprintf("cleaner.runtimes.containerd.namespaces[%d]", i), "命名空间不能为空")
+			}
+		}
+		if r.Containerd.DialTimeout <= 0 {
+			errs.add("cleaner.runtimes.containerd.dial_timeout", "连接超时时间必须大于0")
+		}
+	}
+	if uses(RuntimeCRI) {
+		if r.CRI.Endpoint == "" && mode == RuntimeCRI {
+			errs.add("cleaner.runtimes.cri.endpoint", "CRI运行时服务地址不能为空")
+		}
+		if r.CRI.DialTimeout <= 0 {
+			errs.add("cleaner.runtimes.cri.dial_timeout", "连接超时时间必须大于0")
+		}
+	}
+	if mode == RuntimeAuto && r.CRIO.DialTimeout <= 0 {
+		errs.add("cleaner.runtimes.crio.dial_timeout", "连接超时时间必须大于0")
 	}
 }
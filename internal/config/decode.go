@@ -0,0 +1,161 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// decoder 按配置结构体逐层解析YAML节点。与yaml.Unmarshal不同，遇到未知的键或无法解析的值时
+// 记录错误路径后继续解析，一次报告全部错误
+type decoder struct {
+	// 值的来源，配置文件为SourceFile
+	source string
+	errs   *ValidationError
+	// 解析过的路径及其在配置文件中的行号
+	lines map[string]int
+}
+
+func newDecoder(source string, errs *ValidationError) *decoder {
+	return &decoder{source: source, errs: errs, lines: make(map[string]int)}
+}
+
+// decode 将node写入v，v必须可寻址。结构体只覆盖YAML中出现的字段，其余字段保持原值
+func (d *decoder) decode(node *yaml.Node, v reflect.Value, path string) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	d.lines[path] = node.Line
+
+	switch {
+	case v.Kind() == reflect.Struct:
+		if node.ShortTag() == "!!null" {
+			return
+		}
+		if node.Kind != yaml.MappingNode {
+			d.fail(path, node, "期望映射，实际为%s", nodeKind(node))
+			return
+		}
+		d.decodeStruct(node, v, path)
+
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Struct:
+		if node.ShortTag() == "!!null" {
+			v.Set(reflect.Zero(v.Type()))
+			return
+		}
+		if node.Kind != yaml.SequenceNode {
+			d.fail(path, node, "期望列表，实际为%s", nodeKind(node))
+			return
+		}
+		list := reflect.MakeSlice(v.Type(), len(node.Content), len(node.Content))
+		for i, item := range node.Content {
+			elem := list.Index(i)
+			// 解码空映射以应用元素类型自身的默认值，如RemediationStep默认启用
+			empty := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			if err := empty.Decode(elem.Addr().Interface()); err != nil {
+				d.fail(path, node, "%v", err)
+				return
+			}
+			d.decode(item, elem, fmt.Sprintf("%s[%d]", path, i))
+		}
+		v.Set(list)
+
+	default:
+		if err := node.Decode(v.Addr().Interface()); err != nil {
+			if node.Kind == yaml.ScalarNode {
+				d.fail(path, node, "无法将%q解析为%s", node.Value, typeName(v.Type()))
+			} else {
+				d.fail(path, node, "期望%s，实际为%s", typeName(v.Type()), nodeKind(node))
+			}
+		}
+	}
+}
+
+func (d *decoder) decodeStruct(node *yaml.Node, v reflect.Value, path string) {
+	fields := make(map[string]int)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		if name != "" && name != "-" {
+			fields[name] = i
+		}
+	}
+
+	seen := make(map[string]bool)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		fieldPath := key.Value
+		if path != "" {
+			fieldPath = path + "." + key.Value
+		}
+		if seen[key.Value] {
+			d.fail(fieldPath, key, "配置项重复")
+			continue
+		}
+		seen[key.Value] = true
+
+		index, ok := fields[key.Value]
+		if !ok {
+			d.fail(fieldPath, key, "未知的配置项")
+			continue
+		}
+		d.decode(value, v.Field(index), fieldPath)
+	}
+}
+
+func (d *decoder) fail(path string, node *yaml.Node, format string, args ...any) {
+	fe := FieldError{Path: path, Message: fmt.Sprintf(format, args...)}
+	if d.source == SourceFile {
+		fe.Line = node.Line
+	} else {
+		fe.Source = d.source
+	}
+	d.errs.Errors = append(d.errs.Errors, fe)
+}
+
+// line 返回path在配置文件中的行号，path本身未出现时返回最近的上级路径的行号
+func (d *decoder) line(path string) int {
+	for path != "" {
+		if line, ok := d.lines[path]; ok {
+			return line
+		}
+		path = parentPath(path)
+	}
+	return 0
+}
+
+func nodeKind(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "映射"
+	case yaml.SequenceNode:
+		return "列表"
+	default:
+		return fmt.Sprintf("%q", node.Value)
+	}
+}
+
+func typeName(t reflect.Type) string {
+	if t == durationType {
+		return "时间间隔（如30s、5m）"
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "布尔值"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "整数"
+	case reflect.String:
+		return "字符串"
+	case reflect.Slice:
+		return "列表"
+	case reflect.Map:
+		return "映射"
+	default:
+		return t.String()
+	}
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// FieldError 单个配置项的错误
+type FieldError struct {
+	// YAML路径，如cleaner.remediation_steps[1].timeout，YAML语法错误时为空
+	Path string
+	// 配置文件中的行号，值不是来自配置文件时为0
+	Line int
+	// 值来自环境变量或命令行参数时为其来源，如env:ZOMBIE_CLEANER_CHECK_INTERVAL
+	Source  string
+	Message string
+}
+
+func (e FieldError) Error() string {
+	location := e.Path
+	switch {
+	case e.Line > 0:
+		location = strings.TrimSpace(fmt.Sprintf("%s 第%d行", e.Path, e.Line))
+	case e.Source != "":
+		location = strings.TrimSpace(e.Path + " " + e.Source)
+	}
+	if location == "" {
+		return e.Message
+	}
+	return location + ": " + e.Message
+}
+
+// ValidationError 配置中的全部错误，按发现顺序排列
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Errors) == 1 {
+		return "配置无效: " + e.Errors[0].Error()
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "配置无效，共%d个错误:", len(e.Errors))
+	for _, fe := range e.Errors {
+		b.WriteString("\n  - ")
+		b.WriteString(fe.Error())
+	}
+	return b.String()
+}
+
+// add 记录path的错误
+func (e *ValidationError) add(path, format string, args ...any) {
+	e.Errors = append(e.Errors, FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// merge 合并其他校验函数返回的错误
+func (e *ValidationError) merge(err error) {
+	var other *ValidationError
+	if errors.As(err, &other) {
+		e.Errors = append(e.Errors, other.Errors...)
+	} else if err != nil {
+		e.Errors = append(e.Errors, FieldError{Message: err.Error()})
+	}
+}
+
+// err 没有错误时返回nil
+func (e *ValidationError) err() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	return e
+}
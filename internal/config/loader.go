@@ -0,0 +1,329 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix 覆盖配置项的环境变量前缀
+const EnvPrefix = "ZOMBIE_CLEANER_"
+
+// 配置项来源，环境变量和命令行参数的来源为"env:<名称>"和"flag:--<名称>"
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+)
+
+// legacyEnv 旧版部署文件使用的无前缀环境变量，优先级低于带前缀的环境变量
+var legacyEnv = map[string]string{
+	"LOG_LEVEL":      "logger.level",
+	"DRY_RUN":        "cleaner.dry_run",
+	"CHECK_INTERVAL": "cleaner.check_interval",
+	"CONFIRM_COUNT":  "cleaner.confirm_count",
+}
+
+// Sources 每个配置项的来源，按YAML路径索引
+type Sources map[string]string
+
+// LegacyEnv 返回生效的旧版无前缀环境变量
+func (s Sources) LegacyEnv() []string {
+	var names []string
+	for _, source := range s {
+		name, ok := strings.CutPrefix(source, "env:")
+		if ok && !strings.HasPrefix(name, EnvPrefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Loader 按 默认值 → 配置文件 → 环境变量 → 命令行参数 的顺序合并配置，后者覆盖前者
+type Loader struct {
+	// 配置文件路径，文件不存在时跳过
+	Path string
+	// LookupEnv 读取环境变量，为nil时使用os.LookupEnv
+	LookupEnv func(key string) (string, bool)
+	// Flags 命令行显式指定的配置项，按YAML路径索引
+	Flags map[string]string
+}
+
+// Load 合并并校验配置，返回每个配置项的来源。配置无效时返回*ValidationError，列出所有错误
+func (l *Loader) Load() (*Config, Sources, error) {
+	var data []byte
+	if _, err := os.Stat(l.Path); err == nil {
+		if data, err = os.ReadFile(l.Path); err != nil {
+			return nil, nil, fmt.Errorf("读取配置文件失败: %w", err)
+		}
+	}
+	return l.load(data)
+}
+
+// load 以data作为配置文件内容合并配置
+func (l *Loader) load(data []byte) (*Config, Sources, error) {
+	cfg := Default()
+	leaves := configFields(cfg)
+	sources := make(Sources, len(leaves))
+	for _, f := range leaves {
+		sources[f.path] = SourceDefault
+	}
+
+	errs := &ValidationError{}
+	file := newDecoder(SourceFile, errs)
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		errs.add("", "解析配置文件失败: %v", err)
+		return nil, nil, errs
+	}
+	if len(root.Content) > 0 {
+		file.decode(root.Content[0], reflect.ValueOf(cfg).Elem(), "")
+		for _, f := range leaves {
+			if _, ok := file.lines[f.path]; ok {
+				sources[f.path] = SourceFile
+			}
+		}
+	}
+
+	lookup := l.LookupEnv
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+	byPath := make(map[string]configField, len(leaves))
+	for _, f := range leaves {
+		byPath[f.path] = f
+	}
+
+	legacy := make([]string, 0, len(legacyEnv))
+	for name := range legacyEnv {
+		legacy = append(legacy, name)
+	}
+	sort.Strings(legacy)
+	for _, name := range legacy {
+		if raw, ok := lookup(name); ok {
+			f := byPath[legacyEnv[name]]
+			if setField(f, raw, "env:"+name, errs) {
+				sources[f.path] = "env:" + name
+			}
+		}
+	}
+	for _, f := range leaves {
+		if raw, ok := lookup(f.env); ok && setField(f, raw, "env:"+f.env, errs) {
+			sources[f.path] = "env:" + f.env
+		}
+	}
+	for _, f := range leaves {
+		if raw, ok := l.Flags[f.path]; ok && setField(f, raw, "flag:--"+f.flag, errs) {
+			sources[f.path] = "flag:--" + f.flag
+		}
+	}
+
+	var invalid *ValidationError
+	if errors.As(cfg.validate(), &invalid) {
+		reported := make(map[string]bool, len(errs.Errors))
+		for _, fe := range errs.Errors {
+			reported[fe.Path] = true
+		}
+		for _, fe := range invalid.Errors {
+			// 无法解析的值保持默认值，不再重复报告默认值的校验错误
+			if reported[fe.Path] {
+				continue
+			}
+			switch source := sources.of(fe.Path); source {
+			case SourceFile:
+				fe.Line = file.line(fe.Path)
+			case SourceDefault, "":
+			default:
+				fe.Source = source
+			}
+			errs.Errors = append(errs.Errors, fe)
+		}
+	}
+	if err := errs.err(); err != nil {
+		return nil, nil, err
+	}
+	return cfg, sources, nil
+}
+
+// configField 单个可覆盖的配置项
+type configField struct {
+	// YAML路径，如cleaner.check_interval
+	path string
+	env  string
+	flag string
+	// 绑定到具体配置对象的字段
+	value reflect.Value
+}
+
+// configFields 列出cfg的所有配置项。结构体逐层展开，其余类型（包括列表和映射）作为一个整体
+func configFields(cfg *Config) []configField {
+	var fields []configField
+	var walk func(v reflect.Value, prefix string)
+	walk = func(v reflect.Value, prefix string) {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+			path := name
+			if prefix != "" {
+				path = prefix + "." + name
+			}
+			if v.Field(i).Kind() == reflect.Struct {
+				walk(v.Field(i), path)
+				continue
+			}
+			env, flagName := overrideNames(path)
+			fields = append(fields, configField{path: path, env: env, flag: flagName, value: v.Field(i)})
+		}
+	}
+	walk(reflect.ValueOf(cfg).Elem(), "")
+	return fields
+}
+
+// overrideNames 返回配置项对应的环境变量和命令行参数名称。
+// cleaner段的配置项省略段名，如cleaner.check_interval对应ZOMBIE_CLEANER_CHECK_INTERVAL和--check-interval
+func overrideNames(path string) (env, flagName string) {
+	name := strings.TrimPrefix(path, "cleaner.")
+	name = strings.ReplaceAll(name, ".", "_")
+	return EnvPrefix + strings.ToUpper(name), strings.ReplaceAll(name, "_", "-")
+}
+
+// setField 将环境变量或命令行参数的值写入配置项，失败时记录错误并返回false。
+// 字符串列表可以用逗号分隔，其他非字符串类型按YAML解析
+func setField(f configField, raw, source string, errs *ValidationError) bool {
+	v := f.value
+	switch {
+	case v.Kind() == reflect.String:
+		v.SetString(raw)
+		return true
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String && !strings.HasPrefix(strings.TrimSpace(raw), "["):
+		list := reflect.MakeSlice(v.Type(), 0, 0)
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = reflect.Append(list, reflect.ValueOf(item).Convert(v.Type().Elem()))
+			}
+		}
+		v.Set(list)
+		return true
+	}
+
+	var root yaml.Node
+	err := yaml.Unmarshal([]byte(raw), &root)
+	switch {
+	case err != nil:
+		errs.Errors = append(errs.Errors, FieldError{Path: f.path, Source: source, Message: fmt.Sprintf("无法解析: %v", err)})
+		return false
+	case len(root.Content) == 0:
+		errs.Errors = append(errs.Errors, FieldError{Path: f.path, Source: source, Message: "不能为空"})
+		return false
+	}
+	before := len(errs.Errors)
+	newDecoder(source, errs).decode(root.Content[0], v, f.path)
+	return len(errs.Errors) == before
+}
+
+// of 返回path所属配置项的来源，path可以指向配置项内部，如cleaner.remediation_steps[0].timeout
+func (s Sources) of(path string) string {
+	for path != "" {
+		if source, ok := s[path]; ok {
+			return source
+		}
+		path = parentPath(path)
+	}
+	return ""
+}
+
+// parentPath 返回上级路径，如a.b[0]返回a.b，a.b返回a
+func parentPath(path string) string {
+	if i := strings.LastIndexAny(path, ".["); i >= 0 {
+		return path[:i]
+	}
+	return ""
+}
+
+// flagValue 配置项的命令行参数，只记录显式指定的值
+type flagValue struct {
+	value   string
+	set     bool
+	boolean bool
+}
+
+func (f *flagValue) String() string { return f.value }
+
+func (f *flagValue) Set(s string) error {
+	f.value = s
+	f.set = true
+	return nil
+}
+
+// IsBoolFlag 布尔配置项允许省略取值，如--dry-run
+func (f *flagValue) IsBoolFlag() bool { return f.boolean }
+
+// RegisterFlags 为每个配置项注册命令行参数，返回的函数在解析参数后给出显式指定的配置项，用作Loader.Flags
+func RegisterFlags(fs *flag.FlagSet) func() map[string]string {
+	values := make(map[string]*flagValue)
+	for _, f := range configFields(Default()) {
+		fv := &flagValue{boolean: f.value.Kind() == reflect.Bool}
+		values[f.path] = fv
+		fs.Var(fv, f.flag, fmt.Sprintf("覆盖配置项%s（环境变量%s）", f.path, f.env))
+	}
+
+	return func() map[string]string {
+		overrides := make(map[string]string)
+		for path, fv := range values {
+			if fv.set {
+				overrides[path] = fv.value
+			}
+		}
+		return overrides
+	}
+}
+
+// PrintConfig 以YAML格式输出生效配置，每个配置项后以注释标明来源
+func PrintConfig(w io.Writer, cfg *Config, sources Sources) error {
+	var root yaml.Node
+	if err := root.Encode(cfg); err != nil {
+		return err
+	}
+	annotateSources(&root, "", sources)
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&root); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func annotateSources(node *yaml.Node, prefix string, sources Sources) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		path := key.Value
+		if prefix != "" {
+			path = prefix + "." + key.Value
+		}
+		source, ok := sources[path]
+		if !ok {
+			annotateSources(value, path, sources)
+			continue
+		}
+		// 非空的列表和映射分多行输出，注释放在键后面
+		if value.Kind == yaml.ScalarNode || len(value.Content) == 0 {
+			value.LineComment = source
+		} else {
+			key.LineComment = source
+		}
+	}
+}
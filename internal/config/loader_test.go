@@ -0,0 +1,389 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeEnv 以map模拟环境变量
+func fakeEnv(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+}
+
+func TestLoaderPrecedence(t *testing.T) {
+	path := writeConfigFile(t, `
+cleaner:
+  check_interval: 1m
+  confirm_count: 4
+  dry_run: false
+logger:
+  level: warn
+`)
+	loader := &Loader{
+		Path: path,
+		LookupEnv: fakeEnv(map[string]string{
+			"ZOMBIE_CLEANER_CONFIRM_COUNT": "6",
+			"ZOMBIE_CLEANER_DRY_RUN":       "true",
+			"ZOMBIE_CLEANER_LOGGER_LEVEL":  "debug",
+		}),
+		Flags: map[string]string{"cleaner.dry_run": "false"},
+	}
+
+	cfg, sources, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load返回错误: %v", err)
+	}
+
+	tests := []struct {
+		path       string
+		got        any
+		want       any
+		wantSource string
+	}{
+		{"cleaner.container_timeout", cfg.Cleaner.ContainerTimeout, 10 * time.Second, SourceDefault},
+		{"cleaner.check_interval", cfg.Cleaner.CheckInterval, time.Minute, SourceFile},
+		{"cleaner.confirm_count", cfg.Cleaner.ConfirmCount, 6, "env:ZOMBIE_CLEANER_CONFIRM_COUNT"},
+		{"logger.level", cfg.Logger.Level, "debug", "env:ZOMBIE_CLEANER_LOGGER_LEVEL"},
+		{"cleaner.dry_run", cfg.Cleaner.DryRun, false, "flag:--dry-run"},
+	}
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.got, tt.want) {
+			t.Errorf("%s = %v, 期望 %v", tt.path, tt.got, tt.want)
+		}
+		if sources[tt.path] != tt.wantSource {
+			t.Errorf("%s的来源 = %q, 期望 %q", tt.path, sources[tt.path], tt.wantSource)
+		}
+	}
+}
+
+func TestLoaderLegacyEnv(t *testing.T) {
+	loader := &Loader{LookupEnv: fakeEnv(map[string]string{
+		"LOG_LEVEL":      "debug",
+		"DRY_RUN":        "true",
+		"CHECK_INTERVAL": "3m",
+		"CONFIRM_COUNT":  "2",
+		// 带前缀的环境变量优先
+		"ZOMBIE_CLEANER_CONFIRM_COUNT": "5",
+	})}
+
+	cfg, sources, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load返回错误: %v", err)
+	}
+	if cfg.Logger.Level != "debug" || !cfg.Cleaner.DryRun || cfg.Cleaner.CheckInterval != 3*time.Minute {
+		t.Errorf("旧版环境变量未生效: level=%s, dry_run=%v, check_interval=%v",
+			cfg.Logger.Level, cfg.Cleaner.DryRun, cfg.Cleaner.CheckInterval)
+	}
+	if cfg.Cleaner.ConfirmCount != 5 {
+		t.Errorf("ConfirmCount = %d, 带前缀的环境变量应覆盖旧版环境变量", cfg.Cleaner.ConfirmCount)
+	}
+	want := []string{"CHECK_INTERVAL", "DRY_RUN", "LOG_LEVEL"}
+	if got := sources.LegacyEnv(); !reflect.DeepEqual(got, want) {
+		t.Errorf("LegacyEnv() = %v, 期望 %v", got, want)
+	}
+}
+
+func TestLoaderValueFormats(t *testing.T) {
+	loader := &Loader{LookupEnv: fakeEnv(map[string]string{
+		"ZOMBIE_CLEANER_WHITELIST_PATTERNS":               "^kube-system-.*, ^monitoring-.*",
+		"ZOMBIE_CLEANER_CONTAINER_RUNTIME":                "containerd",
+		"ZOMBIE_CLEANER_BUDGET_MAX_REMEDIATIONS_PER_HOUR": "12",
+		"ZOMBIE_CLEANER_REMEDIATION_STEPS":                `[{"action": "sigchld_parent", "timeout": "5s"}, {"action": "remove_container", "timeout": "20s"}]`,
+		"ZOMBIE_CLEANER_POLICY_RULES":                     `[{"name": "batch", "match": {"namespaces": ["batch"]}, "action": "alert"}]`,
+	})}
+
+	cfg, _, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load返回错误: %v", err)
+	}
+	if !reflect.DeepEqual(cfg.Cleaner.WhitelistPatterns, []string{"^kube-system-.*", "^monitoring-.*"}) {
+		t.Errorf("WhitelistPatterns = %q", cfg.Cleaner.WhitelistPatterns)
+	}
+	if cfg.Cleaner.ContainerRuntime != RuntimeContainerd {
+		t.Errorf("ContainerRuntime = %s", cfg.Cleaner.ContainerRuntime)
+	}
+	if cfg.Cleaner.Budget.MaxRemediationsPerHour != 12 {
+		t.Errorf("Budget.MaxRemediationsPerHour = %d", cfg.Cleaner.Budget.MaxRemediationsPerHour)
+	}
+	wantSteps := []RemediationStep{
+		{Action: ActionSigchldParent, Enabled: true, Timeout: 5 * time.Second},
+		{Action: ActionRemoveContainer, Enabled: true, Timeout: 20 * time.Second},
+	}
+	if !reflect.DeepEqual(cfg.Cleaner.RemediationSteps, wantSteps) {
+		t.Errorf("RemediationSteps = %+v", cfg.Cleaner.RemediationSteps)
+	}
+	if len(cfg.Cleaner.Policy.Rules) != 1 || cfg.Cleaner.Policy.Rules[0].Action != PolicyAlert {
+		t.Errorf("Policy.Rules = %+v", cfg.Cleaner.Policy.Rules)
+	}
+}
+
+func TestLoaderRuntimes(t *testing.T) {
+	path := writeConfigFile(t, `
+cleaner:
+  container_runtime: auto
+  cri_endpoint: unix:///run/k3s/containerd/containerd.sock
+  runtimes:
+    docker:
+      host: tcp://127.0.0.1:2376
+      api_version: "1.41"
+      tls:
+        ca_file: /etc/docker/ca.pem
+        cert_file: /etc/docker/cert.pem
+        key_file: /etc/docker/key.pem
+`)
+	loader := &Loader{Path: path, LookupEnv: fakeEnv(map[string]string{
+		"ZOMBIE_CLEANER_RUNTIMES_CONTAINERD_NAMESPACES":   "moby, k8s.io",
+		"ZOMBIE_CLEANER_RUNTIMES_CONTAINERD_DIAL_TIMEOUT": "3s",
+	})}
+
+	cfg, _, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load返回错误: %v", err)
+	}
+	runtimes := cfg.Cleaner.Runtimes
+	wantDocker := DockerRuntimeConfig{
+		Host:        "tcp://127.0.0.1:2376",
+		APIVersion:  "1.41",
+		TLS:         TLSConfig{CAFile: "/etc/docker/ca.pem", CertFile: "/etc/docker/cert.pem", KeyFile: "/etc/docker/key.pem"},
+		DialTimeout: 10 * time.Second,
+	}
+	if !reflect.DeepEqual(runtimes.Docker, wantDocker) {
+		t.Errorf("Runtimes.Docker = %+v, 期望 %+v", runtimes.Docker, wantDocker)
+	}
+	wantContainerd := ContainerdRuntimeConfig{
+		Address:     "/run/containerd/containerd.sock",
+		Namespaces:  []string{"moby", "k8s.io"},
+		DialTimeout: 3 * time.Second,
+	}
+	if !reflect.DeepEqual(runtimes.Containerd, wantContainerd) {
+		t.Errorf("Runtimes.Containerd = %+v, 期望 %+v", runtimes.Containerd, wantContainerd)
+	}
+	// 已废弃的cri_endpoint覆盖runtimes.cri.endpoint
+	if runtimes.CRI.Endpoint != "unix:///run/k3s/containerd/containerd.sock" {
+		t.Errorf("Runtimes.CRI.Endpoint = %q, 期望使用cri_endpoint", runtimes.CRI.Endpoint)
+	}
+	if runtimes.CRIO.Endpoint != "unix:///var/run/crio/crio.sock" {
+		t.Errorf("Runtimes.CRIO.Endpoint = %q", runtimes.CRIO.Endpoint)
+	}
+}
+
+func TestLoaderInvalidOverride(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		flags   map[string]string
+		wantMsg string
+	}{
+		{name: "时间格式无效", env: map[string]string{"ZOMBIE_CLEANER_CHECK_INTERVAL": "soon"}, wantMsg: "ZOMBIE_CLEANER_CHECK_INTERVAL"},
+		{name: "整数格式无效", env: map[string]string{"CONFIRM_COUNT": "three"}, wantMsg: "CONFIRM_COUNT"},
+		{name: "空值", env: map[string]string{"ZOMBIE_CLEANER_METRICS_PORT": ""}, wantMsg: "ZOMBIE_CLEANER_METRICS_PORT"},
+		{name: "命令行参数无效", flags: map[string]string{"cleaner.dry_run": "maybe"}, wantMsg: "--dry-run"},
+		{name: "覆盖后校验失败", env: map[string]string{"ZOMBIE_CLEANER_CONFIRM_COUNT": "0"}, wantMsg: "确认次数"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := (&Loader{LookupEnv: fakeEnv(tt.env), Flags: tt.flags}).Load()
+			if err == nil || !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Errorf("Load()错误 = %v, 期望包含 %q", err, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestRegisterFlags(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	overrides := RegisterFlags(fs)
+
+	if err := fs.Parse([]string{"--dry-run", "--check-interval", "2m", "--logger-level=debug", "--budget-cluster-enabled=false"}); err != nil {
+		t.Fatalf("解析命令行参数失败: %v", err)
+	}
+	want := map[string]string{
+		"cleaner.dry_run":                "true",
+		"cleaner.check_interval":         "2m",
+		"logger.level":                   "debug",
+		"cleaner.budget.cluster.enabled": "false",
+	}
+	if got := overrides(); !reflect.DeepEqual(got, want) {
+		t.Errorf("overrides() = %v, 期望 %v", got, want)
+	}
+}
+
+func TestPrintConfig(t *testing.T) {
+	loader := &Loader{
+		LookupEnv: fakeEnv(map[string]string{"ZOMBIE_CLEANER_WHITELIST_PATTERNS": "^web-.*"}),
+		Flags:     map[string]string{"cleaner.confirm_count": "7"},
+	}
+	cfg, sources, err := loader.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := PrintConfig(&buf, cfg, sources); err != nil {
+		t.Fatalf("PrintConfig返回错误: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"confirm_count: 7 # flag:--confirm-count",
+		"whitelist_patterns: # env:ZOMBIE_CLEANER_WHITELIST_PATTERNS",
+		"check_interval: 5m0s # default",
+		"rules: [] # default",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("输出中缺少 %q:\n%s", want, out)
+		}
+	}
+
+	// 输出本身是可以加载的配置文件
+	path := writeConfigFile(t, out)
+	reloaded, _, err := (&Loader{Path: path, LookupEnv: fakeEnv(nil)}).Load()
+	if err != nil {
+		t.Fatalf("加载输出的配置失败: %v", err)
+	}
+	if reloaded.Hash() != cfg.Hash() {
+		t.Error("加载输出的配置后应与原配置相同")
+	}
+}
+
+func TestLoaderValidationErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		env  map[string]string
+		want []FieldError
+	}{
+		{
+			name: "未知的配置项",
+			data: "cleaner:\n  check_intervall: 1m\nmetrics:\n  path: /metrics\n",
+			want: []FieldError{
+				{Path: "cleaner.check_intervall", Line: 2, Message: "未知的配置项"},
+				{Path: "metrics.path", Line: 4, Message: "未知的配置项"},
+			},
+		},
+		{
+			name: "重复的配置项",
+			data: "cleaner:\n  dry_run: true\n  dry_run: false\n",
+			want: []FieldError{{Path: "cleaner.dry_run", Line: 3, Message: "配置项重复"}},
+		},
+		{
+			name: "无法解析的值",
+			data: "cleaner:\n  check_interval: soon\n  confirm_count: [3]\n  remediation_steps:\n    - action: stop_container\n      timeout: 1x\n",
+			want: []FieldError{
+				{Path: "cleaner.check_interval", Line: 2, Message: `无法将"soon"解析为时间间隔（如30s、5m）`},
+				{Path: "cleaner.confirm_count", Line: 3, Message: "期望整数，实际为列表"},
+				{Path: "cleaner.remediation_steps[0].timeout", Line: 6, Message: `无法将"1x"解析为时间间隔（如30s、5m）`},
+			},
+		},
+		{
+			name: "校验失败",
+			data: "cleaner:\n  container_runtime: podman\n  whitelist_patterns:\n    - \"^(kube\"\n  remediation_steps:\n    - action: stop_container\n  min_zombie_age: -1m\n",
+			want: []FieldError{
+				{Path: "cleaner.min_zombie_age", Line: 7, Message: "僵尸进程最小存在时长不能为负数"},
+				{Path: "cleaner.whitelist_patterns[0]", Line: 4, Message: "白名单正则表达式无效: error parsing regexp: missing closing ): `^(kube`"},
+				{Path: "cleaner.container_runtime", Line: 2, Message: `容器运行时必须是docker、containerd、cri或auto，实际为"podman"`},
+				{Path: "cleaner.remediation_steps[0].timeout", Line: 6, Message: "处置步骤超时时间必须大于0: stop_container"},
+			},
+		},
+		{
+			name: "风险评分参数无效",
+			data: "cleaner:\n  risk:\n    threshold: 120\n    zombie_saturation: 0\n",
+			want: []FieldError{
+				{Path: "cleaner.risk.threshold", Line: 3, Message: "风险评分阈值必须在1-100之间，实际为120"},
+				{Path: "cleaner.risk.zombie_saturation", Line: 4, Message: "僵尸进程数量饱和值必须大于0"},
+			},
+		},
+		{
+			name: "孤儿僵尸进程策略无效",
+			data: "cleaner:\n  remediation_steps:\n    - action: stop_container\n      timeout: 30s\n  orphans:\n    action: kill\n    remediation_steps: [kill_shim]\n",
+			want: []FieldError{
+				{Path: "cleaner.orphans.action", Line: 6, Message: `孤儿僵尸进程的action必须是ignore、alert或remediate，实际为"kill"`},
+				{Path: "cleaner.orphans.remediation_steps[0]", Line: 7, Message: "孤儿僵尸进程策略引用了未配置的处置步骤: kill_shim"},
+			},
+		},
+		{
+			name: "宿主机僵尸进程参数无效",
+			data: "cleaner:\n  host_zombies:\n    min_age: -1m\n    restart_units:\n      - kubelet.service\n      - session-1.scope\n",
+			want: []FieldError{
+				{Path: "cleaner.host_zombies.min_age", Line: 3, Message: "宿主机僵尸进程最小存在时长不能为负数"},
+				{Path: "cleaner.host_zombies.restart_units[1]", Line: 5, Message: `只能重启systemd service，实际为"session-1.scope"`},
+			},
+		},
+		{
+			name: "D状态进程参数无效",
+			data: "cleaner:\n  stuck_processes:\n    enabled: true\n    min_age: -30s\n",
+			want: []FieldError{{Path: "cleaner.stuck_processes.min_age", Line: 4, Message: "D状态进程最小持续时长不能为负数"}},
+		},
+		{
+			name: "环境变量的值无效",
+			data: "cleaner:\n  confirm_count: 3\n",
+			env: map[string]string{
+				"ZOMBIE_CLEANER_CONFIRM_COUNT":     "0",
+				"ZOMBIE_CLEANER_REMEDIATION_STEPS": `[{"action": "kill_shim", "timeout": "10s", "signal": "KILL", "retries": 1}]`,
+			},
+			want: []FieldError{
+				{Path: "cleaner.remediation_steps[0].retries", Source: "env:ZOMBIE_CLEANER_REMEDIATION_STEPS", Message: "未知的配置项"},
+				{Path: "cleaner.confirm_count", Source: "env:ZOMBIE_CLEANER_CONFIRM_COUNT", Message: "确认次数必须大于0"},
+			},
+		},
+		{
+			name: "运行时配置无效",
+			data: "cleaner:\n  container_runtime: auto\n  runtimes:\n    docker:\n      tls:\n        cert_file: /etc/docker/cert.pem\n    containerd:\n      namespaces: [moby, \"\"]\n      dial_timeout: 0s\n    cri:\n      endpoint: \"\"\n",
+			want: []FieldError{
+				{Path: "cleaner.runtimes.docker.tls.cert_file", Line: 6, Message: "配置cert_file时必须同时配置key_file"},
+				{Path: "cleaner.runtimes.containerd.namespaces[1]", Line: 8, Message: "命名空间不能为空"},
+				{Path: "cleaner.runtimes.containerd.dial_timeout", Line: 9, Message: "连接超时时间必须大于0"},
+			},
+		},
+		{
+			name: "CRI运行时缺少地址",
+			data: "cleaner:\n  container_runtime: cri\n  runtimes:\n    cri:\n      endpoint: \"\"\n    containerd:\n      namespaces: []\n",
+			want: []FieldError{{Path: "cleaner.runtimes.cri.endpoint", Line: 5, Message: "CRI运行时服务地址不能为空"}},
+		},
+		{
+			name: "根节点不是映射",
+			data: "- cleaner\n",
+			want: []FieldError{{Line: 1, Message: "期望映射，实际为列表"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loader := &Loader{Path: writeConfigFile(t, tt.data), LookupEnv: fakeEnv(tt.env)}
+			_, _, err := loader.Load()
+			var invalid *ValidationError
+			if !errors.As(err, &invalid) {
+				t.Fatalf("Load()错误 = %v, 期望*ValidationError", err)
+			}
+			if !reflect.DeepEqual(invalid.Errors, tt.want) {
+				t.Errorf("错误列表 = %+v\n期望 %+v", invalid.Errors, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidationErrorMessage(t *testing.T) {
+	err := &ValidationError{Errors: []FieldError{
+		{Path: "cleaner.check_interval", Line: 3, Message: "检测间隔必须大于0"},
+		{Path: "cleaner.dry_run", Source: "flag:--dry-run", Message: `无法将"maybe"解析为布尔值`},
+	}}
+	want := "配置无效，共2个错误:\n" +
+		"  - cleaner.check_interval 第3行: 检测间隔必须大于0\n" +
+		"  - cleaner.dry_run flag:--dry-run: 无法将\"maybe\"解析为布尔值"
+	if got := err.Error(); got != want {
+		t.Errorf("Error() = %q, 期望 %q", got, want)
+	}
+
+	single := &ValidationError{Errors: err.Errors[:1]}
+	if got := single.Error(); got != "配置无效: cleaner.check_interval 第3行: 检测间隔必须大于0" {
+		t.Errorf("单个错误时Error() = %q", got)
+	}
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// PolicyAction 策略规则决定的处置方式
+type PolicyAction string
+
+const (
+	// 忽略，不计数也不处置
+	PolicyIgnore PolicyAction = "ignore"
+	// 只告警，达到确认次数后记录告警但不处置
+	PolicyAlert PolicyAction = "alert"
+	// 按处置阶梯处置
+	PolicyRemediate PolicyAction = "remediate"
+)
+
+// PolicyConfig 基于规则的处置策略，按顺序匹配，第一条匹配的规则生效
+type PolicyConfig struct {
+	Rules []PolicyRule `yaml:"rules"`
+}
+
+// PolicyRule 单条策略规则
+type PolicyRule struct {
+	// 规则名称，用于日志和指标
+	Name string `yaml:"name"`
+	// 匹配条件，所有非空条件同时满足才算匹配
+	Match PolicyMatch `yaml:"match"`
+	// 处置方式
+	Action PolicyAction `yaml:"action"`
+	// 确认次数，为0时使用全局confirm_count
+	ConfirmCount int `yaml:"confirm_count"`
+	// 启用的处置步骤，为空时使用全局remediation_steps的启用状态
+	RemediationSteps []RemediationAction `yaml:"remediation_steps"`
+}
+
+// PolicyMatch 规则匹配条件
+type PolicyMatch struct {
+	// Pod命名空间，任意一个相等即匹配
+	Namespaces []string `yaml:"namespaces"`
+	// Pod标签，全部相等才匹配
+	Labels map[string]string `yaml:"labels"`
+	// 容器镜像正则表达式，任意一个匹配即可
+	Images []string `yaml:"images"`
+	// 容器启动命令正则表达式
+	Command string `yaml:"command"`
+	// 容器内僵尸进程数量下限
+	MinZombies int `yaml:"min_zombies"`
+}
+
+// OrphanPolicy 僵尸进程的父进程是容器init进程（容器入口进程不回收子进程，缺少tini等init reaper）时的处置策略。
+// 处置方式和处置步骤不会超过匹配的策略规则和Pod注解允许的范围，例如规则为alert时不会处置
+type OrphanPolicy struct {
+	// 处置方式：ignore、alert或remediate
+	Action PolicyAction `yaml:"action"`
+	// 启用的处置步骤，为空时使用全局remediation_steps的启用状态；只有匹配的策略规则也启用的步骤才会执行
+	RemediationSteps []RemediationAction `yaml:"remediation_steps"`
+}
+
+func validatePolicy(policy PolicyConfig, steps []RemediationStep) error {
+	errs := &ValidationError{}
+	configured := make(map[RemediationAction]bool)
+	for _, step := range steps {
+		configured[step.Action] = true
+	}
+
+	names := make(map[string]bool)
+	for i, rule := range policy.Rules {
+		path := fmt.Sprintf("cleaner.policy.rules[%d]", i)
+		if rule.Name == "" {
+			errs.add(path+".name", "策略规则名称不能为空")
+		} else if names[rule.Name] {
+			errs.add(path+".name", "策略规则名称重复: %s", rule.Name)
+		}
+		names[rule.Name] = true
+
+		switch rule.Action {
+		case PolicyIgnore, PolicyAlert, PolicyRemediate:
+		default:
+			errs.add(path+".action", "策略规则%s的action必须是ignore、alert或remediate", rule.Name)
+		}
+		if rule.ConfirmCount < 0 {
+			errs.add(path+".confirm_count", "策略规则%s的确认次数不能为负数", rule.Name)
+		}
+		if rule.Match.MinZombies < 0 {
+			errs.add(path+".match.min_zombies", "策略规则%s的min_zombies不能为负数", rule.Name)
+		}
+		for j, image := range rule.Match.Images {
+			if _, err := regexp.Compile(image); err != nil {
+				errs.add(fmt.Sprintf("%s.match.images[%d]", path, j), "策略规则%s的镜像正则表达式无效: %v", rule.Name, err)
+			}
+		}
+		if rule.Match.Command != "" {
+			if _, err := regexp.Compile(rule.Match.Command); err != nil {
+				errs.add(path+".match.command", "策略规则%s的命令正则表达式无效: %v", rule.Name, err)
+			}
+		}
+		for j, action := range rule.RemediationSteps {
+			if !configured[action] {
+				errs.add(fmt.Sprintf("%s.remediation_steps[%d]", path, j), "策略规则%s引用了未配置的处置步骤: %s", rule.Name, action)
+			}
+		}
+	}
+	return errs.err()
+}
+
+func validateOrphanPolicy(orphans OrphanPolicy, steps []RemediationStep) error {
+	errs := &ValidationError{}
+	switch orphans.Action {
+	case PolicyIgnore, PolicyAlert, PolicyRemediate:
+	default:
+		errs.add("cleaner.orphans.action", "孤儿僵尸进程的action必须是ignore、alert或remediate，实际为%q", orphans.Action)
+	}
+
+	configured := make(map[RemediationAction]bool)
+	for _, step := range steps {
+		configured[step.Action] = true
+	}
+	for i, action := range orphans.RemediationSteps {
+		if !configured[action] {
+			errs.add(fmt.Sprintf("cleaner.orphans.remediation_steps[%d]", i), "孤儿僵尸进程策略引用了未配置的处置步骤: %s", action)
+		}
+	}
+	return errs.err()
+}
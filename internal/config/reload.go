@@ -0,0 +1,62 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+// restartRequiredField 修改后需要重启才能生效的配置项
+type restartRequiredField struct {
+	path string
+	// field 返回配置项的指针
+	field func(c *Config) any
+}
+
+// 运行时连接、事件订阅、Kubernetes客户端、预算和指标等在启动时创建，热加载时保持原值
+var restartRequiredFields = []restartRequiredField{
+	{"cleaner.container_timeout", func(c *Config) any { return &c.Cleaner.ContainerTimeout }},
+	{"cleaner.container_runtime", func(c *Config) any { return &c.Cleaner.ContainerRuntime }},
+	{"cleaner.cri_endpoint", func(c *Config) any { return &c.Cleaner.CRIEndpoint }},
+	{"cleaner.runtimes", func(c *Config) any { return &c.Cleaner.Runtimes }},
+	{"cleaner.attribution_mode", func(c *Config) any { return &c.Cleaner.AttributionMode }},
+	{"cleaner.event_detection", func(c *Config) any { return &c.Cleaner.EventDetection }},
+	{"cleaner.event_trigger_threshold", func(c *Config) any { return &c.Cleaner.EventTriggerThreshold }},
+	{"cleaner.event_min_trigger_interval", func(c *Config) any { return &c.Cleaner.EventMinTriggerInterval }},
+	{"cleaner.remediation_backend", func(c *Config) any { return &c.Cleaner.RemediationBackend }},
+	{"cleaner.state_file", func(c *Config) any { return &c.Cleaner.StateFile }},
+	{"cleaner.budget", func(c *Config) any { return &c.Cleaner.Budget }},
+	{"cleaner.host_zombies.systemd_socket", func(c *Config) any { return &c.Cleaner.HostZombies.SystemdSocket }},
+	{"kubernetes", func(c *Config) any { return &c.Kubernetes }},
+	{"metrics", func(c *Config) any { return &c.Metrics }},
+	{"logger", func(c *Config) any { return &c.Logger }},
+}
+
+// MergeReload 合并热加载的新配置：需要重启才能生效的配置项保留current中的值，
+// 返回合并后的配置和被忽略的配置项路径
+func MergeReload(current, next *Config) (*Config, []string) {
+	merged := *next
+	var ignored []string
+	for _, f := range restartRequiredFields {
+		dst := reflect.ValueOf(f.field(&merged)).Elem()
+		src := reflect.ValueOf(f.field(current)).Elem()
+		if reflect.DeepEqual(dst.Interface(), src.Interface()) {
+			continue
+		}
+		dst.Set(src)
+		ignored = append(ignored, f.path)
+	}
+	return &merged, ignored
+}
+
+// Hash 返回生效配置的摘要，用于判断配置是否变化和在指标中标识当前配置
+func (c *Config) Hash() string {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:12]
+}
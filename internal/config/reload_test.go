@@ -0,0 +1,108 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func writeConfigFile(t *testing.T, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("文件不存在时使用默认配置", func(t *testing.T) {
+		cfg, err := Load(filepath.Join(t.TempDir(), "missing.yaml"))
+		if err != nil {
+			t.Fatalf("Load返回错误: %v", err)
+		}
+		if cfg.Cleaner.CheckInterval != 5*time.Minute || cfg.Cleaner.ConfirmCount != 3 {
+			t.Errorf("默认配置 = %+v", cfg.Cleaner)
+		}
+	})
+
+	t.Run("覆盖默认值", func(t *testing.T) {
+		cfg, err := Load(writeConfigFile(t, "cleaner:\n  check_interval: 1m\n  confirm_count: 5\n"))
+		if err != nil {
+			t.Fatalf("Load返回错误: %v", err)
+		}
+		if cfg.Cleaner.CheckInterval != time.Minute || cfg.Cleaner.ConfirmCount != 5 {
+			t.Errorf("CheckInterval = %v, ConfirmCount = %d", cfg.Cleaner.CheckInterval, cfg.Cleaner.ConfirmCount)
+		}
+	})
+
+	errorCases := []struct {
+		name string
+		data string
+	}{
+		{name: "YAML语法错误", data: "cleaner: [\n"},
+		{name: "校验失败", data: "cleaner:\n  confirm_count: -1\n"},
+		{name: "处置步骤无效", data: "cleaner:\n  remediation_steps:\n    - action: reboot_node\n      timeout: 1s\n"},
+		{name: "策略规则无效", data: "cleaner:\n  policy:\n    rules:\n      - name: r1\n        action: delete\n"},
+	}
+	for _, tt := range errorCases {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Load(writeConfigFile(t, tt.data))
+			if err == nil {
+				t.Fatalf("期望返回错误, 实际返回 %+v", cfg)
+			}
+		})
+	}
+}
+
+func TestMergeReload(t *testing.T) {
+	current, err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	next, err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 可热加载的配置项
+	next.Cleaner.ConfirmCount = 7
+	next.Cleaner.WhitelistPatterns = []string{"^web-.*"}
+	next.Cleaner.MaxConcurrentContainers = 3
+	// 需要重启的配置项
+	next.Cleaner.ContainerRuntime = RuntimeContainerd
+	next.Logger.Level = "debug"
+	next.Cleaner.Budget.MaxRemediationsPerHour = 10
+
+	merged, ignored := MergeReload(current, next)
+
+	if merged.Cleaner.ConfirmCount != 7 || merged.Cleaner.MaxConcurrentContainers != 3 ||
+		!reflect.DeepEqual(merged.Cleaner.WhitelistPatterns, []string{"^web-.*"}) {
+		t.Errorf("可热加载的配置项未生效: %+v", merged.Cleaner)
+	}
+	if merged.Cleaner.ContainerRuntime != RuntimeDocker || merged.Logger.Level != "info" || merged.Cleaner.Budget.MaxRemediationsPerHour != 0 {
+		t.Errorf("需要重启的配置项应保持原值: runtime=%s, level=%s, budget=%d",
+			merged.Cleaner.ContainerRuntime, merged.Logger.Level, merged.Cleaner.Budget.MaxRemediationsPerHour)
+	}
+	wantIgnored := []string{"cleaner.container_runtime", "cleaner.budget", "logger"}
+	if !reflect.DeepEqual(ignored, wantIgnored) {
+		t.Errorf("ignored = %v, 期望 %v", ignored, wantIgnored)
+	}
+	if next.Cleaner.ContainerRuntime != RuntimeContainerd {
+		t.Error("MergeReload不应修改传入的新配置")
+	}
+}
+
+func TestConfigHash(t *testing.T) {
+	a, _ := Load("")
+	b, _ := Load("")
+	if a.Hash() == "" || a.Hash() != b.Hash() {
+		t.Fatalf("相同配置的摘要应相同: %q, %q", a.Hash(), b.Hash())
+	}
+	b.Cleaner.WhitelistPatterns = []string{"^web-.*"}
+	if a.Hash() == b.Hash() {
+		t.Error("配置变化后摘要应变化")
+	}
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"syscall"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RemediationAction 处置阶梯中的单个动作
+type RemediationAction string
+
+const (
+	// 向僵尸进程的父进程发送SIGCHLD，提醒其回收子进程
+	ActionSigchldParent RemediationAction = "sigchld_parent"
+	// 向父进程发送指定信号（如SIGTERM），促使其退出或重启
+	ActionSignalParent RemediationAction = "signal_parent"
+	// 通过运行时优雅停止容器
+	ActionStopContainer RemediationAction = "stop_container"
+	// 通过运行时删除容器
+	ActionRemoveContainer RemediationAction = "remove_container"
+	// 强制kill容器shim进程
+	ActionKillShim RemediationAction = "kill_shim"
+)
+
+// RemediationStep 处置阶梯中一步的配置
+type RemediationStep struct {
+	// 动作类型
+	Action RemediationAction `yaml:"action"`
+	// 是否启用该步骤，配置文件中省略时默认启用
+	Enabled bool `yaml:"enabled"`
+	// 该步骤的超时时间，执行后在此时间内等待僵尸进程消失
+	Timeout time.Duration `yaml:"timeout"`
+	// 发送给父进程的信号，仅signal_parent使用
+	Signal string `yaml:"signal"`
+	// shim还在为其他运行中的容器服务时（如同一Pod共享的containerd-shim-runc-v2）是否仍然清理，仅kill_shim使用
+	Force bool `yaml:"force"`
+	// 向shim发送SIGTERM后等待其退出的时间，超时后发送SIGKILL，为0时使用5s，仅kill_shim使用
+	GracePeriod time.Duration `yaml:"grace_period"`
+}
+
+// KillShimStep 返回steps中的kill_shim步骤，未配置时返回零值
+func KillShimStep(steps []RemediationStep) RemediationStep {
+	for _, step := range steps {
+		if step.Action == ActionKillShim {
+			return step
+		}
+	}
+	return RemediationStep{Action: ActionKillShim}
+}
+
+// UnmarshalYAML 配置文件中列出的步骤未写enabled时默认启用
+func (s *RemediationStep) UnmarshalYAML(value *yaml.Node) error {
+	type plain RemediationStep
+	step := plain{Enabled: true}
+	if err := value.Decode(&step); err != nil {
+		return err
+	}
+	*s = RemediationStep(step)
+	return nil
+}
+
+var signalNames = map[string]syscall.Signal{
+	"SIGHUP":  syscall.SIGHUP,
+	"SIGINT":  syscall.SIGINT,
+	"SIGQUIT": syscall.SIGQUIT,
+	"SIGKILL": syscall.SIGKILL,
+	"SIGUSR1": syscall.SIGUSR1,
+	"SIGUSR2": syscall.SIGUSR2,
+	"SIGTERM": syscall.SIGTERM,
+	"SIGCHLD": syscall.SIGCHLD,
+}
+
+// ParseSignal 解析信号名称，支持"SIGTERM"和"TERM"两种写法
+func ParseSignal(name string) (syscall.Signal, error) {
+	upper := strings.ToUpper(strings.TrimSpace(name))
+	if !strings.HasPrefix(upper, "SIG") {
+		upper = "SIG" + upper
+	}
+	sig, ok := signalNames[upper]
+	if !ok {
+		return 0, fmt.Errorf("不支持的信号: %s", name)
+	}
+	return sig, nil
+}
+
+// DefaultRemediationSteps 默认处置阶梯：先提醒父进程回收，再逐步升级到删除容器和kill shim
+func DefaultRemediationSteps() []RemediationStep {
+	return []RemediationStep{
+		{Action: ActionSigchldParent, Enabled: true, Timeout: 10 * time.Second},
+		{Action: ActionSignalParent, Enabled: false, Timeout: 30 * time.Second, Signal: "SIGTERM"},
+		{Action: ActionStopContainer, Enabled: true, Timeout: 30 * time.Second},
+		{Action: ActionRemoveContainer, Enabled: true, Timeout: 10 * time.Second},
+		{Action: ActionKillShim, Enabled: true, Timeout: 10 * time.Second},
+	}
+}
+
+func validateRemediationSteps(steps []RemediationStep) error {
+	errs := &ValidationError{}
+	seen := make(map[RemediationAction]bool)
+	for i, step := range steps {
+		path := fmt.Sprintf("cleaner.remediation_steps[%d]", i)
+		switch step.Action {
+		case ActionSigchldParent, ActionStopContainer, ActionRemoveContainer, ActionKillShim:
+		case ActionSignalParent:
+			if _, err := ParseSignal(step.Signal); err != nil {
+				errs.add(path+".signal", "处置步骤signal_parent的信号无效: %s", step.Signal)
+			}
+		default:
+			errs.add(path+".action", "未知的处置步骤: %s", step.Action)
+		}
+		if seen[step.Action] {
+			errs.add(path+".action", "处置步骤重复: %s", step.Action)
+		}
+		seen[step.Action] = true
+		if step.Timeout <= 0 {
+			errs.add(path+".timeout", "处置步骤超时时间必须大于0: %s", step.Action)
+		}
+		if step.GracePeriod < 0 {
+			errs.add(path+".grace_period", "shim宽限期不能为负数: %s", step.Action)
+		}
+	}
+	return errs.err()
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"syscall"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseSignal(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    syscall.Signal
+		wantErr bool
+	}{
+		{name: "SIGTERM", want: syscall.SIGTERM},
+		{name: "TERM", want: syscall.SIGTERM},
+		{name: "sigkill", want: syscall.SIGKILL},
+		{name: " hup ", want: syscall.SIGHUP},
+		{name: "SIGCHLD", want: syscall.SIGCHLD},
+		{name: "SIGSTOP", wantErr: true},
+		{name: "", wantErr: true},
+		{name: "15", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSignal(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSignal(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ParseSignal(%q) = %v, 期望 %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateRemediationSteps(t *testing.T) {
+	tests := []struct {
+		name    string
+		steps   []RemediationStep
+		wantErr bool
+	}{
+		{name: "默认阶梯", steps: DefaultRemediationSteps()},
+		{name: "空阶梯", steps: nil},
+		{
+			name:    "未知步骤",
+			steps:   []RemediationStep{{Action: "reboot_node", Enabled: true, Timeout: time.Second}},
+			wantErr: true,
+		},
+		{
+			name: "重复步骤",
+			steps: []RemediationStep{
+				{Action: ActionKillShim, Enabled: true, Timeout: time.Second},
+				{Action: ActionKillShim, Enabled: false, Timeout: time.Second},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "超时时间为0",
+			steps:   []RemediationStep{{Action: ActionStopContainer, Enabled: true}},
+			wantErr: true,
+		},
+		{
+			name:    "signal_parent信号无效",
+			steps:   []RemediationStep{{Action: ActionSignalParent, Enabled: true, Timeout: time.Second, Signal: "SIGFOO"}},
+			wantErr: true,
+		},
+		{
+			name:  "signal_parent信号有效",
+			steps: []RemediationStep{{Action: ActionSignalParent, Enabled: true, Timeout: time.Second, Signal: "USR1"}},
+		},
+		{
+			name:    "kill_shim宽限期为负数",
+			steps:   []RemediationStep{{Action: ActionKillShim, Enabled: true, Timeout: time.Second, GracePeriod: -time.Second}},
+			wantErr: true,
+		},
+		{
+			name:  "kill_shim强制清理共享shim",
+			steps: []RemediationStep{{Action: ActionKillShim, Enabled: true, Timeout: time.Second, Force: true, GracePeriod: 2 * time.Second}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateRemediationSteps(tt.steps); (err != nil) != tt.wantErr {
+				t.Errorf("validateRemediationSteps() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRemediationStepEnabledDefault(t *testing.T) {
+	data := `
+- action: sigchld_parent
+  timeout: 10s
+- action: stop_container
+  enabled: false
+  timeout: 30s
+`
+	var steps []RemediationStep
+	if err := yaml.Unmarshal([]byte(data), &steps); err != nil {
+		t.Fatalf("解析处置步骤失败: %v", err)
+	}
+	if len(steps) != 2 {
+		t.Fatalf("解析出%d个步骤, 期望2个", len(steps))
+	}
+	if !steps[0].Enabled {
+		t.Error("省略enabled的步骤应默认启用")
+	}
+	if steps[1].Enabled {
+		t.Error("显式enabled: false的步骤应保持禁用")
+	}
+	if steps[0].Timeout != 10*time.Second {
+		t.Errorf("Timeout = %v, 期望10s", steps[0].Timeout)
+	}
+}
+
+func TestKillShimStep(t *testing.T) {
+	steps := []RemediationStep{
+		{Action: ActionStopContainer, Enabled: true, Timeout: time.Second},
+		{Action: ActionKillShim, Enabled: true, Timeout: time.Second, Force: true},
+	}
+	if got := KillShimStep(steps); !got.Force {
+		t.Errorf("KillShimStep() = %+v, 期望返回配置的kill_shim步骤", got)
+	}
+	if got := KillShimStep(steps[:1]); got.Action != ActionKillShim || got.Force {
+		t.Errorf("未配置kill_shim时KillShimStep() = %+v, 期望零值步骤", got)
+	}
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ValidationResult 一份配置的校验结果
+type ValidationResult struct {
+	// 配置文件路径，来自ConfigMap时附带ConfigMap名称和数据项
+	Name string
+	// 配置无效时为*ValidationError
+	Err error
+}
+
+// ValidateFile 校验配置文件，不合并环境变量和命令行参数。
+// path是Kubernetes清单时，校验其中每个ConfigMap里以.yaml或.yml结尾的数据项，错误行号为清单中的行号
+func ValidateFile(path string) ([]ValidationResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %w", err)
+	}
+
+	docs, err := decodeDocuments(data)
+	if err != nil || !isManifest(docs) {
+		return []ValidationResult{{Name: path, Err: validateData(data)}}, nil
+	}
+
+	var results []ValidationResult
+	for _, doc := range docs {
+		if mappingValue(doc, "kind").Value != "ConfigMap" {
+			continue
+		}
+		metadata := mappingValue(doc, "metadata")
+		name := mappingValue(metadata, "name").Value
+		if namespace := mappingValue(metadata, "namespace").Value; namespace != "" {
+			name = namespace + "/" + name
+		}
+
+		configMapData := mappingValue(doc, "data")
+		for i := 0; i+1 < len(configMapData.Content); i += 2 {
+			key, value := configMapData.Content[i], configMapData.Content[i+1]
+			if !strings.HasSuffix(key.Value, ".yaml") && !strings.HasSuffix(key.Value, ".yml") {
+				continue
+			}
+			err := validateData([]byte(value.Value))
+			var invalid *ValidationError
+			if errors.As(err, &invalid) {
+				for j := range invalid.Errors {
+					invalid.Errors[j].Line = manifestLine(value, invalid.Errors[j].Line)
+				}
+			}
+			results = append(results, ValidationResult{
+				Name: fmt.Sprintf("%s (ConfigMap %s, %s)", path, name, key.Value),
+				Err:  err,
+			})
+		}
+	}
+	if len(results) == 0 {
+		return nil, errors.New("清单中没有包含配置文件的ConfigMap")
+	}
+	return results, nil
+}
+
+// validateData 校验配置文件内容
+func validateData(data []byte) error {
+	noEnv := func(string) (string, bool) { return "", false }
+	_, _, err := (&Loader{LookupEnv: noEnv}).load(data)
+	return err
+}
+
+func decodeDocuments(data []byte) ([]*yaml.Node, error) {
+	var docs []*yaml.Node
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc yaml.Node
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			return docs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(doc.Content) > 0 {
+			docs = append(docs, doc.Content[0])
+		}
+	}
+}
+
+// isManifest 判断文件是否为Kubernetes清单
+func isManifest(docs []*yaml.Node) bool {
+	for _, doc := range docs {
+		if mappingValue(doc, "apiVersion").Value != "" && mappingValue(doc, "kind").Value != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// mappingValue 返回映射节点中key对应的值，不存在时返回空节点
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				return node.Content[i+1]
+			}
+		}
+	}
+	return &yaml.Node{}
+}
+
+// manifestLine 将ConfigMap数据项内的行号换算为清单中的行号。
+// 只有字面量块（|）保留原有换行，其余写法无法换算，返回0
+func manifestLine(value *yaml.Node, line int) int {
+	if line == 0 || value.Style != yaml.LiteralStyle {
+		return 0
+	}
+	return value.Line + line
+}
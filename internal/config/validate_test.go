@@ -0,0 +1,119 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testManifest = `apiVersion: v1
+kind: Service
+metadata:
+  name: zombie-cleaner-metrics
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: zombie-cleaner-config
+  namespace: kube-system
+data:
+  config.yaml: |
+    cleaner:
+      check_interval: 5m
+      confirm_count: 0
+  README: not a config
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: zombie-cleaner-canary
+data:
+  config.yml: |
+    cleaner:
+      dry_run: true
+`
+
+func TestValidateFile(t *testing.T) {
+	t.Run("配置文件", func(t *testing.T) {
+		path := writeConfigFile(t, "cleaner:\n  check_interval: 1m\n")
+		results, err := ValidateFile(path)
+		if err != nil {
+			t.Fatalf("ValidateFile返回错误: %v", err)
+		}
+		if len(results) != 1 || results[0].Name != path || results[0].Err != nil {
+			t.Errorf("结果 = %+v, 期望%s有效", results, path)
+		}
+	})
+
+	t.Run("忽略环境变量", func(t *testing.T) {
+		t.Setenv("ZOMBIE_CLEANER_CONFIRM_COUNT", "0")
+		results, err := ValidateFile(writeConfigFile(t, "cleaner:\n  confirm_count: 2\n"))
+		if err != nil || results[0].Err != nil {
+			t.Errorf("ValidateFile() = %+v, %v, 环境变量不应参与校验", results, err)
+		}
+	})
+
+	t.Run("ConfigMap清单", func(t *testing.T) {
+		path := writeConfigFile(t, testManifest)
+		results, err := ValidateFile(path)
+		if err != nil {
+			t.Fatalf("ValidateFile返回错误: %v", err)
+		}
+		wantNames := []string{
+			path + " (ConfigMap kube-system/zombie-cleaner-config, config.yaml)",
+			path + " (ConfigMap zombie-cleaner-canary, config.yml)",
+		}
+		var names []string
+		for _, result := range results {
+			names = append(names, result.Name)
+		}
+		if !reflect.DeepEqual(names, wantNames) {
+			t.Fatalf("校验对象 = %q, 期望 %q", names, wantNames)
+		}
+
+		var invalid *ValidationError
+		if !errors.As(results[0].Err, &invalid) {
+			t.Fatalf("第一个ConfigMap的错误 = %v, 期望*ValidationError", results[0].Err)
+		}
+		want := []FieldError{{Path: "cleaner.confirm_count", Line: 15, Message: "确认次数必须大于0"}}
+		if !reflect.DeepEqual(invalid.Errors, want) {
+			t.Errorf("错误列表 = %+v, 期望 %+v（行号为清单中的行号）", invalid.Errors, want)
+		}
+		if results[1].Err != nil {
+			t.Errorf("第二个ConfigMap应有效, 实际错误: %v", results[1].Err)
+		}
+	})
+
+	t.Run("清单中没有配置", func(t *testing.T) {
+		manifest := strings.SplitN(testManifest, "---", 2)[0]
+		if _, err := ValidateFile(writeConfigFile(t, manifest)); err == nil {
+			t.Error("清单中没有ConfigMap时应返回错误")
+		}
+	})
+
+	t.Run("文件不存在", func(t *testing.T) {
+		if _, err := ValidateFile(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+			t.Error("文件不存在时应返回错误")
+		}
+	})
+}
+
+func TestValidateDeployManifest(t *testing.T) {
+	for _, path := range []string{"../../config/config.yaml", "../../deploy/daemonset.yaml"} {
+		if _, err := os.Stat(path); err != nil {
+			t.Skipf("%s不存在", path)
+		}
+		results, err := ValidateFile(path)
+		if err != nil {
+			t.Fatalf("ValidateFile(%s)返回错误: %v", path, err)
+		}
+		for _, result := range results {
+			if result.Err != nil {
+				t.Errorf("%s: %v", result.Name, result.Err)
+			}
+		}
+	}
+}
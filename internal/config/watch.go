@@ -0,0 +1,84 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/tiggoins/zombie-cleaner/internal/logger"
+)
+
+// Watcher 监听配置文件变化。ConfigMap挂载通过替换..data符号链接更新文件，
+// 文件本身的inotify监听会失效，因此监听所在目录，并按文件内容判断是否真正变化。
+// 无法使用inotify时退化为定时轮询
+type Watcher struct {
+	path         string
+	pollInterval time.Duration
+	logger       *logger.Logger
+	changes      chan struct{}
+	last         []byte
+}
+
+// NewWatcher 创建配置文件监听器，pollInterval为兜底轮询间隔
+func NewWatcher(path string, pollInterval time.Duration, log *logger.Logger) *Watcher {
+	w := &Watcher{
+		path:         path,
+		pollInterval: pollInterval,
+		logger:       log.WithComponent("config-watcher"),
+		changes:      make(chan struct{}, 1),
+	}
+	w.last, _ = os.ReadFile(path)
+	return w
+}
+
+// Changes 配置文件内容变化时收到通知，连续的变化合并为一次
+func (w *Watcher) Changes() <-chan struct{} {
+	return w.changes
+}
+
+// Run 持续监听直到ctx取消
+func (w *Watcher) Run(ctx context.Context) {
+	events, closeEvents, err := watchDir(filepath.Dir(w.path))
+	if err != nil {
+		w.logger.Warn("无法监听配置文件目录，使用定时轮询", "path", w.path, "poll_interval", w.pollInterval, "error", err)
+	} else {
+		defer closeEvents()
+	}
+
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
+	// 创建监听器之后、开始监听之前的变化不会产生事件，先检查一次
+	w.check()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-events:
+		case <-ticker.C:
+		}
+		w.check()
+	}
+}
+
+// check 比较文件内容，变化时发出通知
+func (w *Watcher) check() {
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		// ConfigMap更新过程中文件可能短暂不可读，等待下一次事件或轮询
+		w.logger.Debug("读取配置文件失败", "path", w.path, "error", err)
+		return
+	}
+	if bytes.Equal(data, w.last) {
+		return
+	}
+	w.last = data
+	w.logger.Info("检测到配置文件变化", "path", w.path)
+
+	select {
+	case w.changes <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,42 @@
+//go:build linux
+
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// watchDir 通过inotify监听目录，目录内有文件创建、写入、移动或删除时发出通知
+func watchDir(dir string) (<-chan struct{}, func(), error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, nil, fmt.Errorf("创建inotify实例失败: %w", err)
+	}
+	mask := uint32(unix.IN_CREATE | unix.IN_CLOSE_WRITE | unix.IN_MOVED_TO | unix.IN_DELETE | unix.IN_MODIFY)
+	if _, err := unix.InotifyAddWatch(fd, dir, mask); err != nil {
+		unix.Close(fd)
+		return nil, nil, fmt.Errorf("监听目录%s失败: %w", dir, err)
+	}
+
+	// 非阻塞fd交给runtime poller，Close可以打断阻塞中的Read
+	file := os.NewFile(uintptr(fd), "inotify")
+	events := make(chan struct{}, 1)
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			// 只关心是否有变化，不解析具体事件
+			if _, err := file.Read(buf); err != nil {
+				return
+			}
+			select {
+			case events <- struct{}{}:
+			default:
+			}
+		}
+	}()
+
+	return events, func() { file.Close() }, nil
+}
@@ -0,0 +1,44 @@
+//go:build linux
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestWatcherConfigMapSymlinkSwap 模拟kubelet更新ConfigMap挂载：
+// config.yaml -> ..data/config.yaml，..data指向带时间戳的目录，更新时原子替换..data
+func TestWatcherConfigMapSymlinkSwap(t *testing.T) {
+	dir := t.TempDir()
+	writeVersion := func(name, data string) {
+		if err := os.MkdirAll(filepath.Join(dir, name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name, "config.yaml"), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeVersion("..2024_05_01_12_00_00", "cleaner:\n  confirm_count: 3\n")
+	if err := os.Symlink("..2024_05_01_12_00_00", filepath.Join(dir, "..data")); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.Symlink(filepath.Join("..data", "config.yaml"), path); err != nil {
+		t.Fatal(err)
+	}
+	// 轮询间隔足够长，通知只能来自inotify
+	w := startWatcher(t, path, time.Hour)
+
+	writeVersion("..2024_05_01_12_05_00", "cleaner:\n  confirm_count: 5\n")
+	if err := os.Symlink("..2024_05_01_12_05_00", filepath.Join(dir, "..data_tmp")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")); err != nil {
+		t.Fatal(err)
+	}
+	expectChange(t, w, true)
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package config
+
+import "errors"
+
+// watchDir inotify仅在Linux上可用，其他平台只使用定时轮询
+func watchDir(dir string) (<-chan struct{}, func(), error) {
+	return nil, nil, errors.New("inotify仅支持Linux")
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tiggoins/zombie-cleaner/internal/logger"
+)
+
+func startWatcher(t *testing.T, path string, pollInterval time.Duration) *Watcher {
+	t.Helper()
+	w := NewWatcher(path, pollInterval, logger.New("error", "text"))
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go w.Run(ctx)
+	return w
+}
+
+func expectChange(t *testing.T, w *Watcher, want bool) {
+	t.Helper()
+	timeout := time.Second
+	if !want {
+		timeout = 100 * time.Millisecond
+	}
+	select {
+	case <-w.Changes():
+		if !want {
+			t.Error("文件内容未变化时不应通知")
+		}
+	case <-time.After(timeout):
+		if want {
+			t.Error("文件内容变化后未收到通知")
+		}
+	}
+}
+
+func TestWatcherDetectsChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("cleaner:\n  confirm_count: 3\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	w := startWatcher(t, path, 20*time.Millisecond)
+
+	// 内容不变的写入不通知
+	if err := os.WriteFile(path, []byte("cleaner:\n  confirm_count: 3\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	expectChange(t, w, false)
+
+	if err := os.WriteFile(path, []byte("cleaner:\n  confirm_count: 5\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	expectChange(t, w, true)
+}
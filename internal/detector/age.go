@@ -0,0 +1,55 @@
+package detector
+
+import (
+	"sync"
+	"time"
+
+	"github.com/tiggoins/zombie-cleaner/internal/metrics"
+	"github.com/tiggoins/zombie-cleaner/internal/process"
+)
+
+// zombieAgeTracker 按PID和启动时间跟踪每个僵尸进程首次被发现的时间。
+// PID被复用后启动时间不同，会被视为新的僵尸进程
+type zombieAgeTracker struct {
+	mu        sync.Mutex
+	firstSeen map[process.Ref]time.Time
+}
+
+func newZombieAgeTracker() *zombieAgeTracker {
+	return &zombieAgeTracker{firstSeen: make(map[process.Ref]time.Time)}
+}
+
+// update 为本次扫描发现的僵尸进程填写FirstSeen和Age。
+// 本次扫描中已不存在的僵尸进程视为已被回收，将其存在时长记录到直方图后不再跟踪
+func (t *zombieAgeTracker) update(zombies []ZombieInfo, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	current := make(map[process.Ref]bool, len(zombies))
+	for i := range zombies {
+		zombie := &zombies[i]
+		ref := zombie.Ref()
+		current[ref] = true
+
+		firstSeen, ok := t.firstSeen[ref]
+		if !ok {
+			firstSeen = now
+		}
+		// 事件记录的退出时间更接近僵尸进程实际产生的时间
+		if !zombie.ExitedAt.IsZero() && zombie.ExitedAt.Before(firstSeen) {
+			firstSeen = zombie.ExitedAt
+		}
+		t.firstSeen[ref] = firstSeen
+
+		zombie.FirstSeen = firstSeen
+		zombie.Age = now.Sub(firstSeen)
+	}
+
+	nodeName := metrics.GetNodeName()
+	for ref, firstSeen := range t.firstSeen {
+		if !current[ref] {
+			metrics.ZombieAge.WithLabelValues(nodeName).Observe(now.Sub(firstSeen).Seconds())
+			delete(t.firstSeen, ref)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package detector
+
+import (
+	"testing"
+	"time"
+)
+
+func TestZombieAgeTracker(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tracker := newZombieAgeTracker()
+
+	type want struct {
+		firstSeen time.Time
+		age       time.Duration
+	}
+	steps := []struct {
+		name    string
+		now     time.Time
+		zombies []ZombieInfo
+		want    []want
+		tracked int
+	}{
+		{
+			name: "首次发现",
+			now:  start,
+			zombies: []ZombieInfo{
+				{PID: 100, StartTime: 5000},
+				// 事件记录的退出时间早于首次发现时间
+				{PID: 101, StartTime: 5001, ExitedAt: start.Add(-time.Minute)},
+			},
+			want: []want{
+				{firstSeen: start, age: 0},
+				{firstSeen: start.Add(-time.Minute), age: time.Minute},
+			},
+			tracked: 2,
+		},
+		{
+			name: "同一僵尸进程持续存在",
+			now:  start.Add(5 * time.Minute),
+			zombies: []ZombieInfo{
+				{PID: 100, StartTime: 5000},
+				{PID: 101, StartTime: 5001},
+			},
+			want: []want{
+				{firstSeen: start, age: 5 * time.Minute},
+				{firstSeen: start.Add(-time.Minute), age: 6 * time.Minute},
+			},
+			tracked: 2,
+		},
+		{
+			name: "PID被复用后视为新的僵尸进程，已回收的不再跟踪",
+			now:  start.Add(10 * time.Minute),
+			zombies: []ZombieInfo{
+				{PID: 100, StartTime: 9000},
+			},
+			want: []want{
+				{firstSeen: start.Add(10 * time.Minute), age: 0},
+			},
+			tracked: 1,
+		},
+		{
+			name:    "所有僵尸进程都已回收",
+			now:     start.Add(15 * time.Minute),
+			tracked: 0,
+		},
+	}
+
+	for _, step := range steps {
+		tracker.update(step.zombies, step.now)
+		for i, w := range step.want {
+			z := step.zombies[i]
+			if !z.FirstSeen.Equal(w.firstSeen) || z.Age != w.age {
+				t.Errorf("%s: 僵尸进程%d FirstSeen = %v, Age = %v, 期望 %v, %v", step.name, z.PID, z.FirstSeen, z.Age, w.firstSeen, w.age)
+			}
+		}
+		if len(tracker.firstSeen) != step.tracked {
+			t.Errorf("%s: 跟踪的僵尸进程数量 = %d, 期望 %d", step.name, len(tracker.firstSeen), step.tracked)
+		}
+	}
+}
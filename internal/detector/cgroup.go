@@ -0,0 +1,98 @@
+package detector
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// cgroup v1下用于归属判断的控制器优先级，所有进程都会出现在这些层级中
+var cgroupV1Controllers = []string{"pids", "memory", "cpu,cpuacct", "cpuacct,cpu", "name=systemd"}
+
+// readCgroupPath 读取procRoot/<pid>/cgroup并返回用于归属判断的cgroup路径
+func readCgroupPath(procRoot string, pid int) (string, error) {
+	data, err := os.ReadFile(filepath.Join(procRoot, strconv.Itoa(pid), "cgroup"))
+	if err != nil {
+		return "", err
+	}
+	return parseCgroupPath(data), nil
+}
+
+// parseCgroupPath 解析/proc/<pid>/cgroup内容，同时兼容cgroup v1、v2和混合模式。
+// v2(或混合模式下非根的统一层级)直接使用"0::"行；v1按cgroupV1Controllers优先级选择层级。
+// 对容器init进程和僵尸进程使用同一规则，保证两者的路径可以直接比较
+func parseCgroupPath(data []byte) string {
+	var (
+		unified string
+		v1Paths = make(map[string]string)
+		first   string
+	)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		// 格式: hierarchy-ID:controller-list:cgroup-path
+		parts := strings.SplitN(scanner.Text(), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		controllers, cgroupPath := parts[1], parts[2]
+		if parts[0] == "0" && controllers == "" {
+			unified = cgroupPath
+			continue
+		}
+		v1Paths[controllers] = cgroupPath
+		if first == "" {
+			first = cgroupPath
+		}
+	}
+
+	if unified != "" && (unified != "/" || len(v1Paths) == 0) {
+		return unified
+	}
+	for _, controller := range cgroupV1Controllers {
+		if p, ok := v1Paths[controller]; ok {
+			return p
+		}
+	}
+	return first
+}
+
+// cgroupIndex cgroup路径到容器的索引
+type cgroupIndex map[string]*ContainerMeta
+
+// newCgroupIndex 为容器建立cgroup路径索引，容器的CgroupPath为空时从init进程读取
+func (d *Detector) newCgroupIndex(containers []ContainerMeta) cgroupIndex {
+	index := make(cgroupIndex, len(containers))
+	for i := range containers {
+		container := &containers[i]
+		if container.CgroupPath == "" {
+			cgroupPath, err := readCgroupPath(d.procRoot, container.PID)
+			if err != nil {
+				d.logger.Debug("读取容器cgroup失败", "container_id", container.ID, "pid", container.PID, "error", err)
+				continue
+			}
+			container.CgroupPath = cgroupPath
+		}
+		// 根cgroup会匹配所有进程，不能用于归属判断
+		key := path.Clean(container.CgroupPath)
+		if key == "/" || key == "." {
+			continue
+		}
+		index[key] = container
+	}
+	return index
+}
+
+// lookup 查找cgroupPath所属的容器，进程可能位于容器cgroup的子cgroup中，因此逐级向上匹配
+func (idx cgroupIndex) lookup(cgroupPath string) (*ContainerMeta, bool) {
+	for p := path.Clean(cgroupPath); p != "/" && p != "."; p = path.Dir(p) {
+		if container, ok := idx[p]; ok {
+			return container, true
+		}
+	}
+	return nil, false
+}
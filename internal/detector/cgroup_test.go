@@ -0,0 +1,139 @@
+package detector
+
+import (
+	"testing"
+
+	"github.com/tiggoins/zombie-cleaner/internal/logger"
+)
+
+func TestParseCgroupPath(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{
+			name: "cgroup v2 systemd驱动",
+			data: "0::/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod1234.slice/cri-containerd-abcdef.scope\n",
+			want: "/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod1234.slice/cri-containerd-abcdef.scope",
+		},
+		{
+			name: "cgroup v1 cgroupfs驱动",
+			data: `12:hugetlb:/kubepods/besteffort/pod1234/abcdef
+11:pids:/kubepods/besteffort/pod1234/abcdef
+10:memory:/kubepods/besteffort/pod1234/abcdef
+9:cpu,cpuacct:/kubepods/besteffort/pod1234/abcdef
+1:name=systemd:/kubepods/besteffort/pod1234/abcdef
+`,
+			want: "/kubepods/besteffort/pod1234/abcdef",
+		},
+		{
+			name: "cgroup v1 systemd驱动优先使用pids层级",
+			data: `11:memory:/kubepods.slice/kubepods-pod1234.slice/docker-abcdef.scope
+4:pids:/kubepods.slice/kubepods-pod1234.slice/docker-abcdef.scope
+1:name=systemd:/system.slice/docker.service
+`,
+			want: "/kubepods.slice/kubepods-pod1234.slice/docker-abcdef.scope",
+		},
+		{
+			name: "cgroup v1缺少优先控制器时回退到第一行",
+			data: "5:devices:/kubepods/pod1234/abcdef\n",
+			want: "/kubepods/pod1234/abcdef",
+		},
+		{
+			name: "混合模式统一层级为根时使用v1层级",
+			data: `11:pids:/kubepods/burstable/pod1234/abcdef
+1:name=systemd:/kubepods/burstable/pod1234/abcdef
+0::/
+`,
+			want: "/kubepods/burstable/pod1234/abcdef",
+		},
+		{
+			name: "混合模式统一层级非根时优先使用统一层级",
+			data: `11:pids:/kubepods/burstable/pod1234/abcdef
+0::/kubepods/burstable/pod1234/abcdef/init
+`,
+			want: "/kubepods/burstable/pod1234/abcdef/init",
+		},
+		{
+			name: "纯v2根cgroup",
+			data: "0::/\n",
+			want: "/",
+		},
+		{
+			name: "忽略格式错误的行",
+			data: "garbage\n0::/kubepods/pod1/ctr\n",
+			want: "/kubepods/pod1/ctr",
+		},
+		{
+			name: "空内容",
+			data: "",
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseCgroupPath([]byte(tt.data)); got != tt.want {
+				t.Errorf("parseCgroupPath() = %q, 期望 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCgroupIndexLookup(t *testing.T) {
+	app := &ContainerMeta{ID: "app"}
+	sidecar := &ContainerMeta{ID: "sidecar"}
+	idx := cgroupIndex{
+		"/kubepods/pod1/app":     app,
+		"/kubepods/pod1/sidecar": sidecar,
+	}
+
+	tests := []struct {
+		name   string
+		path   string
+		want   *ContainerMeta
+		wantOK bool
+	}{
+		{name: "精确匹配", path: "/kubepods/pod1/app", want: app, wantOK: true},
+		{name: "尾部斜杠", path: "/kubepods/pod1/sidecar/", want: sidecar, wantOK: true},
+		{name: "子cgroup向上匹配", path: "/kubepods/pod1/app/init/worker", want: app, wantOK: true},
+		{name: "Pod级cgroup不匹配容器", path: "/kubepods/pod1", wantOK: false},
+		{name: "前缀相同但不是父级", path: "/kubepods/pod1/application", wantOK: false},
+		{name: "根cgroup", path: "/", wantOK: false},
+		{name: "空路径", path: "", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := idx.lookup(tt.path)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("lookup(%q) = (%v, %v), 期望 (%v, %v)", tt.path, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestNewCgroupIndexReadsProcRoot(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"100/cgroup": "0::/kubepods/pod1/app\n",
+	})
+	d := &Detector{logger: logger.New("error", "text"), procRoot: root}
+	containers := []ContainerMeta{
+		{ID: "app", PID: 100},
+		// init进程已退出
+		{ID: "gone", PID: 101},
+	}
+
+	idx := d.newCgroupIndex(containers)
+	if containers[0].CgroupPath != "/kubepods/pod1/app" {
+		t.Errorf("从init进程读取的cgroup = %q, 期望 /kubepods/pod1/app", containers[0].CgroupPath)
+	}
+	if got, ok := idx.lookup("/kubepods/pod1/app/worker"); !ok || got.ID != "app" {
+		t.Errorf("lookup() = (%v, %v), 期望app", got, ok)
+	}
+	if len(idx) != 1 {
+		t.Errorf("索引包含%d个cgroup, 期望 1", len(idx))
+	}
+}
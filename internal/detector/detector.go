@@ -3,31 +3,62 @@ package detector
 import (
 	"context"
 	"fmt"
-	"strings"
 	"sync"
 	"time"
 
-	"github.com/prometheus/procfs"
 	"github.com/tiggoins/zombie-cleaner/internal/config"
 	"github.com/tiggoins/zombie-cleaner/internal/logger"
 	"github.com/tiggoins/zombie-cleaner/internal/metrics"
+	"github.com/tiggoins/zombie-cleaner/internal/process"
 	"github.com/tiggoins/zombie-cleaner/internal/runtime"
 )
 
 type ContainerMeta = runtime.ContainerMeta
 
+// 僵尸进程归属来源
+const (
+	AttributedByCgroup  = "cgroup"
+	AttributedByPIDTree = "pidtree"
+)
+
 type ZombieInfo struct {
-	PID           int
-	PPID          int
+	PID int
+	// StartTime 僵尸进程的启动时间（/proc/<pid>/stat中的starttime），与PID一起标识同一个僵尸进程
+	StartTime uint64
+	PPID      int
+	// ParentKind 父进程是宿主机init、容器init还是普通进程
+	ParentKind ParentKind
+	// ParentStartTime 父进程的启动时间（/proc/<pid>/stat中的starttime），向父进程发送信号前用于校验PID未被复用
+	ParentStartTime uint64
+	// ParentComm 父进程名称，只为宿主机僵尸进程填充
+	ParentComm string
+	// SystemdUnit 父进程所属的systemd service或scope，只为宿主机僵尸进程填充，不属于任何unit时为空
+	SystemdUnit   string
 	Cmdline       string
 	Container     *ContainerMeta
 	IsInContainer bool
+	// AttributedBy 归属到容器所依据的方式，未归属时为空
+	AttributedBy string
+	// ExitedAt 进程事件记录的退出时间，未启用事件检测或未跟踪到时为零值
+	ExitedAt time.Time
+	// FirstSeen 首次发现该僵尸进程的时间，事件记录的退出时间更早时取退出时间
+	FirstSeen time.Time
+	// Age 僵尸进程已存在的时长
+	Age time.Duration
+	// Risk 所属容器的PID耗尽风险，同一容器的僵尸进程共享，未归属容器时为nil
+	Risk *Risk
+}
+
+// Ref 返回僵尸进程的身份标识
+func (z ZombieInfo) Ref() process.Ref {
+	return process.Ref{PID: z.PID, StartTime: z.StartTime}
 }
 
 type Detector struct {
 	logger           *logger.Logger
 	ContainerRuntime runtime.ContainerRuntimeInterface
 	containerTimeout time.Duration
+	attributionMode  config.AttributionMode
 
 	// 超时容器跟踪
 	timeoutContainers struct {
@@ -35,43 +66,132 @@ type Detector struct {
 		m  map[string]time.Time // containerID -> timeout time
 	}
 
-	// 全局缓存避免重复构建同一PID子树
-	pidTreeCache struct {
-		mu sync.Mutex
-		m  map[int]map[int]bool
-	}
+	// 事件驱动检测，未启用时为nil
+	eventWatcher *EventWatcher
+
+	// 读取进程信息的proc文件系统
+	procRoot string
+
+	// 跟踪僵尸进程的存在时长
+	zombieAges *zombieAgeTracker
+	// PID耗尽风险评分
+	risk *riskScorer
+	// 僵尸进程数量趋势
+	trends *trendTracker
+	// D状态进程
+	stuck *stuckTracker
 }
 
-func New(containerTimeout time.Duration, containerRuntime config.ContainerRuntime, log *logger.Logger) (*Detector, error) {
+func New(cfg *config.CleanerConfig, log *logger.Logger) (*Detector, error) {
+	containerTimeout := cfg.ContainerTimeout
 	d := &Detector{
 		logger:           log.WithComponent("detector"),
 		containerTimeout: containerTimeout,
+		attributionMode:  cfg.AttributionMode,
+		procRoot:         process.DefaultRoot,
+		zombieAges:       newZombieAgeTracker(),
+		risk:             newRiskScorer(cfg.Risk),
+		trends:           newTrendTracker(cfg.Trend),
+		stuck:            newStuckTracker(cfg.StuckProcesses),
 	}
-	d.pidTreeCache.m = make(map[int]map[int]bool)
 	d.timeoutContainers.m = make(map[string]time.Time)
 
 	var runtimeImpl runtime.ContainerRuntimeInterface
 	var err error
 
-	// 根据配置创建容器运行时实现
-	switch containerRuntime {
+	// 根据配置创建容器运行时实现，各运行时在创建时确认守护进程可用
+	runtimes := cfg.Runtimes
+	healthChecks := make(map[string]runtime.ContainerRuntimeInterface)
+	switch cfg.ContainerRuntime {
 	case config.RuntimeDocker:
-		runtimeImpl, err = runtime.NewDockerRuntime(log, containerTimeout, d)
+		runtimeImpl, err = runtime.NewDockerRuntime(log, runtimes.Docker, containerTimeout, d.procRoot, d)
 		if err != nil {
-			return nil, fmt.Errorf("%s", "无法创建Docker运行时")
+			return nil, fmt.Errorf("无法创建Docker运行时: %w", err)
 		}
+		healthChecks[runtime.NameDocker] = runtimeImpl
 	case config.RuntimeContainerd:
-		runtimeImpl, err = runtime.NewContainerdRuntime(log, containerTimeout, d)
+		runtimeImpl, err = runtime.NewContainerdRuntime(log, runtimes.Containerd, containerTimeout, d.procRoot, d)
 		if err != nil {
-			return nil, fmt.Errorf("%s", "无法创建Containerd运行时")
+			return nil, fmt.Errorf("无法创建Containerd运行时: %w", err)
 		}
+		healthChecks[runtime.NameContainerd] = runtimeImpl
+	case config.RuntimeCRI:
+		runtimeImpl, err = runtime.NewCRIRuntime(log, runtimes.CRI, containerTimeout, d.procRoot, d)
+		if err != nil {
+			return nil, fmt.Errorf("无法创建CRI运行时: %w", err)
+		}
+		healthChecks[runtime.NameCRI] = runtimeImpl
+	case config.RuntimeAuto:
+		multi, err := runtime.NewAutoRuntime(log, runtimes, containerTimeout, d.procRoot, d)
+		if err != nil {
+			return nil, fmt.Errorf("自动探测容器运行时失败: %w", err)
+		}
+		d.logger.Info("自动探测到容器运行时", "runtimes", multi.Names())
+		for _, name := range multi.Names() {
+			healthChecks[name] = multi.Runtime(name)
+		}
+		runtimeImpl = multi
+	default:
+		return nil, fmt.Errorf("不支持的容器运行时: %s", cfg.ContainerRuntime)
 	}
 
+	for name, rt := range healthChecks {
+		registerRuntimeHealthCheck(name, rt)
+	}
 	d.ContainerRuntime = runtimeImpl
 
+	if cfg.EventDetection {
+		source, err := NewNetlinkEventSource(log)
+		if err != nil {
+			// 事件检测只是加速手段，失败时退回纯轮询
+			d.logger.Warn("无法订阅进程事件，仅使用定时检测", "error", err)
+		} else {
+			d.eventWatcher = NewEventWatcher(source, d.procRoot, cfg.EventTriggerThreshold, cfg.EventMinTriggerInterval, log)
+		}
+	}
+
 	return d, nil
 }
 
+// registerRuntimeHealthCheck 在/health中检查运行时连接，并更新runtime_up指标
+func registerRuntimeHealthCheck(name string, rt runtime.ContainerRuntimeInterface) {
+	metrics.RegisterHealthCheck("runtime/"+name, func(ctx context.Context) error {
+		err := rt.Ping(ctx)
+		up := 1.0
+		if err != nil {
+			up = 0
+		}
+		metrics.RuntimeUp.WithLabelValues(metrics.GetNodeName(), name).Set(up)
+		return err
+	})
+	metrics.RuntimeUp.WithLabelValues(metrics.GetNodeName(), name).Set(1)
+}
+
+// StartEventWatcher 启动事件驱动检测，未启用时直接返回
+func (d *Detector) StartEventWatcher(ctx context.Context) {
+	if d.eventWatcher == nil {
+		return
+	}
+	d.logger.Info("启用事件驱动检测")
+	go d.eventWatcher.Run(ctx)
+}
+
+// ExcludeFromTrigger 设置清理器不会处置的僵尸进程，这些进程不计入事件触发阈值
+func (d *Detector) ExcludeFromTrigger(pids map[int]bool) {
+	if d.eventWatcher == nil {
+		return
+	}
+	d.eventWatcher.SetExcluded(pids)
+}
+
+// EventTriggers 返回事件触发的立即检测通道，未启用事件检测时返回nil
+func (d *Detector) EventTriggers() <-chan struct{} {
+	if d.eventWatcher == nil {
+		return nil
+	}
+	return d.eventWatcher.Triggers()
+}
+
 func (d *Detector) DetectZombies(ctx context.Context) ([]ZombieInfo, error) {
 	start := time.Now()
 	nodeName := metrics.GetNodeName()
@@ -84,48 +204,54 @@ func (d *Detector) DetectZombies(ctx context.Context) ([]ZombieInfo, error) {
 	// 清理旧的超时记录
 	d.CleanupOldTimeouts()
 
-	// 获取所有进程信息
-	fs, err := procfs.NewFS("/proc")
-	if err != nil {
-		return nil, fmt.Errorf("无法打开 /proc: %w", err)
-	}
+	// 单次遍历/proc，构建本周期的进程快照和收集僵尸进程
+	scanner := newProcScanner(d.procRoot)
+	snapshot := newProcSnapshot()
+	zombies := make(map[int]process.Stat)
+	// 处于D状态的线程，未启用检测时为空
+	stuckCfg := d.stuck.config()
+	var stuckCandidates []stuckCandidate
 
-	allProcs, err := fs.AllProcs()
+	err := scanner.scan(ctx, func(stat process.Stat) {
+		snapshot.add(stat)
+		if stat.State == "Z" {
+			zombies[stat.PID] = stat
+		}
+		if stuckCfg.Enabled {
+			if stuckCfg.ScanThreads {
+				stuckCandidates = append(stuckCandidates, stuckThreads(ctx, scanner, stat.PID)...)
+			} else if stat.State == "D" {
+				stuckCandidates = append(stuckCandidates, stuckCandidate{pid: stat.PID, Stat: stat})
+			}
+		}
+	})
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
 	if err != nil {
 		return nil, fmt.Errorf("获取进程信息失败: %w", err)
 	}
 
-	// 构建父进程映射和收集僵尸进程
-	parentMap := make(map[int][]int)
-	zombies := make(map[int]procfs.Proc)
-
-	for _, proc := range allProcs {
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		default:
-		}
-
-		stat, err := proc.Stat()
-		if err != nil {
-			continue
-		}
-		parentMap[stat.PPID] = append(parentMap[stat.PPID], stat.PID)
-		if stat.State == "Z" {
-			zombies[stat.PID] = proc
-		}
+	var stuck []StuckProcess
+	if stuckCfg.Enabled {
+		stuck = d.stuck.persistent(stuckCandidates, time.Now())
 	}
 
 	zombieCount := len(zombies)
 	d.logger.Info("发现僵尸进程", "count", zombieCount)
 	metrics.ZombieProcessesFound.WithLabelValues(nodeName).Set(float64(zombieCount))
 
-	if zombieCount == 0 {
+	// 有D状态进程时仍然需要容器列表来关联
+	if zombieCount == 0 && len(stuck) == 0 {
+		now := time.Now()
+		d.zombieAges.update(nil, now)
+		d.assessRisk(nil, now)
+		d.describeStuck(nil, nil, nil)
 		return nil, nil
 	}
 
 	// 获取容器PID树
-	containers, err := d.getContainerPIDTrees(ctx, parentMap)
+	containers, err := d.getContainerPIDTrees(ctx, snapshot)
 	if err != nil {
 		d.logger.Error("获取容器PID树失败", "error", err)
 		return nil, err
@@ -139,42 +265,67 @@ func (d *Detector) DetectZombies(ctx context.Context) ([]ZombieInfo, error) {
 		}
 	}
 
+	// cgroup模式下建立cgroup路径到容器的索引
+	var cgroups cgroupIndex
+	if d.attributionMode == config.AttributionCgroup {
+		cgroups = d.newCgroupIndex(containers)
+	}
+
+	d.describeStuck(stuck, pidToContainer, cgroups)
+
 	// 分析僵尸进程归属
 	var zombieInfos []ZombieInfo
-	for zpid, proc := range zombies {
+	for zpid, stat := range zombies {
 		select {
 		case <-ctx.Done():
 			return nil, ctx.Err()
 		default:
 		}
 
-		stat, err := proc.Stat()
-		if err != nil {
-			continue
-		}
-
 		// 获取完整的命令行参数
-		cmdline, err := proc.CmdLine()
+		cmdlineStr, err := readCmdline(d.procRoot, zpid)
 		if err != nil {
 			d.logger.Warn("无法获取进程命令行参数", "pid", zpid, "error", err)
 			// 如果无法获取命令行参数，回退到使用Comm
-			cmdline = []string{stat.Comm}
+			cmdlineStr = stat.Comm
 		}
 
-		cmdlineStr := strings.Join(cmdline, " ")
 		zombieInfo := ZombieInfo{
-			PID:     zpid,
-			PPID:    stat.PPID,
-			Cmdline: cmdlineStr,
+			PID:             zpid,
+			StartTime:       stat.StartTime,
+			PPID:            stat.PPID,
+			ParentStartTime: snapshot.startTime(stat.PPID),
+			ParentKind:      d.parentKind(stat.PPID),
+			Cmdline:         cmdlineStr,
+		}
+		if d.eventWatcher != nil {
+			if exitedAt, ok := d.eventWatcher.ExitedAt(zpid); ok {
+				zombieInfo.ExitedAt = exitedAt
+			}
+		}
+
+		// 检查僵尸进程是否属于容器，cgroup模式优先按cgroup归属，失败时回退到PID树
+		if cgroups != nil {
+			if container, ok := d.attributeByCgroup(zpid, cgroups); ok {
+				zombieInfo.Container = container
+				zombieInfo.IsInContainer = true
+				zombieInfo.AttributedBy = AttributedByCgroup
+			}
+		}
+		if !zombieInfo.IsInContainer {
+			if container, ok := pidToContainer[zpid]; ok {
+				zombieInfo.Container = container
+				zombieInfo.IsInContainer = true
+				zombieInfo.AttributedBy = AttributedByPIDTree
+			} else if container, ok := pidToContainer[stat.PPID]; ok {
+				zombieInfo.Container = container
+				zombieInfo.IsInContainer = true
+				zombieInfo.AttributedBy = AttributedByPIDTree
+			}
 		}
 
-		// 检查僵尸进程是否属于容器
-		if container, ok := pidToContainer[zpid]; ok {
-			zombieInfo.Container = container
-			zombieInfo.IsInContainer = true
-		} else if container, ok := pidToContainer[stat.PPID]; ok {
-			zombieInfo.Container = container
-			zombieInfo.IsInContainer = true
+		if !zombieInfo.IsInContainer {
+			d.describeHostParent(&zombieInfo)
 		}
 
 		zombieInfos = append(zombieInfos, zombieInfo)
@@ -183,104 +334,58 @@ func (d *Detector) DetectZombies(ctx context.Context) ([]ZombieInfo, error) {
 		if zombieInfo.IsInContainer {
 			d.logger.WithZombie(zpid, stat.PPID, cmdlineStr).
 				WithContainer(zombieInfo.Container.ID, zombieInfo.Container.PodName, zombieInfo.Container.PodNS).
-				Info("发现容器内僵尸进程")
+				Info("发现容器内僵尸进程", "attributed_by", zombieInfo.AttributedBy)
 		} else {
 			d.logger.WithZombie(zpid, stat.PPID, cmdlineStr).
-				Info("发现宿主机僵尸进程")
+				Info("发现宿主机僵尸进程", "parent_comm", zombieInfo.ParentComm, "systemd_unit", zombieInfo.SystemdUnit)
 		}
 	}
 
+	now := time.Now()
+	d.zombieAges.update(zombieInfos, now)
+	d.assessRisk(zombieInfos, now)
 	return zombieInfos, nil
 }
 
-func (d *Detector) getContainerPIDTrees(ctx context.Context, parentMap map[int][]int) ([]ContainerMeta, error) {
-	var (
-		resultMu  sync.Mutex
-		result    []ContainerMeta
-		wg        sync.WaitGroup
-		semaphore = make(chan struct{}, 20) // 限制并发数量
-	)
-
-	// 获取容器列表
+// getContainerPIDTrees 获取容器列表，并根据本周期的进程快照计算每个容器的进程子树
+func (d *Detector) getContainerPIDTrees(ctx context.Context, snapshot *procSnapshot) ([]ContainerMeta, error) {
 	containers, err := d.ContainerRuntime.ListContainers(ctx)
 	if err != nil {
 		d.logger.Error("获取容器列表失败", "error", err)
-		return result, err
+		return nil, err
 	}
 
-	// 构建PID树并填充容器信息
 	for i := range containers {
-		select {
-		case <-ctx.Done():
-			return result, ctx.Err()
-		case semaphore <- struct{}{}:
-		}
-
-		wg.Add(1)
-		go func(container runtime.ContainerMeta) {
-			defer func() {
-				<-semaphore
-				wg.Done()
-			}()
-
-			// 构建PID树
-			container.PIDSet = d.buildPIDTree(container.PID, parentMap)
-
-			resultMu.Lock()
-			result = append(result, container)
-			resultMu.Unlock()
-		}(containers[i])
+		containers[i].InitStartTime = snapshot.startTime(containers[i].PID)
 	}
+	snapshot.containerTrees(containers)
+	metrics.TrackedContainers.WithLabelValues(metrics.GetNodeName()).Set(float64(len(containers)))
 
-	wg.Wait()
-	metrics.TrackedContainers.WithLabelValues(metrics.GetNodeName()).Set(float64(len(result)))
-
-	return result, nil
+	return containers, nil
 }
 
-func (d *Detector) buildPIDTree(root int, parentMap map[int][]int) map[int]bool {
-	// 检查缓存
-	d.pidTreeCache.mu.Lock()
-	if cached, ok := d.pidTreeCache.m[root]; ok {
-		d.pidTreeCache.mu.Unlock()
-		return cached
-	}
-	d.pidTreeCache.mu.Unlock()
-
-	// 构建PID树
-	tree := make(map[int]bool)
-	visited := make(map[int]bool)
+// ProcRoot 返回检测时读取的proc文件系统
+func (d *Detector) ProcRoot() string {
+	return d.procRoot
+}
 
-	var walk func(pid int)
-	walk = func(pid int) {
-		if visited[pid] {
-			return
-		}
-		visited[pid] = true
-		tree[pid] = true
-		// 添加边界检查，防止无限递归
-		if pid < 0 || pid > 4194304 { // kernel.pid_max = 4194304
-			return
-		}
-		for _, child := range parentMap[pid] {
-			// 添加子PID的边界检查
-			if child >= 0 && child <= 4194304 {
-				walk(child)
-			}
-		}
+// IsZombie 检查ref对应的进程当前是否仍处于僵尸状态。进程不存在或PID已被复用（启动时间不一致）时返回false
+func (d *Detector) IsZombie(ref process.Ref) bool {
+	stat, err := process.ReadStat(d.procRoot, ref.PID)
+	if err != nil || stat.State != "Z" {
+		return false
 	}
-	walk(root)
+	return ref.StartTime == 0 || stat.StartTime == ref.StartTime
+}
 
-	// 缓存结果，但限制缓存大小以防止内存泄漏
-	d.pidTreeCache.mu.Lock()
-	// 如果缓存太大，清空它以避免内存泄漏
-	if len(d.pidTreeCache.m) > 1000 {
-		d.pidTreeCache.m = make(map[int]map[int]bool)
+// attributeByCgroup 根据僵尸进程的cgroup查找所属容器
+func (d *Detector) attributeByCgroup(pid int, cgroups cgroupIndex) (*ContainerMeta, bool) {
+	cgroupPath, err := readCgroupPath(d.procRoot, pid)
+	if err != nil {
+		d.logger.Debug("读取僵尸进程cgroup失败", "pid", pid, "error", err)
+		return nil, false
 	}
-	d.pidTreeCache.m[root] = tree
-	d.pidTreeCache.mu.Unlock()
-
-	return tree
+	return cgroups.lookup(cgroupPath)
 }
 
 func (d *Detector) RecordTimeoutContainer(containerID string) {
@@ -320,8 +425,13 @@ func (d *Detector) GetTimeoutContainers() []string {
 	return containers
 }
 
-// Close 关闭容器运行时连接
+// Close 关闭事件源和容器运行时连接
 func (d *Detector) Close() error {
+	if d.eventWatcher != nil {
+		if err := d.eventWatcher.Close(); err != nil {
+			d.logger.Warn("关闭进程事件源失败", "error", err)
+		}
+	}
 	if d.ContainerRuntime != nil {
 		return d.ContainerRuntime.Close()
 	}
@@ -0,0 +1,37 @@
+package detector
+
+import (
+	"testing"
+
+	"github.com/tiggoins/zombie-cleaner/internal/process"
+)
+
+func TestIsZombie(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"10/stat": statLine(10, "worker", "Z", 1, 100),
+		"11/stat": statLine(11, "worker", "S", 1, 110),
+		// 原僵尸进程已被回收，PID被新的僵尸进程复用
+		"12/stat": statLine(12, "curl", "Z", 1, 500),
+	})
+	d := &Detector{procRoot: root}
+
+	tests := []struct {
+		name string
+		ref  process.Ref
+		want bool
+	}{
+		{name: "仍是僵尸进程", ref: process.Ref{PID: 10, StartTime: 100}, want: true},
+		{name: "进程正在运行", ref: process.Ref{PID: 11, StartTime: 110}},
+		{name: "PID被复用", ref: process.Ref{PID: 12, StartTime: 120}},
+		{name: "进程不存在", ref: process.Ref{PID: 13, StartTime: 130}},
+		{name: "缺少启动时间时只检查状态", ref: process.Ref{PID: 12}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := d.IsZombie(tt.ref); got != tt.want {
+				t.Errorf("IsZombie(%+v) = %v, 期望 %v", tt.ref, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package detector
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/tiggoins/zombie-cleaner/internal/logger"
+	"github.com/tiggoins/zombie-cleaner/internal/metrics"
+	"github.com/tiggoins/zombie-cleaner/internal/process"
+)
+
+// ProcEventType 进程事件类型
+type ProcEventType string
+
+const (
+	ProcEventFork ProcEventType = "fork"
+	ProcEventExit ProcEventType = "exit"
+)
+
+const (
+	// 进程退出后等待父进程回收的宽限时间，超过后才视为未回收
+	exitReapGrace = 500 * time.Millisecond
+	// 核对待回收进程状态的周期
+	exitVerifyInterval = time.Second
+	// 跟踪表的最大容量，防止事件风暴时内存无限增长
+	maxTrackedExits = 65536
+)
+
+// ProcEvent 进程事件，PID/PPID均为线程组ID
+type ProcEvent struct {
+	Type ProcEventType
+	PID  int
+	PPID int
+	Time time.Time
+}
+
+// EventSource 进程事件来源，生产环境为netlink proc connector，测试中可注入合成事件
+type EventSource interface {
+	// Events 返回事件通道，通道在Close后关闭
+	Events() <-chan ProcEvent
+	// Close 停止接收事件
+	Close() error
+}
+
+// exitRecord 已退出进程的跟踪记录
+type exitRecord struct {
+	ppid     int
+	exitedAt time.Time
+	zombie   bool
+}
+
+// EventWatcher 根据进程事件实时跟踪已退出但未被回收的子进程，
+// 数量超过阈值时通过Triggers通知调用方立即执行一次检测
+type EventWatcher struct {
+	logger             *logger.Logger
+	source             EventSource
+	threshold          int
+	minTriggerInterval time.Duration
+
+	// readState 读取进程状态，测试中可替换
+	readState func(pid int) (string, error)
+
+	mu          sync.Mutex
+	parents     map[int]int
+	exits       map[int]*exitRecord
+	lastTrigger time.Time
+	// 清理器不会处置的僵尸进程（宿主机、白名单、策略忽略等），不计入触发阈值
+	excluded map[int]bool
+
+	triggers chan struct{}
+}
+
+// NewEventWatcher 创建事件跟踪器，复查进程状态时读取procRoot
+func NewEventWatcher(source EventSource, procRoot string, threshold int, minTriggerInterval time.Duration, log *logger.Logger) *EventWatcher {
+	return &EventWatcher{
+		logger:             log.WithComponent("event-watcher"),
+		source:             source,
+		threshold:          threshold,
+		minTriggerInterval: minTriggerInterval,
+		readState: func(pid int) (string, error) {
+			return readProcState(procRoot, pid)
+		},
+		parents:  make(map[int]int),
+		exits:    make(map[int]*exitRecord),
+		triggers: make(chan struct{}, 1),
+	}
+}
+
+// Triggers 返回立即检测的触发通道
+func (w *EventWatcher) Triggers() <-chan struct{} {
+	return w.triggers
+}
+
+// Run 处理事件直到ctx取消或事件源关闭
+func (w *EventWatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(exitVerifyInterval)
+	defer ticker.Stop()
+
+	events := w.source.Events()
+	nodeName := metrics.GetNodeName()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				w.logger.Warn("进程事件源已关闭，仅依赖定时检测")
+				return
+			}
+			metrics.ProcEventsReceived.WithLabelValues(nodeName, string(event.Type)).Inc()
+			w.handleEvent(event)
+		case now := <-ticker.C:
+			w.verify(now)
+		}
+	}
+}
+
+// Close 关闭事件源
+func (w *EventWatcher) Close() error {
+	return w.source.Close()
+}
+
+// UnreapedCount 返回当前已确认未被回收的子进程数量
+func (w *EventWatcher) UnreapedCount() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.unreapedLocked(false)
+}
+
+// SetExcluded 设置不计入触发阈值的僵尸进程，每个检测周期整体替换
+func (w *EventWatcher) SetExcluded(pids map[int]bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.excluded = pids
+}
+
+// ExitedAt 返回事件记录的进程退出时间，未跟踪到该进程时返回false
+func (w *EventWatcher) ExitedAt(pid int) (time.Time, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	record, ok := w.exits[pid]
+	if !ok {
+		return time.Time{}, false
+	}
+	return record.exitedAt, true
+}
+
+func (w *EventWatcher) handleEvent(event ProcEvent) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	switch event.Type {
+	case ProcEventFork:
+		if len(w.parents) < maxTrackedExits {
+			w.parents[event.PID] = event.PPID
+		}
+	case ProcEventExit:
+		ppid := event.PPID
+		if ppid <= 0 {
+			ppid = w.parents[event.PID]
+		}
+		delete(w.parents, event.PID)
+		if len(w.exits) >= maxTrackedExits {
+			return
+		}
+		exitedAt := event.Time
+		if exitedAt.IsZero() {
+			exitedAt = time.Now()
+		}
+		w.exits[event.PID] = &exitRecord{ppid: ppid, exitedAt: exitedAt}
+	}
+}
+
+// verify 核对已退出进程是否仍处于僵尸状态，已回收的记录会被移除
+func (w *EventWatcher) verify(now time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for pid, record := range w.exits {
+		if now.Sub(record.exitedAt) < exitReapGrace {
+			continue
+		}
+		state, err := w.readState(pid)
+		if err != nil || state != "Z" {
+			// 进程已被回收（或PID已被复用）
+			delete(w.exits, pid)
+			continue
+		}
+		record.zombie = true
+	}
+
+	metrics.UnreapedChildren.WithLabelValues(metrics.GetNodeName()).Set(float64(w.unreapedLocked(false)))
+
+	unreaped := w.unreapedLocked(true)
+	if unreaped < w.threshold || now.Sub(w.lastTrigger) < w.minTriggerInterval {
+		return
+	}
+
+	select {
+	case w.triggers <- struct{}{}:
+		w.lastTrigger = now
+		w.logger.Warn("未回收子进程数量超过阈值，触发立即检测",
+			"unreaped", unreaped,
+			"threshold", w.threshold)
+	default:
+		// 已有待处理的触发
+	}
+}
+
+// unreapedLocked 统计未回收的子进程，actionableOnly为true时排除清理器不会处置的进程
+func (w *EventWatcher) unreapedLocked(actionableOnly bool) int {
+	count := 0
+	for pid, record := range w.exits {
+		if !record.zombie {
+			continue
+		}
+		if actionableOnly && w.excluded[pid] {
+			continue
+		}
+		count++
+	}
+	return count
+}
+
+// readProcState 从procRoot/<pid>/stat读取进程状态
+func readProcState(procRoot string, pid int) (string, error) {
+	stat, err := process.ReadStat(procRoot, pid)
+	if err != nil {
+		return "", err
+	}
+	return stat.State, nil
+}
@@ -0,0 +1,238 @@
+package detector
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tiggoins/zombie-cleaner/internal/logger"
+)
+
+// fakeEventSource 注入合成进程事件
+type fakeEventSource struct {
+	events chan ProcEvent
+}
+
+func newFakeEventSource() *fakeEventSource {
+	return &fakeEventSource{events: make(chan ProcEvent, 16)}
+}
+
+func (s *fakeEventSource) Events() <-chan ProcEvent { return s.events }
+
+func (s *fakeEventSource) Close() error {
+	close(s.events)
+	return nil
+}
+
+// newTestWatcher 创建使用给定进程状态表的事件跟踪器，不在表中的进程视为已被回收
+func newTestWatcher(threshold int, minInterval time.Duration, states map[int]string) *EventWatcher {
+	w := NewEventWatcher(newFakeEventSource(), "", threshold, minInterval, logger.New("error", "text"))
+	w.readState = func(pid int) (string, error) {
+		state, ok := states[pid]
+		if !ok {
+			return "", errors.New("no such process")
+		}
+		return state, nil
+	}
+	return w
+}
+
+func triggered(w *EventWatcher) bool {
+	select {
+	case <-w.Triggers():
+		return true
+	default:
+		return false
+	}
+}
+
+func TestEventWatcherReadsProcRoot(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"10/stat": statLine(10, "worker", "Z", 1, 100),
+	})
+	w := NewEventWatcher(newFakeEventSource(), root, 1, 0, logger.New("error", "text"))
+	if state, err := w.readState(10); err != nil || state != "Z" {
+		t.Errorf("readState(10) = %q, %v, 期望 Z", state, err)
+	}
+	if _, err := w.readState(11); err == nil {
+		t.Error("进程不存在时readState()应返回错误")
+	}
+}
+
+func TestEventWatcherGracePeriod(t *testing.T) {
+	base := time.Now()
+	w := newTestWatcher(1, 0, map[int]string{100: "Z"})
+
+	w.handleEvent(ProcEvent{Type: ProcEventExit, PID: 100, PPID: 1, Time: base})
+
+	// 宽限期内不核对状态
+	w.verify(base.Add(exitReapGrace / 2))
+	if got := w.UnreapedCount(); got != 0 {
+		t.Fatalf("宽限期内UnreapedCount = %d, 期望0", got)
+	}
+	if triggered(w) {
+		t.Fatal("宽限期内不应触发检测")
+	}
+
+	w.verify(base.Add(exitReapGrace))
+	if got := w.UnreapedCount(); got != 1 {
+		t.Fatalf("宽限期后UnreapedCount = %d, 期望1", got)
+	}
+	if !triggered(w) {
+		t.Fatal("达到阈值后应触发检测")
+	}
+}
+
+func TestEventWatcherReapedVsZombie(t *testing.T) {
+	base := time.Now()
+	states := map[int]string{
+		// 仍是僵尸进程
+		100: "Z",
+		// PID已被复用为正常进程
+		101: "S",
+		// 102不在表中：已被回收
+	}
+	w := newTestWatcher(10, 0, states)
+
+	for _, pid := range []int{100, 101, 102} {
+		w.handleEvent(ProcEvent{Type: ProcEventExit, PID: pid, PPID: 50, Time: base})
+	}
+	w.verify(base.Add(time.Second))
+
+	if got := w.UnreapedCount(); got != 1 {
+		t.Fatalf("UnreapedCount = %d, 期望1", got)
+	}
+	if _, ok := w.ExitedAt(101); ok {
+		t.Error("PID被复用的记录应被移除")
+	}
+	if _, ok := w.ExitedAt(102); ok {
+		t.Error("已回收的记录应被移除")
+	}
+	exitedAt, ok := w.ExitedAt(100)
+	if !ok || !exitedAt.Equal(base) {
+		t.Errorf("ExitedAt(100) = (%v, %v), 期望 (%v, true)", exitedAt, ok, base)
+	}
+}
+
+func TestEventWatcherExitWithoutParentUsesFork(t *testing.T) {
+	w := newTestWatcher(10, 0, nil)
+
+	w.handleEvent(ProcEvent{Type: ProcEventFork, PID: 200, PPID: 42})
+	// 旧内核的exit事件不包含父进程
+	w.handleEvent(ProcEvent{Type: ProcEventExit, PID: 200})
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	record, ok := w.exits[200]
+	if !ok {
+		t.Fatal("exit事件未被跟踪")
+	}
+	if record.ppid != 42 {
+		t.Errorf("ppid = %d, 期望取自fork事件的42", record.ppid)
+	}
+	if record.exitedAt.IsZero() {
+		t.Error("缺少事件时间时应使用当前时间")
+	}
+	if _, ok := w.parents[200]; ok {
+		t.Error("进程退出后应移除fork记录")
+	}
+}
+
+func TestEventWatcherThrottling(t *testing.T) {
+	base := time.Now()
+	states := map[int]string{1: "Z", 2: "Z", 3: "Z"}
+	w := newTestWatcher(2, time.Minute, states)
+
+	w.handleEvent(ProcEvent{Type: ProcEventExit, PID: 1, Time: base})
+	w.verify(base.Add(time.Second))
+	if triggered(w) {
+		t.Fatal("未达到阈值不应触发检测")
+	}
+
+	w.handleEvent(ProcEvent{Type: ProcEventExit, PID: 2, Time: base})
+	now := base.Add(2 * time.Second)
+	w.verify(now)
+	if !triggered(w) {
+		t.Fatal("达到阈值应触发检测")
+	}
+
+	// 最小间隔内不重复触发
+	w.handleEvent(ProcEvent{Type: ProcEventExit, PID: 3, Time: base})
+	w.verify(now.Add(30 * time.Second))
+	if triggered(w) {
+		t.Fatal("最小触发间隔内不应再次触发")
+	}
+
+	w.verify(now.Add(time.Minute))
+	if !triggered(w) {
+		t.Fatal("超过最小触发间隔后应再次触发")
+	}
+}
+
+func TestEventWatcherExcludedNotCounted(t *testing.T) {
+	base := time.Now()
+	w := newTestWatcher(2, 0, map[int]string{1: "Z", 2: "Z"})
+
+	w.handleEvent(ProcEvent{Type: ProcEventExit, PID: 1, Time: base})
+	w.handleEvent(ProcEvent{Type: ProcEventExit, PID: 2, Time: base})
+	w.SetExcluded(map[int]bool{2: true})
+
+	w.verify(base.Add(time.Second))
+	if triggered(w) {
+		t.Fatal("被排除的僵尸进程不应计入触发阈值")
+	}
+	if got := w.UnreapedCount(); got != 2 {
+		t.Errorf("UnreapedCount = %d, 被排除的进程仍应计入总数", got)
+	}
+
+	w.SetExcluded(nil)
+	w.verify(base.Add(2 * time.Second))
+	if !triggered(w) {
+		t.Fatal("取消排除后应触发检测")
+	}
+}
+
+func TestEventWatcherMaxTrackedExits(t *testing.T) {
+	w := newTestWatcher(1, 0, nil)
+
+	for pid := 1; pid <= maxTrackedExits+10; pid++ {
+		w.handleEvent(ProcEvent{Type: ProcEventFork, PID: pid, PPID: 1})
+		w.handleEvent(ProcEvent{Type: ProcEventExit, PID: pid, PPID: 1})
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.exits) != maxTrackedExits {
+		t.Errorf("跟踪的退出记录数 = %d, 期望上限 %d", len(w.exits), maxTrackedExits)
+	}
+	if _, ok := w.exits[maxTrackedExits+1]; ok {
+		t.Error("超过上限的退出事件不应被跟踪")
+	}
+}
+
+func TestEventWatcherRunStopsWhenSourceClosed(t *testing.T) {
+	w := newTestWatcher(1, 0, nil)
+	source := w.source.(*fakeEventSource)
+
+	done := make(chan struct{})
+	go func() {
+		w.Run(context.Background())
+		close(done)
+	}()
+
+	source.events <- ProcEvent{Type: ProcEventExit, PID: 7, PPID: 1, Time: time.Now()}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close返回错误: %v", err)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("事件源关闭后Run未退出")
+	}
+	if _, ok := w.ExitedAt(7); !ok {
+		t.Error("关闭前注入的事件应已被处理")
+	}
+}
@@ -0,0 +1,34 @@
+package detector
+
+import (
+	"strings"
+
+	"github.com/tiggoins/zombie-cleaner/internal/process"
+)
+
+// describeHostParent 为宿主机僵尸进程补全父进程名称和父进程所属的systemd unit
+func (d *Detector) describeHostParent(zombie *ZombieInfo) {
+	if stat, err := process.ReadStat(d.procRoot, zombie.PPID); err == nil {
+		zombie.ParentComm = stat.Comm
+	}
+	// 僵尸进程由父进程负责回收，按父进程的cgroup确定unit，父进程已退出时退回到僵尸进程自身
+	for _, pid := range []int{zombie.PPID, zombie.PID} {
+		cgroupPath, err := readCgroupPath(d.procRoot, pid)
+		if err != nil {
+			continue
+		}
+		zombie.SystemdUnit = systemdUnit(cgroupPath)
+		return
+	}
+}
+
+// systemdUnit 返回cgroup路径中最内层的systemd service或scope，不属于任何unit时为空
+func systemdUnit(cgroupPath string) string {
+	parts := strings.Split(cgroupPath, "/")
+	for i := len(parts) - 1; i >= 0; i-- {
+		if strings.HasSuffix(parts[i], ".service") || strings.HasSuffix(parts[i], ".scope") {
+			return parts[i]
+		}
+	}
+	return ""
+}
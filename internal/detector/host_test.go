@@ -0,0 +1,41 @@
+package detector
+
+import "testing"
+
+func TestSystemdUnit(t *testing.T) {
+	tests := map[string]string{
+		"/system.slice/kubelet.service":                             "kubelet.service",
+		"/user.slice/user-1000.slice/session-3.scope":               "session-3.scope",
+		"/system.slice/containerd.service/kubepods-burstable.slice": "containerd.service",
+		"/":             "",
+		"/system.slice": "",
+	}
+	for cgroupPath, want := range tests {
+		if got := systemdUnit(cgroupPath); got != want {
+			t.Errorf("systemdUnit(%q) = %q, 期望 %q", cgroupPath, got, want)
+		}
+	}
+}
+
+func TestDescribeHostParent(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"50/stat":   "50 (node-agent) S 1 50 50 0 -1 4194560 0 0 0 0 0 0 0 0 20 0 1 0 1234 0 0\n",
+		"50/cgroup": "0::/system.slice/node-agent.service\n",
+		// 父进程已退出，按僵尸进程自身的cgroup
+		"61/cgroup": "12:pids:/system.slice/cron.service\n1:name=systemd:/system.slice/cron.service\n",
+	})
+	d := &Detector{procRoot: root}
+
+	zombie := ZombieInfo{PID: 51, PPID: 50}
+	d.describeHostParent(&zombie)
+	if zombie.ParentComm != "node-agent" || zombie.SystemdUnit != "node-agent.service" {
+		t.Errorf("ParentComm = %q, SystemdUnit = %q", zombie.ParentComm, zombie.SystemdUnit)
+	}
+
+	orphan := ZombieInfo{PID: 61, PPID: 60}
+	d.describeHostParent(&orphan)
+	if orphan.ParentComm != "" || orphan.SystemdUnit != "cron.service" {
+		t.Errorf("ParentComm = %q, SystemdUnit = %q", orphan.ParentComm, orphan.SystemdUnit)
+	}
+}
@@ -0,0 +1,195 @@
+//go:build linux
+
+package detector
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/tiggoins/zombie-cleaner/internal/logger"
+)
+
+// 内核connector协议常量，见include/uapi/linux/connector.h和cn_proc.h
+const (
+	cnIdxProc          = 0x1
+	cnValProc          = 0x1
+	procCnMcastListen  = 1
+	procCnMcastIgnore  = 2
+	procEventFork      = 0x00000001
+	procEventExit      = 0x80000000
+	cnMsgHeaderLen     = 20
+	procEventHeaderLen = 16
+	netlinkRecvBufSize = 4 * 1024 * 1024
+	// 接收超时，用于定期检查事件源是否已关闭
+	netlinkRecvTimeout = time.Second
+)
+
+// netlinkEventSource 通过NETLINK_CONNECTOR订阅内核进程事件
+type netlinkEventSource struct {
+	fd        int
+	logger    *logger.Logger
+	events    chan ProcEvent
+	closed    atomic.Bool
+	closeOnce sync.Once
+}
+
+// NewNetlinkEventSource 创建netlink proc connector事件源，需要CAP_NET_ADMIN且位于宿主机网络命名空间
+func NewNetlinkEventSource(log *logger.Logger) (EventSource, error) {
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, unix.NETLINK_CONNECTOR)
+	if err != nil {
+		return nil, fmt.Errorf("创建netlink socket失败: %w", err)
+	}
+
+	// 事件风暴时尽量避免ENOBUFS丢事件
+	_ = unix.SetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_RCVBUFFORCE, netlinkRecvBufSize)
+	tv := unix.NsecToTimeval(netlinkRecvTimeout.Nanoseconds())
+	if err := unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &tv); err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("设置netlink接收超时失败: %w", err)
+	}
+
+	if err := unix.Bind(fd, &unix.SockaddrNetlink{Family: unix.AF_NETLINK, Groups: cnIdxProc}); err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("绑定netlink socket失败: %w", err)
+	}
+
+	s := &netlinkEventSource{
+		fd:     fd,
+		logger: log.WithComponent("netlink"),
+		events: make(chan ProcEvent, 1024),
+	}
+
+	if err := s.sendMcastOp(procCnMcastListen); err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("订阅进程事件失败: %w", err)
+	}
+
+	go s.receive()
+	return s, nil
+}
+
+func (s *netlinkEventSource) Events() <-chan ProcEvent {
+	return s.events
+}
+
+func (s *netlinkEventSource) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		err = s.sendMcastOp(procCnMcastIgnore)
+		// receive在下一次接收超时后关闭fd和事件通道
+		s.closed.Store(true)
+	})
+	return err
+}
+
+// sendMcastOp 发送PROC_CN_MCAST_LISTEN/IGNORE控制消息
+func (s *netlinkEventSource) sendMcastOp(op uint32) error {
+	buf := make([]byte, unix.NLMSG_HDRLEN+cnMsgHeaderLen+4)
+	ne := binary.NativeEndian
+
+	// nlmsghdr
+	ne.PutUint32(buf[0:4], uint32(len(buf)))
+	ne.PutUint16(buf[4:6], unix.NLMSG_DONE)
+	ne.PutUint32(buf[12:16], uint32(os.Getpid()))
+
+	// cn_msg
+	cn := buf[unix.NLMSG_HDRLEN:]
+	ne.PutUint32(cn[0:4], cnIdxProc)
+	ne.PutUint32(cn[4:8], cnValProc)
+	ne.PutUint16(cn[16:18], 4)
+	ne.PutUint32(cn[cnMsgHeaderLen:], op)
+
+	return unix.Sendto(s.fd, buf, 0, &unix.SockaddrNetlink{Family: unix.AF_NETLINK})
+}
+
+func (s *netlinkEventSource) receive() {
+	defer close(s.events)
+	defer unix.Close(s.fd)
+
+	buf := make([]byte, os.Getpagesize()*4)
+	for !s.closed.Load() {
+		n, _, err := unix.Recvfrom(s.fd, buf, 0)
+		if err != nil {
+			if errors.Is(err, unix.EINTR) || errors.Is(err, unix.EAGAIN) {
+				continue
+			}
+			if errors.Is(err, unix.ENOBUFS) {
+				// 内核缓冲区溢出，部分事件丢失，由定时检测兜底
+				s.logger.Warn("netlink接收缓冲区溢出，部分进程事件丢失")
+				continue
+			}
+			s.logger.Warn("接收netlink消息失败", "error", err)
+			return
+		}
+
+		msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			s.logger.Debug("解析netlink消息失败", "error", err)
+			continue
+		}
+		for _, msg := range msgs {
+			event, ok := parseProcEvent(msg.Data)
+			if !ok {
+				continue
+			}
+			select {
+			case s.events <- event:
+			default:
+				// 消费方处理不过来时丢弃事件，由定时检测兜底
+			}
+		}
+	}
+}
+
+// parseProcEvent 解析cn_msg中的proc_event，只保留线程组leader的fork/exit事件
+func parseProcEvent(data []byte) (ProcEvent, bool) {
+	if len(data) < cnMsgHeaderLen+procEventHeaderLen {
+		return ProcEvent{}, false
+	}
+	ne := binary.NativeEndian
+	ev := data[cnMsgHeaderLen:]
+	what := ne.Uint32(ev[0:4])
+	body := ev[procEventHeaderLen:]
+
+	switch what {
+	case procEventFork:
+		// parent_pid, parent_tgid, child_pid, child_tgid
+		if len(body) < 16 {
+			return ProcEvent{}, false
+		}
+		childPID, childTGID := ne.Uint32(body[8:12]), ne.Uint32(body[12:16])
+		if childPID != childTGID {
+			return ProcEvent{}, false // 线程创建
+		}
+		return ProcEvent{
+			Type: ProcEventFork,
+			PID:  int(childTGID),
+			PPID: int(ne.Uint32(body[4:8])),
+			Time: time.Now(),
+		}, true
+	case procEventExit:
+		// process_pid, process_tgid, exit_code, exit_signal, parent_pid, parent_tgid
+		if len(body) < 16 {
+			return ProcEvent{}, false
+		}
+		pid, tgid := ne.Uint32(body[0:4]), ne.Uint32(body[4:8])
+		if pid != tgid {
+			return ProcEvent{}, false // 线程退出
+		}
+		event := ProcEvent{Type: ProcEventExit, PID: int(tgid), Time: time.Now()}
+		// 旧内核的exit事件不包含父进程信息
+		if len(body) >= 24 {
+			event.PPID = int(ne.Uint32(body[20:24]))
+		}
+		return event, true
+	}
+	return ProcEvent{}, false
+}
@@ -0,0 +1,97 @@
+//go:build linux
+
+package detector
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// buildProcEvent 构造cn_msg+proc_event负载，body为事件数据部分
+func buildProcEvent(what uint32, body ...uint32) []byte {
+	data := make([]byte, cnMsgHeaderLen+procEventHeaderLen+4*len(body))
+	ne := binary.NativeEndian
+	ne.PutUint32(data[cnMsgHeaderLen:], what)
+	for i, v := range body {
+		ne.PutUint32(data[cnMsgHeaderLen+procEventHeaderLen+4*i:], v)
+	}
+	return data
+}
+
+func TestParseProcEvent(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   []byte
+		want   ProcEvent
+		wantOK bool
+	}{
+		{
+			name:   "fork事件",
+			data:   buildProcEvent(procEventFork, 10, 10, 20, 20),
+			want:   ProcEvent{Type: ProcEventFork, PID: 20, PPID: 10},
+			wantOK: true,
+		},
+		{
+			name:   "线程创建",
+			data:   buildProcEvent(procEventFork, 10, 10, 21, 20),
+			wantOK: false,
+		},
+		{
+			name: "exit事件携带父进程",
+			// process_pid, process_tgid, exit_code, exit_signal, parent_pid, parent_tgid
+			data:   buildProcEvent(procEventExit, 30, 30, 0, 17, 10, 10),
+			want:   ProcEvent{Type: ProcEventExit, PID: 30, PPID: 10},
+			wantOK: true,
+		},
+		{
+			name:   "旧内核exit事件不含父进程",
+			data:   buildProcEvent(procEventExit, 30, 30, 0, 17),
+			want:   ProcEvent{Type: ProcEventExit, PID: 30},
+			wantOK: true,
+		},
+		{
+			name:   "线程退出",
+			data:   buildProcEvent(procEventExit, 31, 30, 0, 17, 10, 10),
+			wantOK: false,
+		},
+		{
+			name:   "截断的exit事件",
+			data:   buildProcEvent(procEventExit, 30, 30),
+			wantOK: false,
+		},
+		{
+			name:   "截断的fork事件",
+			data:   buildProcEvent(procEventFork, 10, 10, 20),
+			wantOK: false,
+		},
+		{
+			name:   "其他事件类型",
+			data:   buildProcEvent(0x00000002, 1, 1, 1, 1),
+			wantOK: false,
+		},
+		{
+			name:   "消息头不完整",
+			data:   make([]byte, cnMsgHeaderLen+procEventHeaderLen-1),
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseProcEvent(tt.data)
+			if ok != tt.wantOK {
+				t.Fatalf("parseProcEvent() ok = %v, 期望 %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if got.Time.IsZero() {
+				t.Error("事件时间不应为零值")
+			}
+			got.Time = tt.want.Time
+			if got != tt.want {
+				t.Errorf("parseProcEvent() = %+v, 期望 %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package detector
+
+import (
+	"errors"
+
+	"github.com/tiggoins/zombie-cleaner/internal/logger"
+)
+
+// NewNetlinkEventSource netlink proc connector仅在Linux上可用
+func NewNetlinkEventSource(log *logger.Logger) (EventSource, error) {
+	return nil, errors.New("netlink proc connector仅支持Linux")
+}
@@ -0,0 +1,51 @@
+package detector
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// ParentKind 僵尸进程父进程的类型
+type ParentKind string
+
+const (
+	// ParentProcess 普通进程
+	ParentProcess ParentKind = "process"
+	// ParentContainerInit 容器PID命名空间的init进程（容器内PID为1），通常是不回收子进程的入口进程
+	ParentContainerInit ParentKind = "container_init"
+	// ParentHostInit 宿主机的init进程
+	ParentHostInit ParentKind = "host_init"
+)
+
+// parentKind 判断僵尸进程的父进程是宿主机init、容器init还是普通进程。
+// 父进程在其PID命名空间中的PID为1时视为容器init；启用shareProcessNamespace时容器init是pause进程
+func (d *Detector) parentKind(ppid int) ParentKind {
+	if ppid == 1 {
+		return ParentHostInit
+	}
+	if nsPID, err := readNSPID(d.procRoot, ppid); err == nil && nsPID == 1 {
+		return ParentContainerInit
+	}
+	return ParentProcess
+}
+
+// readNSPID 读取进程在其所属的最内层PID命名空间中的PID（/proc/<pid>/status的NSpid最后一列）
+func readNSPID(procRoot string, pid int) (int, error) {
+	data, err := os.ReadFile(filepath.Join(procRoot, strconv.Itoa(pid), "status"))
+	if err != nil {
+		return 0, err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := bytes.Fields(scanner.Bytes())
+		if len(fields) < 2 || string(fields[0]) != "NSpid:" {
+			continue
+		}
+		return strconv.Atoi(string(fields[len(fields)-1]))
+	}
+	return 0, fmt.Errorf("进程%d的status中没有NSpid", pid)
+}
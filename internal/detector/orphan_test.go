@@ -0,0 +1,33 @@
+package detector
+
+import "testing"
+
+func TestParentKind(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		// 容器入口进程，在容器PID命名空间中为1
+		"200/status": "Name:\tsh\nPid:\t200\nPPid:\t150\nNSpid:\t200\t1\n",
+		// 容器内的普通进程
+		"201/status": "Name:\tjava\nPid:\t201\nPPid:\t200\nNSpid:\t201\t7\n",
+		// 宿主机进程
+		"300/status": "Name:\tkubelet\nPid:\t300\nPPid:\t1\nNSpid:\t300\n",
+	})
+	d := &Detector{procRoot: root}
+
+	tests := []struct {
+		ppid int
+		want ParentKind
+	}{
+		{ppid: 1, want: ParentHostInit},
+		{ppid: 200, want: ParentContainerInit},
+		{ppid: 201, want: ParentProcess},
+		{ppid: 300, want: ParentProcess},
+		// 父进程已退出
+		{ppid: 400, want: ParentProcess},
+	}
+	for _, tt := range tests {
+		if got := d.parentKind(tt.ppid); got != tt.want {
+			t.Errorf("parentKind(%d) = %s, 期望 %s", tt.ppid, got, tt.want)
+		}
+	}
+}
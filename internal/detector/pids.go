@@ -0,0 +1,168 @@
+package detector
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// PIDUsage cgroup pids控制器的使用情况
+type PIDUsage struct {
+	// 限制所在的cgroup路径，容器及其所有上级cgroup都没有限制时为容器自身的cgroup
+	CgroupPath string
+	Current    int64
+	// pids.max，为0时表示不限制
+	Max int64
+}
+
+// Limited 是否设置了PID数量上限
+func (u PIDUsage) Limited() bool {
+	return u.Max > 0
+}
+
+// Ratio 已用比例，不限制时为0
+func (u PIDUsage) Ratio() float64 {
+	if !u.Limited() {
+		return 0
+	}
+	return min(float64(u.Current)/float64(u.Max), 1)
+}
+
+// Remaining 剩余可用的PID数量，不限制时返回-1
+func (u PIDUsage) Remaining() int64 {
+	if !u.Limited() {
+		return -1
+	}
+	return max(u.Max-u.Current, 0)
+}
+
+// readPIDUsage 读取容器cgroup及其上级cgroup的pids.current和pids.max，返回使用比例最高的一级。
+// Kubernetes的Pod级PID限制（podPidsLimit）设置在Pod的cgroup上，容器自身的pids.max通常为max。
+// 同时兼容cgroup v2（root下直接是统一层级）和v1（pids控制器挂载在root/pids下）
+func readPIDUsage(root, cgroupPath string) (PIDUsage, error) {
+	if strings.HasPrefix(cgroupPath, "/..") {
+		// 清理器位于独立的cgroup命名空间中，路径相对于命名空间的根，无法映射到root
+		return PIDUsage{}, fmt.Errorf("cgroup路径%s不在当前cgroup命名空间内", cgroupPath)
+	}
+	base := filepath.Join(root, "pids")
+	if _, err := os.Stat(filepath.Join(root, "cgroup.controllers")); err == nil {
+		base = root
+	}
+
+	start := path.Clean("/" + cgroupPath)
+	var best PIDUsage
+	for p := start; ; p = path.Dir(p) {
+		usage, err := readCgroupPIDs(filepath.Join(base, p))
+		switch {
+		case err == nil:
+			usage.CgroupPath = p
+			if p == start || (usage.Limited() && (!best.Limited() || usage.Ratio() > best.Ratio())) {
+				best = usage
+			}
+		case p == start:
+			return PIDUsage{}, err
+		}
+		// 根cgroup没有pids.current，上级cgroup读取失败时忽略
+		if p == "/" {
+			return best, nil
+		}
+	}
+}
+
+// readCgroupPIDs 读取单个cgroup目录下的pids.current和pids.max
+func readCgroupPIDs(dir string) (PIDUsage, error) {
+	current, err := readPIDsFile(filepath.Join(dir, "pids.current"))
+	if err != nil {
+		return PIDUsage{}, err
+	}
+	limit, err := readPIDsFile(filepath.Join(dir, "pids.max"))
+	if err != nil {
+		return PIDUsage{}, err
+	}
+	return PIDUsage{Current: current, Max: limit}, nil
+}
+
+// readPIDsFile 读取pids.current或pids.max，"max"返回0
+func readPIDsFile(name string) (int64, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return 0, err
+	}
+	value := strings.TrimSpace(string(data))
+	if value == "max" {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("无法解析 %s: %w", name, err)
+	}
+	return n, nil
+}
+
+// NodePIDs 节点的PID和线程数量上限
+type NodePIDs struct {
+	// 当前的进程和线程总数，PID和线程ID共用同一编号空间
+	Threads int64
+	// kernel.pid_max
+	PIDMax int64
+	// kernel.threads-max
+	ThreadsMax int64
+}
+
+// Ratio 已用比例，取pid_max和threads-max中更接近上限的一项
+func (n NodePIDs) Ratio() float64 {
+	var ratio float64
+	for _, limit := range []int64{n.PIDMax, n.ThreadsMax} {
+		if limit > 0 {
+			ratio = max(ratio, float64(n.Threads)/float64(limit))
+		}
+	}
+	return min(ratio, 1)
+}
+
+// Remaining 剩余可用的PID数量
+func (n NodePIDs) Remaining() int64 {
+	remaining := int64(-1)
+	for _, limit := range []int64{n.PIDMax, n.ThreadsMax} {
+		if limit > 0 && (remaining < 0 || limit-n.Threads < remaining) {
+			remaining = max(limit-n.Threads, 0)
+		}
+	}
+	return remaining
+}
+
+// readNodePIDs 读取kernel.pid_max、kernel.threads-max，当前线程总数取自/proc/loadavg的第4列
+func readNodePIDs(procRoot string) (NodePIDs, error) {
+	var (
+		node NodePIDs
+		err  error
+	)
+	if node.PIDMax, err = readPIDsFile(filepath.Join(procRoot, "sys/kernel/pid_max")); err != nil {
+		return NodePIDs{}, err
+	}
+	if node.ThreadsMax, err = readPIDsFile(filepath.Join(procRoot, "sys/kernel/threads-max")); err != nil {
+		return NodePIDs{}, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(procRoot, "loadavg"))
+	if err != nil {
+		return NodePIDs{}, err
+	}
+	// 格式: 0.00 0.01 0.05 1/523 12345
+	fields := strings.Fields(string(data))
+	if len(fields) < 4 {
+		return NodePIDs{}, errors.New("无法解析 /proc/loadavg")
+	}
+	_, total, ok := strings.Cut(fields[3], "/")
+	if !ok {
+		return NodePIDs{}, errors.New("无法解析 /proc/loadavg")
+	}
+	if node.Threads, err = strconv.ParseInt(total, 10, 64); err != nil {
+		return NodePIDs{}, fmt.Errorf("无法解析 /proc/loadavg: %w", err)
+	}
+	return node, nil
+}
@@ -0,0 +1,122 @@
+package detector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeFiles 在root下写入文件，files的键为相对路径
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestReadPIDUsage(t *testing.T) {
+	const (
+		v2Pod       = "/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod1234.slice"
+		v2Container = v2Pod + "/cri-containerd-abcdef.scope"
+		v1Container = "/kubepods/burstable/pod1234/abcdef"
+	)
+
+	tests := []struct {
+		name    string
+		files   map[string]string
+		cgroup  string
+		want    PIDUsage
+		wantErr bool
+	}{
+		{
+			name: "cgroup v2使用Pod级限制",
+			files: map[string]string{
+				"cgroup.controllers":                                   "cpu memory pids\n",
+				"kubepods.slice/pids.current":                          "900\n",
+				"kubepods.slice/pids.max":                              "max\n",
+				v2Pod[1:] + "/pids.current":                            "950\n",
+				v2Pod[1:] + "/pids.max":                                "1024\n",
+				v2Container[1:] + "/pids.current":                      "940\n",
+				v2Container[1:] + "/pids.max":                          "max\n",
+				"kubepods.slice/kubepods-burstable.slice/pids.current": "950\n",
+				"kubepods.slice/kubepods-burstable.slice/pids.max":     "max\n",
+			},
+			cgroup: v2Container,
+			want:   PIDUsage{CgroupPath: v2Pod, Current: 950, Max: 1024},
+		},
+		{
+			name: "cgroup v1的pids层级，容器自身更接近上限",
+			files: map[string]string{
+				"pids/kubepods/burstable/pod1234/pids.current": "100\n",
+				"pids/kubepods/burstable/pod1234/pids.max":     "4096\n",
+				"pids" + v1Container + "/pids.current":         "98\n",
+				"pids" + v1Container + "/pids.max":             "100\n",
+			},
+			cgroup: v1Container,
+			want:   PIDUsage{CgroupPath: v1Container, Current: 98, Max: 100},
+		},
+		{
+			name: "没有任何限制",
+			files: map[string]string{
+				"cgroup.controllers":              "pids\n",
+				v2Container[1:] + "/pids.current": "3\n",
+				v2Container[1:] + "/pids.max":     "max\n",
+			},
+			cgroup: v2Container,
+			want:   PIDUsage{CgroupPath: v2Container, Current: 3},
+		},
+		{
+			name:    "容器cgroup不存在",
+			files:   map[string]string{"cgroup.controllers": "pids\n"},
+			cgroup:  v2Container,
+			wantErr: true,
+		},
+		{
+			name:    "路径在cgroup命名空间之外",
+			files:   map[string]string{"cgroup.controllers": "pids\n"},
+			cgroup:  "/../../kubepods/pod1234/abcdef",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeFiles(t, root, tt.files)
+			got, err := readPIDUsage(root, tt.cgroup)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readPIDUsage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("readPIDUsage() = %+v, 期望 %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadNodePIDs(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"sys/kernel/pid_max":     "32768\n",
+		"sys/kernel/threads-max": "8192\n",
+		"loadavg":                "0.52 0.58 0.59 3/6144 123456\n",
+	})
+
+	node, err := readNodePIDs(root)
+	if err != nil {
+		t.Fatalf("readNodePIDs返回错误: %v", err)
+	}
+	want := NodePIDs{Threads: 6144, PIDMax: 32768, ThreadsMax: 8192}
+	if node != want {
+		t.Errorf("readNodePIDs() = %+v, 期望 %+v", node, want)
+	}
+	if node.Ratio() != 0.75 || node.Remaining() != 2048 {
+		t.Errorf("Ratio() = %v, Remaining() = %d, 期望按threads-max计算为0.75和2048", node.Ratio(), node.Remaining())
+	}
+}
@@ -0,0 +1,80 @@
+package detector
+
+import "github.com/tiggoins/zombie-cleaner/internal/process"
+
+// procSnapshot 单次扫描得到的进程快照，每个检测周期重新构建，不跨周期复用
+type procSnapshot struct {
+	// 父进程到子进程的索引
+	children map[int][]int
+	// 进程启动时间，用于识别遍历期间被复用的PID
+	startTimes map[int]uint64
+}
+
+func newProcSnapshot() *procSnapshot {
+	return &procSnapshot{
+		children:   make(map[int][]int),
+		startTimes: make(map[int]uint64),
+	}
+}
+
+// add 记录扫描到的进程
+func (s *procSnapshot) add(stat process.Stat) {
+	s.children[stat.PPID] = append(s.children[stat.PPID], stat.PID)
+	s.startTimes[stat.PID] = stat.StartTime
+}
+
+// startTime 返回进程的启动时间，进程不在快照中时返回0
+func (s *procSnapshot) startTime(pid int) uint64 {
+	return s.startTimes[pid]
+}
+
+// isChild 判断child是否仍是parent的子进程。/proc的遍历不是原子的，
+// 父进程在遍历期间退出且PID被复用时，子进程的启动时间会早于新进程，这条父子关系已失效
+func (s *procSnapshot) isChild(parent, child int) bool {
+	parentStart, ok := s.startTimes[parent]
+	if !ok {
+		return true
+	}
+	return s.startTimes[child] >= parentStart
+}
+
+// containerTrees 一次遍历为所有容器填充PIDSet（容器init进程及其所有后代）。
+// 遍历到其他容器的init进程时停止，嵌套容器的进程只归属于最内层的容器；
+// init进程相同的容器共享同一个PIDSet
+func (s *procSnapshot) containerTrees(containers []ContainerMeta) {
+	roots := make(map[int]map[int]bool, len(containers))
+	for i := range containers {
+		root := containers[i].PID
+		tree, ok := roots[root]
+		if !ok {
+			tree = make(map[int]bool)
+			roots[root] = tree
+		}
+		containers[i].PIDSet = tree
+	}
+
+	// 每个进程最多访问一次，使用显式栈避免很深的进程树导致递归过深
+	visited := make(map[int]bool)
+	var stack []int
+	for root, tree := range roots {
+		if root <= 0 {
+			continue
+		}
+		stack = append(stack[:0], root)
+		for len(stack) > 0 {
+			pid := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if visited[pid] {
+				continue
+			}
+			visited[pid] = true
+			tree[pid] = true
+			for _, child := range s.children[pid] {
+				if _, nested := roots[child]; nested || !s.isChild(pid, child) {
+					continue
+				}
+				stack = append(stack, child)
+			}
+		}
+	}
+}
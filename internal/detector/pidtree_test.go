@@ -0,0 +1,158 @@
+package detector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/tiggoins/zombie-cleaner/internal/config"
+	"github.com/tiggoins/zombie-cleaner/internal/logger"
+	"github.com/tiggoins/zombie-cleaner/internal/process"
+	"github.com/tiggoins/zombie-cleaner/internal/runtime"
+)
+
+// snapshotOf 由(pid, ppid, starttime)构建进程快照
+func snapshotOf(procs ...[3]int) *procSnapshot {
+	s := newProcSnapshot()
+	for _, p := range procs {
+		s.add(process.Stat{PID: p[0], PPID: p[1], StartTime: uint64(p[2])})
+	}
+	return s
+}
+
+func treePIDs(tree map[int]bool) []int {
+	var pids []int
+	for pid := range tree {
+		pids = append(pids, pid)
+	}
+	slices.Sort(pids)
+	return pids
+}
+
+func TestContainerTrees(t *testing.T) {
+	snapshot := snapshotOf(
+		[3]int{1, 0, 1},
+		// shim和容器init
+		[3]int{50, 1, 10},
+		[3]int{100, 50, 20},
+		[3]int{101, 100, 21},
+		[3]int{102, 101, 22},
+		// 容器内启动的嵌套容器
+		[3]int{200, 101, 30},
+		[3]int{201, 200, 31},
+		// 父进程PID在遍历期间被复用，启动时间早于新的父进程
+		[3]int{300, 50, 40},
+		[3]int{301, 300, 5},
+	)
+	containers := []ContainerMeta{
+		{ID: "app", PID: 100},
+		{ID: "nested", PID: 200},
+		// 与app共享init进程
+		{ID: "app-alias", PID: 100},
+		{ID: "reused", PID: 300},
+		// 未运行的容器
+		{ID: "stopped", PID: 0},
+	}
+	snapshot.containerTrees(containers)
+
+	want := map[string][]int{
+		"app":       {100, 101, 102},
+		"nested":    {200, 201},
+		"app-alias": {100, 101, 102},
+		"reused":    {300},
+		"stopped":   nil,
+	}
+	for _, container := range containers {
+		if got := treePIDs(container.PIDSet); !slices.Equal(got, want[container.ID]) {
+			t.Errorf("容器%s的PID树 = %v, 期望 %v", container.ID, got, want[container.ID])
+		}
+	}
+}
+
+// listRuntime 只实现ListContainers的运行时
+type listRuntime struct {
+	runtime.ContainerRuntimeInterface
+	containers []ContainerMeta
+}
+
+func (r *listRuntime) ListContainers(context.Context) ([]ContainerMeta, error) {
+	// 每次返回新的副本，与真实运行时一致
+	containers := make([]ContainerMeta, len(r.containers))
+	copy(containers, r.containers)
+	return containers, nil
+}
+
+func TestDetectZombiesPicksUpNewDescendants(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"1/stat":   statLine(1, "systemd", "S", 0, 1),
+		"50/stat":  statLine(50, "containerd-shim", "S", 1, 10),
+		"100/stat": statLine(100, "app", "S", 50, 20),
+		"101/stat": statLine(101, "worker", "S", 100, 21),
+	})
+	d := &Detector{
+		logger:           logger.New("error", "text"),
+		ContainerRuntime: &listRuntime{containers: []ContainerMeta{{ID: "app", PID: 100, CgroupPath: "/kubepods/app"}}},
+		attributionMode:  config.AttributionPIDTree,
+		procRoot:         root,
+		zombieAges:       newZombieAgeTracker(),
+		risk:             newRiskScorer(config.RiskConfig{}),
+		trends:           newTrendTracker(config.TrendConfig{}),
+		stuck:            newStuckTracker(config.StuckProcessConfig{}),
+	}
+	d.timeoutContainers.m = make(map[string]time.Time)
+
+	detect := func() map[int]ZombieInfo {
+		t.Helper()
+		zombies, err := d.DetectZombies(context.Background())
+		if err != nil {
+			t.Fatalf("DetectZombies()返回错误: %v", err)
+		}
+		found := make(map[int]ZombieInfo)
+		for _, zombie := range zombies {
+			found[zombie.PID] = zombie
+		}
+		return found
+	}
+
+	// 第一个周期只有容器init的直接子进程退出
+	writeFiles(t, root, map[string]string{"101/stat": statLine(101, "worker", "Z", 100, 21)})
+	if zombie := detect()[101]; !zombie.IsInContainer || zombie.Container.ID != "app" {
+		t.Fatalf("第一个周期的僵尸进程 = %+v, 期望属于app", zombie)
+	}
+
+	// 第二个周期容器内出现了新的孙子进程，其父进程也是新进程
+	writeFiles(t, root, map[string]string{
+		"101/stat": statLine(101, "worker", "S", 100, 21),
+		"102/stat": statLine(102, "sh", "S", 101, 30),
+		"103/stat": statLine(103, "curl", "Z", 102, 31),
+	})
+	zombie := detect()[103]
+	if !zombie.IsInContainer || zombie.Container.ID != "app" {
+		t.Fatalf("第二个周期的僵尸进程 = %+v, 期望属于app", zombie)
+	}
+	if got, want := treePIDs(zombie.Container.PIDSet), []int{100, 101, 102, 103}; !slices.Equal(got, want) {
+		t.Errorf("第二个周期的PID树 = %v, 期望 %v", got, want)
+	}
+	if zombie.Container.InitStartTime != 20 {
+		t.Errorf("容器init进程启动时间 = %d, 期望 20", zombie.Container.InitStartTime)
+	}
+	if zombie.ParentStartTime != 30 {
+		t.Errorf("父进程启动时间 = %d, 期望 30", zombie.ParentStartTime)
+	}
+
+	// 第三个周期102退出，PID被宿主机上的新进程复用
+	if err := os.RemoveAll(filepath.Join(root, "103")); err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, root, map[string]string{
+		"102/stat": statLine(102, "cron", "S", 1, 40),
+		"104/stat": statLine(104, "backup", "Z", 102, 41),
+	})
+	if zombie := detect()[104]; zombie.IsInContainer {
+		t.Errorf("第三个周期宿主机僵尸进程被归属到容器%s", zombie.Container.ID)
+	}
+}
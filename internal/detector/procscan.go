@@ -0,0 +1,181 @@
+package detector
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/tiggoins/zombie-cleaner/internal/process"
+)
+
+// 每次从目录中读取的条目数
+const procDirBatch = 512
+
+// procScanner 单次遍历/proc的进程扫描器，只解析stat中检测需要的字段（pid、ppid、state、starttime、comm）。
+// 多次读取之间复用路径和读缓冲区，相同的comm只保存一份；遍历期间退出的进程直接跳过。
+// 回调中可以继续调用同一扫描器的threads，但扫描器不能并发使用
+type procScanner struct {
+	root  string
+	path  []byte
+	buf   []byte
+	comms map[string]string
+}
+
+func newProcScanner(root string) *procScanner {
+	return &procScanner{
+		root:  root,
+		buf:   make([]byte, 1024),
+		comms: make(map[string]string),
+	}
+}
+
+// scan 遍历root下的所有进程（不包括线程），对每个进程调用fn
+func (s *procScanner) scan(ctx context.Context, fn func(process.Stat)) error {
+	return s.scanDir(ctx, s.root, fn)
+}
+
+// threads 遍历进程的所有线程（root/<pid>/task），Stat.PID为线程ID
+func (s *procScanner) threads(ctx context.Context, pid int, fn func(process.Stat)) error {
+	return s.scanDir(ctx, filepath.Join(s.root, strconv.Itoa(pid), "task"), fn)
+}
+
+func (s *procScanner) scanDir(ctx context.Context, dir string, fn func(process.Stat)) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		names, err := d.Readdirnames(procDirBatch)
+		for _, name := range names {
+			if name == "" || name[0] < '0' || name[0] > '9' {
+				continue
+			}
+			data, ok := s.readStat(dir, name)
+			if !ok {
+				continue
+			}
+			if stat, ok := s.parseStat(data); ok {
+				fn(stat)
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// readStat 读取dir/<name>/stat到复用的缓冲区，缓冲区不足时扩容后重新读取
+func (s *procScanner) readStat(dir, name string) ([]byte, bool) {
+	s.path = append(s.path[:0], dir...)
+	s.path = append(s.path, '/')
+	s.path = append(s.path, name...)
+	s.path = append(s.path, "/stat"...)
+
+	for {
+		f, err := os.Open(string(s.path))
+		if err != nil {
+			return nil, false
+		}
+		n, err := io.ReadFull(f, s.buf)
+		f.Close()
+		switch {
+		case errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, io.EOF):
+			return s.buf[:n], n > 0
+		case err != nil:
+			return nil, false
+		}
+		s.buf = make([]byte, 2*len(s.buf))
+	}
+}
+
+// parseStat 从stat内容中解析用到的字段，comm可能包含空格和括号，以第一个'('和最后一个')'为界
+func (s *procScanner) parseStat(data []byte) (process.Stat, bool) {
+	lparen, rparen := bytes.IndexByte(data, '('), bytes.LastIndexByte(data, ')')
+	if lparen < 0 || rparen < lparen {
+		return process.Stat{}, false
+	}
+	pid, ok := parseDecimal(bytes.TrimSpace(data[:lparen]))
+	if !ok {
+		return process.Stat{}, false
+	}
+	stat := process.Stat{PID: int(pid), Comm: s.intern(data[lparen+1 : rparen])}
+
+	// 从state（第3个字段）开始，ppid为第4个字段，starttime为第22个字段
+	rest := data[rparen+1:]
+	for field := 3; field <= 22; field++ {
+		rest = bytes.TrimLeft(rest, " ")
+		end := bytes.IndexByte(rest, ' ')
+		if end < 0 {
+			end = len(rest)
+		}
+		token := rest[:end]
+		rest = rest[end:]
+		if len(token) == 0 {
+			return process.Stat{}, false
+		}
+		switch field {
+		case 3:
+			stat.State = string(token)
+		case 4:
+			ppid, ok := parseDecimal(token)
+			if !ok {
+				return process.Stat{}, false
+			}
+			stat.PPID = int(ppid)
+		case 22:
+			startTime, ok := parseDecimal(bytes.TrimRight(token, "\n"))
+			if !ok {
+				return process.Stat{}, false
+			}
+			stat.StartTime = startTime
+		}
+	}
+	return stat, true
+}
+
+// intern 返回comm对应的字符串，相同的comm共享同一份内存
+func (s *procScanner) intern(comm []byte) string {
+	if v, ok := s.comms[string(comm)]; ok {
+		return v
+	}
+	v := string(comm)
+	s.comms[v] = v
+	return v
+}
+
+// parseDecimal 解析非负十进制整数
+func parseDecimal(b []byte) (uint64, bool) {
+	if len(b) == 0 {
+		return 0, false
+	}
+	var n uint64
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+		n = n*10 + uint64(c-'0')
+	}
+	return n, true
+}
+
+// readCmdline 读取进程的命令行参数，参数之间以空格分隔；僵尸进程的cmdline为空
+func readCmdline(procRoot string, pid int) (string, error) {
+	data, err := os.ReadFile(filepath.Join(procRoot, strconv.Itoa(pid), "cmdline"))
+	if err != nil {
+		return "", err
+	}
+	return strings.ReplaceAll(strings.TrimRight(string(data), "\x00"), "\x00", " "), nil
+}
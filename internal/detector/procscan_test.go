@@ -0,0 +1,247 @@
+package detector
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/procfs"
+	"github.com/tiggoins/zombie-cleaner/internal/process"
+)
+
+// statLine 生成与内核格式一致的stat内容（52个字段）
+func statLine(pid int, comm, state string, ppid int, startTime uint64) string {
+	fields := []string{strconv.Itoa(pid), "(" + comm + ")", state, strconv.Itoa(ppid)}
+	for i := 5; i <= 52; i++ {
+		switch i {
+		case 22:
+			fields = append(fields, strconv.FormatUint(startTime, 10))
+		default:
+			fields = append(fields, strconv.Itoa(i))
+		}
+	}
+	return strings.Join(fields, " ") + "\n"
+}
+
+// writeProcFixture 生成包含n个进程的合成/proc，每100个进程中有一个僵尸进程
+func writeProcFixture(tb testing.TB, n int) string {
+	tb.Helper()
+	root := tb.TempDir()
+	comms := []string{"java", "nginx: worker", "sh", "(sd-pam)", "kworker/0:1"}
+	for pid := 1; pid <= n; pid++ {
+		state, cmdline := "S", comms[pid%len(comms)]+"\x00--flag\x00"
+		if pid%100 == 0 {
+			state, cmdline = "Z", ""
+		}
+		dir := filepath.Join(root, strconv.Itoa(pid))
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			tb.Fatal(err)
+		}
+		stat := statLine(pid, comms[pid%len(comms)], state, max(pid/10, 1), uint64(1000+pid))
+		if err := os.WriteFile(filepath.Join(dir, "stat"), []byte(stat), 0o644); err != nil {
+			tb.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "cmdline"), []byte(cmdline), 0o644); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	// /proc下的非进程条目
+	if err := os.WriteFile(filepath.Join(root, "loadavg"), []byte("0.00 0.00 0.00 1/100 100\n"), 0o644); err != nil {
+		tb.Fatal(err)
+	}
+	return root
+}
+
+func TestProcScannerParseStat(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		want   process.Stat
+		wantOK bool
+	}{
+		{
+			name:   "普通进程",
+			data:   statLine(42, "nginx", "S", 1, 12345),
+			want:   process.Stat{PID: 42, PPID: 1, Comm: "nginx", State: "S", StartTime: 12345},
+			wantOK: true,
+		},
+		{
+			name:   "comm包含空格和括号",
+			data:   statLine(7, "a) (b c", "Z", 3, 99),
+			want:   process.Stat{PID: 7, PPID: 3, Comm: "a) (b c", State: "Z", StartTime: 99},
+			wantOK: true,
+		},
+		{
+			name:   "starttime是最后一个字段",
+			data:   "9 (sh) D 2 1 1 0 -1 0 0 0 0 0 0 0 0 0 20 0 1 0 777\n",
+			want:   process.Stat{PID: 9, PPID: 2, Comm: "sh", State: "D", StartTime: 777},
+			wantOK: true,
+		},
+		{name: "字段不足", data: "9 (sh) S 2 1 1\n"},
+		{name: "缺少括号", data: "9 sh S 2 1 1 0 -1 0 0 0 0 0 0 0 0 0 20 0 1 0 777\n"},
+		{name: "ppid无效", data: "9 (sh) S x 1 1 0 -1 0 0 0 0 0 0 0 0 0 20 0 1 0 777\n"},
+		{name: "空内容"},
+	}
+
+	s := newProcScanner("")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := s.parseStat([]byte(tt.data))
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("parseStat() = %+v, %v, 期望 %+v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestProcScannerScan(t *testing.T) {
+	root := writeProcFixture(t, 250)
+	// stat不完整的进程被跳过
+	writeFiles(t, root, map[string]string{
+		"300/stat":        "300 (broken",
+		"301/cmdline":     "",
+		"1/task/1/stat":   statLine(1, "init", "S", 0, 1001),
+		"1/task/5/stat":   statLine(5, "worker", "D", 0, 1002),
+		"1/task/x/stat":   "ignored",
+		"self/stat":       statLine(1, "init", "S", 0, 1001),
+		"thread-self/foo": "",
+	})
+
+	s := newProcScanner(root)
+	var pids, zombies []int
+	if err := s.scan(context.Background(), func(stat process.Stat) {
+		pids = append(pids, stat.PID)
+		if stat.State == "Z" {
+			zombies = append(zombies, stat.PID)
+		}
+	}); err != nil {
+		t.Fatalf("scan()返回错误: %v", err)
+	}
+	slices.Sort(pids)
+	if len(pids) != 250 || pids[0] != 1 || pids[249] != 250 {
+		t.Errorf("扫描到%d个进程（%v...），期望1-250", len(pids), pids[:min(len(pids), 3)])
+	}
+	slices.Sort(zombies)
+	if want := []int{100, 200}; !slices.Equal(zombies, want) {
+		t.Errorf("僵尸进程 = %v, 期望 %v", zombies, want)
+	}
+
+	var threads []process.Stat
+	if err := s.threads(context.Background(), 1, func(stat process.Stat) {
+		threads = append(threads, stat)
+	}); err != nil {
+		t.Fatalf("threads()返回错误: %v", err)
+	}
+	slices.SortFunc(threads, func(a, b process.Stat) int { return a.PID - b.PID })
+	if len(threads) != 2 || threads[1].PID != 5 || threads[1].State != "D" {
+		t.Errorf("线程 = %+v, 期望TID 1和处于D状态的TID 5", threads)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := s.scan(ctx, func(process.Stat) {}); err != context.Canceled {
+		t.Errorf("取消后scan() = %v, 期望context.Canceled", err)
+	}
+	if err := newProcScanner(filepath.Join(root, "missing")).scan(context.Background(), func(process.Stat) {}); err == nil {
+		t.Error("proc目录不存在时scan()应返回错误")
+	}
+}
+
+func TestReadCmdline(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"10/cmdline": "java\x00-jar\x00app.jar\x00",
+		"11/cmdline": "",
+	})
+	tests := []struct {
+		pid     int
+		want    string
+		wantErr bool
+	}{
+		{pid: 10, want: "java -jar app.jar"},
+		// 僵尸进程
+		{pid: 11, want: ""},
+		{pid: 12, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := readCmdline(root, tt.pid)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("readCmdline(%d) = %q, %v, 期望 %q", tt.pid, got, err, tt.want)
+		}
+	}
+}
+
+// 对比的两种扫描方式使用的合成进程数
+var benchmarkProcCounts = []int{1000, 10000}
+
+func BenchmarkProcScanner(b *testing.B) {
+	for _, n := range benchmarkProcCounts {
+		root := writeProcFixture(b, n)
+		b.Run(fmt.Sprintf("procs=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				parentMap := make(map[int][]int)
+				zombies := make(map[int]process.Stat)
+				err := newProcScanner(root).scan(context.Background(), func(stat process.Stat) {
+					parentMap[stat.PPID] = append(parentMap[stat.PPID], stat.PID)
+					if stat.State == "Z" {
+						zombies[stat.PID] = stat
+					}
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+				for pid := range zombies {
+					if _, err := readCmdline(root, pid); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkProcfsScan 原先基于procfs的扫描方式：AllProcs后逐个解析stat，僵尸进程再读取一次stat和cmdline
+func BenchmarkProcfsScan(b *testing.B) {
+	for _, n := range benchmarkProcCounts {
+		root := writeProcFixture(b, n)
+		b.Run(fmt.Sprintf("procs=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				fs, err := procfs.NewFS(root)
+				if err != nil {
+					b.Fatal(err)
+				}
+				procs, err := fs.AllProcs()
+				if err != nil {
+					b.Fatal(err)
+				}
+				parentMap := make(map[int][]int)
+				zombies := make(map[int]procfs.Proc)
+				for _, proc := range procs {
+					stat, err := proc.Stat()
+					if err != nil {
+						continue
+					}
+					parentMap[stat.PPID] = append(parentMap[stat.PPID], stat.PID)
+					if stat.State == "Z" {
+						zombies[stat.PID] = proc
+					}
+				}
+				for _, proc := range zombies {
+					if _, err := proc.Stat(); err != nil {
+						b.Fatal(err)
+					}
+					if _, err := proc.CmdLine(); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+	}
+}
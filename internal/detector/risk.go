@@ -0,0 +1,167 @@
+package detector
+
+import (
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/tiggoins/zombie-cleaner/internal/config"
+	"github.com/tiggoins/zombie-cleaner/internal/metrics"
+)
+
+// Risk 容器的PID耗尽风险
+type Risk struct {
+	// Score 风险评分，0-100
+	Score float64
+	// Zombies 容器内的僵尸进程数量
+	Zombies int
+	// GrowthRate 滑动窗口内僵尸进程数量每分钟的增长量，首次发现时为0
+	GrowthRate float64
+	// Trend 滑动窗口内僵尸进程数量的变化趋势
+	Trend Trend
+	// PIDs 容器cgroup及其上级cgroup中最接近上限的一级，无法读取时为零值
+	PIDs PIDUsage
+	// Node 节点的PID使用情况，无法读取时为零值
+	Node NodePIDs
+	// TimeToExhaustion 按当前增长速度预计耗尽PID的时间，不增长或无法估算时为0
+	TimeToExhaustion time.Duration
+}
+
+// LogValue 在日志中展开为一组字段，nil时省略
+func (r *Risk) LogValue() slog.Value {
+	if r == nil {
+		return slog.GroupValue()
+	}
+	attrs := []slog.Attr{
+		slog.Float64("score", math.Round(r.Score*10)/10),
+		slog.Int("zombies", r.Zombies),
+		slog.Float64("growth_per_minute", math.Round(r.GrowthRate*100)/100),
+		slog.String("trend", string(r.Trend)),
+	}
+	if r.PIDs.Limited() {
+		attrs = append(attrs,
+			slog.String("pids_cgroup", r.PIDs.CgroupPath),
+			slog.Int64("pids_current", r.PIDs.Current),
+			slog.Int64("pids_max", r.PIDs.Max))
+	}
+	if r.TimeToExhaustion > 0 {
+		attrs = append(attrs, slog.Duration("time_to_exhaustion", r.TimeToExhaustion.Round(time.Second)))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// riskScorer 计算容器的PID耗尽风险
+type riskScorer struct {
+	mu  sync.Mutex
+	cfg config.RiskConfig
+}
+
+func newRiskScorer(cfg config.RiskConfig) *riskScorer {
+	return &riskScorer{cfg: cfg}
+}
+
+// setConfig 热加载时更新评分参数
+func (s *riskScorer) setConfig(cfg config.RiskConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cfg = cfg
+}
+
+func (s *riskScorer) config() config.RiskConfig {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cfg
+}
+
+// score 综合三项计算风险评分：PID使用比例、按增长速度预计耗尽PID的紧迫程度和僵尸进程数量，
+// 每项取值0-1，评分为100*(1-(1-使用比例)*(1-紧迫程度)*(1-僵尸进程数量))，任意一项接近1时评分都接近100
+func (s *riskScorer) score(risk *Risk) {
+	cfg := s.config()
+
+	pressure := max(risk.PIDs.Ratio(), risk.Node.Ratio())
+
+	var urgency float64
+	remaining := risk.Node.Remaining()
+	if r := risk.PIDs.Remaining(); r >= 0 && (remaining < 0 || r < remaining) {
+		remaining = r
+	}
+	if risk.GrowthRate > 0 && remaining >= 0 {
+		risk.TimeToExhaustion = time.Duration(float64(remaining) / risk.GrowthRate * float64(time.Minute))
+		urgency = min(max(1-risk.TimeToExhaustion.Seconds()/cfg.ExhaustionHorizon.Seconds(), 0), 1)
+	}
+
+	zombies := min(float64(risk.Zombies)/float64(cfg.ZombieSaturation), 1)
+
+	risk.Score = 100 * (1 - (1-pressure)*(1-urgency)*(1-zombies))
+}
+
+// assessRisk 为每个容器计算僵尸进程数量趋势和PID耗尽风险，同一容器的僵尸进程共享同一个Risk
+func (d *Detector) assessRisk(zombies []ZombieInfo, now time.Time) {
+	nodeName := metrics.GetNodeName()
+	metrics.PIDRiskScore.Reset()
+	metrics.ContainerZombies.Reset()
+	metrics.ZombieGrowthRate.Reset()
+	metrics.ZombieTrend.Reset()
+
+	node, err := readNodePIDs(d.procRoot)
+	if err != nil {
+		d.logger.Debug("读取节点PID上限失败", "error", err)
+	} else {
+		metrics.NodePIDUsage.WithLabelValues(nodeName).Set(node.Ratio())
+	}
+
+	counts := make(map[string]int)
+	for _, zombie := range zombies {
+		if zombie.IsInContainer {
+			counts[zombie.Container.ID]++
+		}
+	}
+	growths := d.trends.observe(counts, now)
+
+	cgroupRoot := d.risk.config().CgroupRoot
+	risks := make(map[string]*Risk, len(counts))
+	for i := range zombies {
+		zombie := &zombies[i]
+		if !zombie.IsInContainer {
+			continue
+		}
+		container := zombie.Container
+		risk, ok := risks[container.ID]
+		if !ok {
+			g := growths[container.ID]
+			risk = &Risk{Zombies: counts[container.ID], GrowthRate: g.rate, Trend: g.trend, Node: node}
+			if container.CgroupPath == "" {
+				if cgroupPath, err := readCgroupPath(d.procRoot, container.PID); err == nil {
+					container.CgroupPath = cgroupPath
+				}
+			}
+			if usage, err := readPIDUsage(cgroupRoot, container.CgroupPath); err != nil {
+				d.logger.Debug("读取容器pids控制器失败", "container_id", container.ID, "cgroup", container.CgroupPath, "error", err)
+			} else {
+				risk.PIDs = usage
+			}
+			d.risk.score(risk)
+			risks[container.ID] = risk
+			metrics.PIDRiskScore.WithLabelValues(nodeName, container.PodNS, container.PodName).Set(risk.Score)
+			metrics.ContainerZombies.WithLabelValues(nodeName, container.PodNS, container.PodName).Set(float64(risk.Zombies))
+			metrics.ZombieGrowthRate.WithLabelValues(nodeName, container.PodNS, container.PodName).Set(risk.GrowthRate)
+			metrics.ZombieTrend.WithLabelValues(nodeName, container.PodNS, container.PodName, string(risk.Trend)).Set(1)
+			if risk.Trend != TrendStable {
+				d.logger.WithContainer(container.ID, container.PodName, container.PodNS).
+					Warn("容器僵尸进程数量异常增长", "trend", risk.Trend, "growth_per_minute", math.Round(risk.GrowthRate*100)/100, "zombies", risk.Zombies)
+			}
+		}
+		zombie.Risk = risk
+	}
+}
+
+// SetRiskConfig 热加载时更新风险评分参数
+func (d *Detector) SetRiskConfig(cfg config.RiskConfig) {
+	d.risk.setConfig(cfg)
+}
+
+// SetTrendConfig 热加载时更新趋势参数
+func (d *Detector) SetTrendConfig(cfg config.TrendConfig) {
+	d.trends.setConfig(cfg)
+}
@@ -0,0 +1,72 @@
+package detector
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/tiggoins/zombie-cleaner/internal/config"
+)
+
+func testRiskConfig() config.RiskConfig {
+	return config.RiskConfig{Threshold: 80, ZombieSaturation: 100, ExhaustionHorizon: time.Hour, CgroupRoot: "/sys/fs/cgroup"}
+}
+
+func TestRiskScore(t *testing.T) {
+	idleNode := NodePIDs{Threads: 1000, PIDMax: 4194304, ThreadsMax: 1000000}
+
+	tests := []struct {
+		name      string
+		risk      Risk
+		wantScore float64
+		wantTTE   time.Duration
+	}{
+		{
+			name:      "单个稳定的僵尸进程",
+			risk:      Risk{Zombies: 1, Node: idleNode},
+			wantScore: 1.1,
+		},
+		{
+			name:      "大量僵尸进程",
+			risk:      Risk{Zombies: 150, Node: idleNode},
+			wantScore: 100,
+		},
+		{
+			name:      "接近Pod的PID上限",
+			risk:      Risk{Zombies: 10, PIDs: PIDUsage{Current: 900, Max: 1000}, Node: idleNode},
+			wantScore: 91,
+		},
+		{
+			name: "按增长速度半小时内耗尽PID",
+			// 剩余600个PID，每分钟增长20个，30分钟后耗尽
+			risk:      Risk{Zombies: 10, GrowthRate: 20, PIDs: PIDUsage{Current: 400, Max: 1000}, Node: idleNode},
+			wantScore: 100 * (1 - 0.6*0.5*0.9),
+			wantTTE:   30 * time.Minute,
+		},
+		{
+			name:      "不限制PID时按节点余量估算",
+			risk:      Risk{GrowthRate: 10, Node: NodePIDs{Threads: 7900, PIDMax: 32768, ThreadsMax: 8000}},
+			wantScore: 100 * (1 - (1-7900.0/8000)*(1-(1-10.0/60))),
+			wantTTE:   10 * time.Minute,
+		},
+		{
+			name:      "僵尸进程减少",
+			risk:      Risk{Zombies: 1, GrowthRate: -5, Node: idleNode},
+			wantScore: 1.1,
+		},
+	}
+
+	s := newRiskScorer(testRiskConfig())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			risk := tt.risk
+			s.score(&risk)
+			if math.Abs(risk.Score-tt.wantScore) > 0.1 {
+				t.Errorf("Score = %.2f, 期望 %.2f", risk.Score, tt.wantScore)
+			}
+			if risk.TimeToExhaustion.Round(time.Second) != tt.wantTTE {
+				t.Errorf("TimeToExhaustion = %v, 期望 %v", risk.TimeToExhaustion, tt.wantTTE)
+			}
+		})
+	}
+}
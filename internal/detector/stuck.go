@@ -0,0 +1,225 @@
+package detector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tiggoins/zombie-cleaner/internal/config"
+	"github.com/tiggoins/zombie-cleaner/internal/metrics"
+	"github.com/tiggoins/zombie-cleaner/internal/process"
+)
+
+// 内核调用栈最多保留的帧数
+const maxStackFrames = 16
+
+// StuckProcess 处于不可中断睡眠（D状态）的进程或线程
+type StuckProcess struct {
+	// PID 所属进程的PID
+	PID int
+	// TID 线程ID，主线程时与PID相同
+	TID       int
+	StartTime uint64
+	Comm      string
+	// WChan 进程阻塞所在的内核函数，不可读时为空
+	WChan string
+	// Stack 内核调用栈（/proc/<pid>/task/<tid>/stack），需要CAP_SYS_ADMIN，不可读时为空
+	Stack []string
+	// CgroupPath 所属cgroup，inspect超时的容器不在容器列表中，按cgroup路径中的容器ID关联
+	CgroupPath string
+	// Container 所属容器，宿主机进程为nil
+	Container *ContainerMeta
+	// FirstSeen 首次发现处于D状态的时间
+	FirstSeen time.Time
+	// Duration 已持续处于D状态的时长（按检测周期估算）
+	Duration time.Duration
+}
+
+// InContainer 判断进程是否属于containerID对应的容器
+func (s StuckProcess) InContainer(containerID string) bool {
+	if s.Container != nil {
+		return s.Container.ID == containerID
+	}
+	return containerID != "" && strings.Contains(s.CgroupPath, containerID)
+}
+
+// stuckCandidate 本次扫描中处于D状态的线程
+type stuckCandidate struct {
+	pid int
+	process.Stat
+}
+
+// stuckThreads 返回进程中处于D状态的线程
+func stuckThreads(ctx context.Context, scanner *procScanner, pid int) []stuckCandidate {
+	var candidates []stuckCandidate
+	// 进程在遍历期间退出时忽略
+	_ = scanner.threads(ctx, pid, func(stat process.Stat) {
+		if stat.State == "D" {
+			candidates = append(candidates, stuckCandidate{pid: pid, Stat: stat})
+		}
+	})
+	return candidates
+}
+
+// stuckTracker 跟踪处于D状态的线程首次被发现的时间，保存上一次扫描的结果
+type stuckTracker struct {
+	mu        sync.Mutex
+	cfg       config.StuckProcessConfig
+	firstSeen map[process.Ref]time.Time
+	last      []StuckProcess
+}
+
+func newStuckTracker(cfg config.StuckProcessConfig) *stuckTracker {
+	return &stuckTracker{cfg: cfg, firstSeen: make(map[process.Ref]time.Time)}
+}
+
+// setConfig 热加载时更新检测参数
+func (t *stuckTracker) setConfig(cfg config.StuckProcessConfig) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cfg = cfg
+	if !cfg.Enabled {
+		t.firstSeen = make(map[process.Ref]time.Time)
+		t.last = nil
+	}
+}
+
+func (t *stuckTracker) config() config.StuckProcessConfig {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.cfg
+}
+
+// persistent 记录本次扫描中的D状态线程，返回持续时长达到min_age的线程，不再处于D状态的线程不再跟踪
+func (t *stuckTracker) persistent(candidates []stuckCandidate, now time.Time) []StuckProcess {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	current := make(map[process.Ref]bool, len(candidates))
+	var stuck []StuckProcess
+	for _, c := range candidates {
+		ref := process.Ref{PID: c.PID, StartTime: c.StartTime}
+		current[ref] = true
+		firstSeen, ok := t.firstSeen[ref]
+		if !ok {
+			firstSeen = now
+			t.firstSeen[ref] = now
+		}
+		if now.Sub(firstSeen) < t.cfg.MinAge {
+			continue
+		}
+		stuck = append(stuck, StuckProcess{
+			PID:       c.pid,
+			TID:       c.PID,
+			StartTime: c.StartTime,
+			Comm:      c.Comm,
+			FirstSeen: firstSeen,
+			Duration:  now.Sub(firstSeen),
+		})
+	}
+	for ref := range t.firstSeen {
+		if !current[ref] {
+			delete(t.firstSeen, ref)
+		}
+	}
+	return stuck
+}
+
+// setLast 保存本次扫描的结果
+func (t *stuckTracker) setLast(stuck []StuckProcess) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.last = stuck
+}
+
+// snapshot 返回上一次扫描发现的D状态进程
+func (t *stuckTracker) snapshot() []StuckProcess {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]StuckProcess(nil), t.last...)
+}
+
+// describeStuck 读取D状态线程的wchan、内核调用栈和cgroup，关联到容器，记录日志和指标
+func (d *Detector) describeStuck(stuck []StuckProcess, pidToContainer map[int]*ContainerMeta, cgroups cgroupIndex) {
+	nodeName := metrics.GetNodeName()
+	metrics.StuckProcesses.Reset()
+
+	for i := range stuck {
+		sp := &stuck[i]
+		taskDir := filepath.Join(d.procRoot, strconv.Itoa(sp.PID), "task", strconv.Itoa(sp.TID))
+		if data, err := os.ReadFile(filepath.Join(taskDir, "wchan")); err == nil && string(data) != "0" {
+			sp.WChan = strings.TrimSpace(string(data))
+		}
+		if data, err := os.ReadFile(filepath.Join(taskDir, "stack")); err == nil {
+			sp.Stack = parseKernelStack(data)
+		}
+		if cgroupPath, err := readCgroupPath(d.procRoot, sp.PID); err == nil {
+			sp.CgroupPath = cgroupPath
+		}
+
+		if cgroups != nil && sp.CgroupPath != "" {
+			if container, ok := cgroups.lookup(sp.CgroupPath); ok {
+				sp.Container = container
+			}
+		}
+		if sp.Container == nil {
+			sp.Container = pidToContainer[sp.PID]
+		}
+
+		namespace, podName := "", ""
+		log := d.logger
+		if sp.Container != nil {
+			namespace, podName = sp.Container.PodNS, sp.Container.PodName
+			log = log.WithContainer(sp.Container.ID, podName, namespace)
+		}
+		metrics.StuckProcesses.WithLabelValues(nodeName, namespace, podName, sp.WChan).Inc()
+		log.Warn("发现处于不可中断状态（D）的进程",
+			"pid", sp.PID,
+			"tid", sp.TID,
+			"comm", sp.Comm,
+			"wchan", sp.WChan,
+			"stuck_for", sp.Duration.Round(time.Second),
+			"cgroup", sp.CgroupPath,
+			"kernel_stack", sp.Stack)
+	}
+	d.stuck.setLast(stuck)
+}
+
+// parseKernelStack 解析/proc/<pid>/stack，去掉每帧开头的地址
+func parseKernelStack(data []byte) []string {
+	var frames []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		line = strings.TrimSpace(line)
+		if _, frame, ok := strings.Cut(line, "] "); ok {
+			line = frame
+		}
+		if line == "" {
+			continue
+		}
+		frames = append(frames, line)
+		if len(frames) == maxStackFrames {
+			break
+		}
+	}
+	return frames
+}
+
+// StuckProcesses 返回最近一次检测发现的D状态进程，未启用时为空
+func (d *Detector) StuckProcesses() []StuckProcess {
+	if d.stuck == nil {
+		return nil
+	}
+	return d.stuck.snapshot()
+}
+
+// SetStuckProcessConfig 热加载时更新D状态进程的检测参数
+func (d *Detector) SetStuckProcessConfig(cfg config.StuckProcessConfig) {
+	d.stuck.setConfig(cfg)
+	if !cfg.Enabled {
+		metrics.StuckProcesses.Reset()
+	}
+}
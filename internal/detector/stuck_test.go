@@ -0,0 +1,126 @@
+package detector
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/tiggoins/zombie-cleaner/internal/config"
+	"github.com/tiggoins/zombie-cleaner/internal/logger"
+	"github.com/tiggoins/zombie-cleaner/internal/process"
+)
+
+func candidate(pid, tid int, startTime uint64) stuckCandidate {
+	return stuckCandidate{pid: pid, Stat: process.Stat{PID: tid, State: "D", StartTime: startTime, Comm: "worker"}}
+}
+
+func TestStuckTrackerPersistent(t *testing.T) {
+	tracker := newStuckTracker(config.StuckProcessConfig{Enabled: true, MinAge: time.Minute})
+	start := time.Now()
+
+	// 首次发现时未达到min_age
+	if got := tracker.persistent([]stuckCandidate{candidate(100, 100, 10), candidate(100, 101, 11)}, start); len(got) != 0 {
+		t.Fatalf("首次发现的D状态进程 = %v, 期望为空", got)
+	}
+
+	// 线程101恢复后不再跟踪，PID 102是新进程
+	got := tracker.persistent([]stuckCandidate{candidate(100, 100, 10), candidate(102, 102, 12)}, start.Add(90*time.Second))
+	if len(got) != 1 || got[0].PID != 100 || got[0].TID != 100 || got[0].Duration != 90*time.Second {
+		t.Fatalf("持续处于D状态的进程 = %+v, 期望只有PID 100且持续90s", got)
+	}
+
+	// 线程101再次进入D状态时重新计时
+	got = tracker.persistent([]stuckCandidate{candidate(100, 101, 11), candidate(102, 102, 12)}, start.Add(3*time.Minute))
+	if len(got) != 1 || got[0].TID != 102 {
+		t.Fatalf("持续处于D状态的进程 = %+v, 期望只有TID 102", got)
+	}
+
+	// PID复用后视为新进程
+	if got := tracker.persistent([]stuckCandidate{candidate(102, 102, 99)}, start.Add(4*time.Minute)); len(got) != 0 {
+		t.Errorf("PID复用后的进程 = %v, 期望为空", got)
+	}
+}
+
+func TestParseKernelStack(t *testing.T) {
+	data := "[<0>] rpc_wait_bit_killable+0x1e/0xa0 [sunrpc]\n[<0>] __rpc_execute+0x107/0x400 [sunrpc]\n[<0>] nfs4_proc_getattr+0x60/0x100 [nfsv4]\n"
+	want := []string{
+		"rpc_wait_bit_killable+0x1e/0xa0 [sunrpc]",
+		"__rpc_execute+0x107/0x400 [sunrpc]",
+		"nfs4_proc_getattr+0x60/0x100 [nfsv4]",
+	}
+	if got := parseKernelStack([]byte(data)); !slices.Equal(got, want) {
+		t.Errorf("parseKernelStack() = %q, 期望 %q", got, want)
+	}
+	if got := parseKernelStack(nil); len(got) != 0 {
+		t.Errorf("空调用栈 = %q, 期望为空", got)
+	}
+
+	var long []byte
+	for range maxStackFrames + 5 {
+		long = append(long, "[<0>] schedule+0x1/0x2\n"...)
+	}
+	if got := parseKernelStack(long); len(got) != maxStackFrames {
+		t.Errorf("调用栈帧数 = %d, 期望 %d", len(got), maxStackFrames)
+	}
+}
+
+func TestStuckProcessInContainer(t *testing.T) {
+	container := &ContainerMeta{ID: "abc123"}
+	tests := []struct {
+		name        string
+		stuck       StuckProcess
+		containerID string
+		want        bool
+	}{
+		{name: "已关联容器", stuck: StuckProcess{Container: container}, containerID: "abc123", want: true},
+		{name: "其他容器", stuck: StuckProcess{Container: container}, containerID: "def456", want: false},
+		{name: "inspect超时容器按cgroup路径匹配", stuck: StuckProcess{CgroupPath: "/kubepods/pod1/cri-containerd-abc123.scope"}, containerID: "abc123", want: true},
+		{name: "宿主机进程", stuck: StuckProcess{CgroupPath: "/system.slice/nfs.service"}, containerID: "abc123", want: false},
+		{name: "空容器ID", stuck: StuckProcess{CgroupPath: "/kubepods/pod1"}, containerID: "", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.stuck.InContainer(tt.containerID); got != tt.want {
+				t.Errorf("InContainer(%q) = %v, 期望 %v", tt.containerID, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDescribeStuck(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		// 容器内阻塞在NFS上的线程
+		"200/cgroup":         "0::/kubepods/pod1/app/worker\n",
+		"200/task/201/wchan": "rpc_wait_bit_killable",
+		"200/task/201/stack": "[<0>] rpc_wait_bit_killable+0x1e/0xa0 [sunrpc]\n",
+		// 宿主机进程，没有读取调用栈的权限
+		"300/cgroup":         "0::/system.slice/backup.service\n",
+		"300/task/300/wchan": "0",
+	})
+	app := &ContainerMeta{ID: "app", PodName: "web", PodNS: "default"}
+	d := &Detector{
+		procRoot: root,
+		logger:   logger.New("error", "text"),
+		stuck:    newStuckTracker(config.StuckProcessConfig{Enabled: true}),
+	}
+
+	d.describeStuck([]StuckProcess{{PID: 200, TID: 201}, {PID: 300, TID: 300}}, nil, cgroupIndex{"/kubepods/pod1/app": app})
+
+	got := d.StuckProcesses()
+	if len(got) != 2 {
+		t.Fatalf("D状态进程数量 = %d, 期望 2", len(got))
+	}
+	if got[0].Container != app || got[0].WChan != "rpc_wait_bit_killable" || len(got[0].Stack) != 1 {
+		t.Errorf("容器内的D状态线程 = %+v", got[0])
+	}
+	if got[1].Container != nil || got[1].WChan != "" || got[1].Stack != nil || got[1].CgroupPath != "/system.slice/backup.service" {
+		t.Errorf("宿主机的D状态进程 = %+v", got[1])
+	}
+
+	// 禁用后清空上一次的结果
+	d.SetStuckProcessConfig(config.StuckProcessConfig{})
+	if got := d.StuckProcesses(); len(got) != 0 {
+		t.Errorf("禁用后的D状态进程 = %v, 期望为空", got)
+	}
+}
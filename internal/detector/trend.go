@@ -0,0 +1,127 @@
+package detector
+
+import (
+	"sync"
+	"time"
+
+	"github.com/tiggoins/zombie-cleaner/internal/config"
+)
+
+// Trend 容器僵尸进程数量的变化趋势
+type Trend string
+
+const (
+	// TrendStable 数量不变、减少，或采样不足以判断
+	TrendStable Trend = "stable"
+	// TrendGrowing 窗口内持续增长，通常是父进程不回收子进程导致的泄漏
+	TrendGrowing Trend = "growing"
+	// TrendSpiking 相邻两次检测之间突然大量增加
+	TrendSpiking Trend = "spiking"
+)
+
+// zombieSample 一次检测时容器的僵尸进程数量
+type zombieSample struct {
+	count int
+	at    time.Time
+}
+
+// growth 容器在滑动窗口内的增长情况
+type growth struct {
+	// 每分钟的增长量
+	rate  float64
+	trend Trend
+}
+
+// trendTracker 为每个容器保存滑动窗口内的僵尸进程数量采样
+type trendTracker struct {
+	mu      sync.Mutex
+	cfg     config.TrendConfig
+	samples map[string][]zombieSample
+}
+
+func newTrendTracker(cfg config.TrendConfig) *trendTracker {
+	return &trendTracker{cfg: cfg, samples: make(map[string][]zombieSample)}
+}
+
+// setConfig 热加载时更新趋势参数
+func (t *trendTracker) setConfig(cfg config.TrendConfig) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cfg = cfg
+}
+
+// observe 记录本次的僵尸进程数量，丢弃窗口之外的采样，返回每个容器的增长情况。
+// 不在counts中的容器（僵尸进程已全部消失）不再跟踪
+func (t *trendTracker) observe(counts map[string]int, now time.Time) map[string]growth {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	cutoff := now.Add(-t.cfg.Window)
+	result := make(map[string]growth, len(counts))
+	for containerID, count := range counts {
+		samples := t.samples[containerID]
+		kept := samples[:0]
+		for _, s := range samples {
+			if !s.at.Before(cutoff) && s.at.Before(now) {
+				kept = append(kept, s)
+			}
+		}
+		kept = append(kept, zombieSample{count: count, at: now})
+		t.samples[containerID] = kept
+		result[containerID] = t.classify(kept)
+	}
+	for containerID := range t.samples {
+		if _, ok := counts[containerID]; !ok {
+			delete(t.samples, containerID)
+		}
+	}
+	return result
+}
+
+// classify 按窗口内的采样判断趋势：最近两次采样之间增加量达到spike_increase为突增；
+// 至少3次采样、最小二乘斜率为正且相对窗口内最小值的增加量达到growth_min_increase为持续增长
+func (t *trendTracker) classify(samples []zombieSample) growth {
+	g := growth{rate: slope(samples), trend: TrendStable}
+	n := len(samples)
+	if n < 2 {
+		return g
+	}
+
+	last := samples[n-1].count
+	if last-samples[n-2].count >= t.cfg.SpikeIncrease {
+		g.trend = TrendSpiking
+		return g
+	}
+
+	lowest := last
+	for _, s := range samples {
+		lowest = min(lowest, s.count)
+	}
+	if n >= 3 && g.rate > 0 && last-lowest >= t.cfg.GrowthMinIncrease {
+		g.trend = TrendGrowing
+	}
+	return g
+}
+
+// slope 按最小二乘法计算僵尸进程数量每分钟的变化量，采样不足两次时为0
+func slope(samples []zombieSample) float64 {
+	if len(samples) < 2 {
+		return 0
+	}
+	origin := samples[0].at
+	var sumX, sumY, sumXX, sumXY float64
+	for _, s := range samples {
+		x := s.at.Sub(origin).Minutes()
+		y := float64(s.count)
+		sumX += x
+		sumY += y
+		sumXX += x * x
+		sumXY += x * y
+	}
+	n := float64(len(samples))
+	denom := n*sumXX - sumX*sumX
+	if denom == 0 {
+		return 0
+	}
+	return (n*sumXY - sumX*sumY) / denom
+}
@@ -0,0 +1,99 @@
+package detector
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/tiggoins/zombie-cleaner/internal/config"
+)
+
+func TestTrendTracker(t *testing.T) {
+	cfg := config.TrendConfig{Window: 15 * time.Minute, GrowthMinIncrease: 3, SpikeIncrease: 20}
+
+	tests := []struct {
+		name string
+		// 每5分钟一次检测时的僵尸进程数量
+		counts    []int
+		wantTrend Trend
+		wantRate  float64
+	}{
+		{
+			name:      "首次发现",
+			counts:    []int{5},
+			wantTrend: TrendStable,
+		},
+		{
+			name:      "单个稳定的僵尸进程",
+			counts:    []int{1, 1, 1, 1},
+			wantTrend: TrendStable,
+		},
+		{
+			name:      "持续增长",
+			counts:    []int{2, 4, 6, 8},
+			wantTrend: TrendGrowing,
+			wantRate:  0.4,
+		},
+		{
+			name:      "增长量不足",
+			counts:    []int{1, 2, 3},
+			wantTrend: TrendStable,
+			wantRate:  0.2,
+		},
+		{
+			name:      "两次采样不足以判断持续增长",
+			counts:    []int{1, 10},
+			wantTrend: TrendStable,
+			wantRate:  1.8,
+		},
+		{
+			name:      "突增",
+			counts:    []int{1, 1, 30},
+			wantTrend: TrendSpiking,
+			wantRate:  2.9,
+		},
+		{
+			name: "窗口之外的采样不参与计算",
+			// 第一次的0个在20分钟前，超出15分钟的窗口
+			counts:    []int{0, 10, 10, 10, 10},
+			wantTrend: TrendStable,
+		},
+		{
+			name:      "数量减少",
+			counts:    []int{10, 8, 6},
+			wantTrend: TrendStable,
+			wantRate:  -0.4,
+		},
+	}
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := newTrendTracker(cfg)
+			var g growth
+			for i, count := range tt.counts {
+				g = tracker.observe(map[string]int{"c1": count}, start.Add(time.Duration(i)*5*time.Minute))["c1"]
+			}
+			if g.trend != tt.wantTrend {
+				t.Errorf("trend = %s, 期望 %s", g.trend, tt.wantTrend)
+			}
+			if math.Abs(g.rate-tt.wantRate) > 0.01 {
+				t.Errorf("rate = %.2f, 期望 %.2f", g.rate, tt.wantRate)
+			}
+		})
+	}
+}
+
+func TestTrendTrackerForgetsContainers(t *testing.T) {
+	tracker := newTrendTracker(config.TrendConfig{Window: 15 * time.Minute, GrowthMinIncrease: 3, SpikeIncrease: 20})
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tracker.observe(map[string]int{"c1": 2, "c2": 1}, start)
+	tracker.observe(map[string]int{"c2": 1}, start.Add(5*time.Minute))
+	if _, ok := tracker.samples["c1"]; ok {
+		t.Error("僵尸进程消失的容器不应继续跟踪")
+	}
+	if got := len(tracker.samples["c2"]); got != 2 {
+		t.Errorf("c2的采样数量 = %d, 期望 2", got)
+	}
+}
@@ -0,0 +1,100 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// 预算ConfigMap中的字段
+const (
+	budgetKeyWindowStart = "window_start"
+	budgetKeyCount       = "count"
+)
+
+// 更新冲突时的最大重试次数
+const budgetMaxRetries = 5
+
+// ConfigMapBudget 通过ConfigMap记录全集群在当前小时窗口内已启动的处置次数。
+// 各节点依赖resourceVersion乐观并发控制更新计数
+type ConfigMapBudget struct {
+	client    kubernetes.Interface
+	namespace string
+	name      string
+	limit     int
+	window    time.Duration
+
+	// 可替换的时间源
+	now func() time.Time
+}
+
+// NewConfigMapBudget 创建集群预算，limit为每小时上限
+func NewConfigMapBudget(client kubernetes.Interface, namespace, name string, limit int) *ConfigMapBudget {
+	return &ConfigMapBudget{
+		client:    client,
+		namespace: namespace,
+		name:      name,
+		limit:     limit,
+		window:    time.Hour,
+		now:       time.Now,
+	}
+}
+
+// Acquire 在当前窗口内占用一次额度，额度已用完时返回false
+func (b *ConfigMapBudget) Acquire(ctx context.Context) (bool, error) {
+	for i := 0; i < budgetMaxRetries; i++ {
+		allowed, err := b.tryAcquire(ctx)
+		if apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err) {
+			continue
+		}
+		return allowed, err
+	}
+	return false, fmt.Errorf("更新集群处置预算冲突次数过多")
+}
+
+func (b *ConfigMapBudget) tryAcquire(ctx context.Context) (bool, error) {
+	windowStart := b.now().Truncate(b.window).UTC().Format(time.RFC3339)
+
+	cm, err := b.client.CoreV1().ConfigMaps(b.namespace).Get(ctx, b.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: b.name, Namespace: b.namespace},
+			Data: map[string]string{
+				budgetKeyWindowStart: windowStart,
+				budgetKeyCount:       "1",
+			},
+		}
+		if _, err := b.client.CoreV1().ConfigMaps(b.namespace).Create(ctx, cm, metav1.CreateOptions{}); err != nil {
+			return false, fmt.Errorf("创建集群处置预算失败: %w", err)
+		}
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("读取集群处置预算失败: %w", err)
+	}
+
+	count := 0
+	if cm.Data[budgetKeyWindowStart] == windowStart {
+		// 计数无法解析时视为0，由本次更新覆盖
+		count, _ = strconv.Atoi(cm.Data[budgetKeyCount])
+	}
+	if count >= b.limit {
+		return false, nil
+	}
+
+	if cm.Data == nil {
+		cm.Data = make(map[string]string)
+	}
+	cm.Data[budgetKeyWindowStart] = windowStart
+	cm.Data[budgetKeyCount] = strconv.Itoa(count + 1)
+	if _, err := b.client.CoreV1().ConfigMaps(b.namespace).Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+		return false, fmt.Errorf("更新集群处置预算失败: %w", err)
+	}
+	return true, nil
+}
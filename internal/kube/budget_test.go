@@ -0,0 +1,140 @@
+package kube
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var configMapsResource = schema.GroupResource{Resource: "configmaps"}
+
+func newTestConfigMapBudget(client *fake.Clientset, limit int, now *time.Time) *ConfigMapBudget {
+	b := NewConfigMapBudget(client, "kube-system", "zombie-cleaner-budget", limit)
+	b.now = func() time.Time { return *now }
+	return b
+}
+
+func getBudgetConfigMap(t *testing.T, client *fake.Clientset) *corev1.ConfigMap {
+	t.Helper()
+	cm, err := client.CoreV1().ConfigMaps("kube-system").Get(context.Background(), "zombie-cleaner-budget", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("读取预算ConfigMap失败: %v", err)
+	}
+	return cm
+}
+
+func TestConfigMapBudgetLimit(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	now := time.Date(2024, 5, 1, 12, 10, 0, 0, time.UTC)
+	b := newTestConfigMapBudget(client, 2, &now)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		allowed, err := b.Acquire(ctx)
+		if err != nil || !allowed {
+			t.Fatalf("第%d次Acquire = (%v, %v), 期望允许", i+1, allowed, err)
+		}
+	}
+	allowed, err := b.Acquire(ctx)
+	if err != nil || allowed {
+		t.Fatalf("超过上限后Acquire = (%v, %v), 期望拒绝且无错误", allowed, err)
+	}
+
+	cm := getBudgetConfigMap(t, client)
+	if cm.Data[budgetKeyCount] != "2" {
+		t.Errorf("count = %q, 期望2", cm.Data[budgetKeyCount])
+	}
+	if cm.Data[budgetKeyWindowStart] != "2024-05-01T12:00:00Z" {
+		t.Errorf("window_start = %q", cm.Data[budgetKeyWindowStart])
+	}
+}
+
+func TestConfigMapBudgetWindowRollover(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	now := time.Date(2024, 5, 1, 12, 59, 0, 0, time.UTC)
+	b := newTestConfigMapBudget(client, 1, &now)
+	ctx := context.Background()
+
+	if allowed, _ := b.Acquire(ctx); !allowed {
+		t.Fatal("窗口内第一次应允许")
+	}
+	if allowed, _ := b.Acquire(ctx); allowed {
+		t.Fatal("窗口内额度已用完")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if allowed, err := b.Acquire(ctx); err != nil || !allowed {
+		t.Fatalf("进入新窗口后Acquire = (%v, %v), 期望允许", allowed, err)
+	}
+	cm := getBudgetConfigMap(t, client)
+	if cm.Data[budgetKeyWindowStart] != "2024-05-01T13:00:00Z" || cm.Data[budgetKeyCount] != "1" {
+		t.Errorf("新窗口数据 = %v", cm.Data)
+	}
+}
+
+func TestConfigMapBudgetCorruptedCount(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	client := fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "zombie-cleaner-budget", Namespace: "kube-system"},
+		Data:       map[string]string{budgetKeyWindowStart: "2024-05-01T12:00:00Z", budgetKeyCount: "garbage"},
+	})
+	b := newTestConfigMapBudget(client, 1, &now)
+
+	if allowed, err := b.Acquire(context.Background()); err != nil || !allowed {
+		t.Fatalf("计数无法解析时Acquire = (%v, %v), 期望视为0并允许", allowed, err)
+	}
+	if cm := getBudgetConfigMap(t, client); cm.Data[budgetKeyCount] != "1" {
+		t.Errorf("count = %q, 期望1", cm.Data[budgetKeyCount])
+	}
+}
+
+func TestConfigMapBudgetConflictRetry(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	client := fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "zombie-cleaner-budget", Namespace: "kube-system"},
+		Data:       map[string]string{budgetKeyWindowStart: "2024-05-01T12:00:00Z", budgetKeyCount: "0"},
+	})
+
+	// 前两次更新模拟其他节点并发修改
+	conflicts := 2
+	client.PrependReactor("update", "configmaps", func(k8stesting.Action) (bool, runtime.Object, error) {
+		if conflicts > 0 {
+			conflicts--
+			return true, nil, apierrors.NewConflict(configMapsResource, "zombie-cleaner-budget", errors.New("resourceVersion changed"))
+		}
+		return false, nil, nil
+	})
+
+	b := newTestConfigMapBudget(client, 5, &now)
+	if allowed, err := b.Acquire(context.Background()); err != nil || !allowed {
+		t.Fatalf("冲突重试后Acquire = (%v, %v), 期望允许", allowed, err)
+	}
+	if cm := getBudgetConfigMap(t, client); cm.Data[budgetKeyCount] != "1" {
+		t.Errorf("count = %q, 期望1", cm.Data[budgetKeyCount])
+	}
+}
+
+func TestConfigMapBudgetTooManyConflicts(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	client := fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "zombie-cleaner-budget", Namespace: "kube-system"},
+	})
+	client.PrependReactor("update", "configmaps", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewConflict(configMapsResource, "zombie-cleaner-budget", errors.New("resourceVersion changed"))
+	})
+
+	b := newTestConfigMapBudget(client, 5, &now)
+	allowed, err := b.Acquire(context.Background())
+	if err == nil || allowed {
+		t.Fatalf("持续冲突时Acquire = (%v, %v), 期望拒绝并返回错误", allowed, err)
+	}
+}
@@ -0,0 +1,178 @@
+package kube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/tiggoins/zombie-cleaner/internal/config"
+	"github.com/tiggoins/zombie-cleaner/internal/logger"
+	"github.com/tiggoins/zombie-cleaner/internal/metrics"
+)
+
+// ErrEvictionBlocked 驱逐被PodDisruptionBudget拒绝
+var ErrEvictionBlocked = errors.New("驱逐被PodDisruptionBudget拒绝")
+
+// NewClient 创建Kubernetes客户端，未指定kubeconfig时使用集群内配置
+func NewClient(cfg config.KubernetesConfig) (kubernetes.Interface, error) {
+	var (
+		restConfig *rest.Config
+		err        error
+	)
+	if cfg.Kubeconfig != "" {
+		restConfig, err = clientcmd.BuildConfigFromFlags("", cfg.Kubeconfig)
+	} else {
+		restConfig, err = rest.InClusterConfig()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("加载Kubernetes配置失败: %w", err)
+	}
+	restConfig.Timeout = cfg.APITimeout
+	restConfig.UserAgent = "zombie-cleaner"
+
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("创建Kubernetes客户端失败: %w", err)
+	}
+	return client, nil
+}
+
+// IsUnreachable 判断错误是否表示API Server不可达，此时调用方应回退到运行时删除
+func IsUnreachable(err error) bool {
+	if err == nil {
+		return false
+	}
+	var status apierrors.APIStatus
+	if !errors.As(err, &status) {
+		// 非API状态错误：连接失败、DNS、TLS、上下文超时等
+		return true
+	}
+	return apierrors.IsServerTimeout(err) || apierrors.IsTimeout(err) || apierrors.IsServiceUnavailable(err)
+}
+
+// PodRemediator 通过Kubernetes API驱逐Pod，驱逐接口不可用时回退到带宽限期的删除
+type PodRemediator struct {
+	client      kubernetes.Interface
+	logger      *logger.Logger
+	gracePeriod time.Duration
+}
+
+// NewPodRemediator 创建Pod处置器，client可以是fake clientset
+func NewPodRemediator(client kubernetes.Interface, gracePeriod time.Duration, log *logger.Logger) *PodRemediator {
+	return &PodRemediator{
+		client:      client,
+		logger:      log.WithComponent("kube"),
+		gracePeriod: gracePeriod,
+	}
+}
+
+// EvictPod 驱逐Pod，uid非空时作为前置条件，避免误删同名的新Pod。
+// Pod已不存在时视为成功；PDB拒绝时返回ErrEvictionBlocked
+func (r *PodRemediator) EvictPod(ctx context.Context, namespace, name, uid string) error {
+	gracePeriodSeconds := int64(r.gracePeriod.Seconds())
+	deleteOptions := &metav1.DeleteOptions{GracePeriodSeconds: &gracePeriodSeconds}
+	if uid != "" {
+		podUID := types.UID(uid)
+		deleteOptions.Preconditions = &metav1.Preconditions{UID: &podUID}
+	}
+
+	r.logger.Info("尝试驱逐Pod", "namespace", namespace, "pod_name", name)
+
+	err := r.client.CoreV1().Pods(namespace).EvictV1(ctx, &policyv1.Eviction{
+		ObjectMeta:    metav1.ObjectMeta{Name: name, Namespace: namespace},
+		DeleteOptions: deleteOptions,
+	})
+	switch {
+	case err == nil:
+		r.logger.Info("成功提交Pod驱逐", "namespace", namespace, "pod_name", name)
+		return nil
+	case isDisruptionBudgetError(err):
+		// 保留API状态，调用方据此区分PDB拒绝和API不可达
+		return fmt.Errorf("%w: %w", ErrEvictionBlocked, err)
+	case apierrors.IsConflict(err):
+		// UID前置条件不满足，原Pod已被替换
+		r.logger.Info("Pod已被重建，跳过驱逐", "namespace", namespace, "pod_name", name)
+		return nil
+	case apierrors.IsNotFound(err), apierrors.IsMethodNotSupported(err):
+		// Pod不存在，或集群不支持eviction子资源
+		return r.deletePod(ctx, namespace, name, deleteOptions)
+	default:
+		return fmt.Errorf("驱逐Pod失败: %w", err)
+	}
+}
+
+// deletePod 带宽限期删除Pod，Pod不存在时视为成功
+func (r *PodRemediator) deletePod(ctx context.Context, namespace, name string, deleteOptions *metav1.DeleteOptions) error {
+	r.logger.Info("驱逐不可用，尝试删除Pod", "namespace", namespace, "pod_name", name)
+
+	err := r.client.CoreV1().Pods(namespace).Delete(ctx, name, *deleteOptions)
+	switch {
+	case err == nil:
+		r.logger.Info("成功提交Pod删除", "namespace", namespace, "pod_name", name)
+		return nil
+	case apierrors.IsNotFound(err):
+		r.logger.Info("Pod已不存在", "namespace", namespace, "pod_name", name)
+		return nil
+	case apierrors.IsConflict(err):
+		r.logger.Info("Pod已被重建，跳过删除", "namespace", namespace, "pod_name", name)
+		return nil
+	default:
+		return fmt.Errorf("删除Pod失败: %w", err)
+	}
+}
+
+// GetPodAnnotations 读取Pod注解，uid非空时校验Pod未被重建
+func GetPodAnnotations(ctx context.Context, client kubernetes.Interface, namespace, name, uid string) (map[string]string, error) {
+	pod, err := client.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("获取Pod失败: %w", err)
+	}
+	if uid != "" && string(pod.UID) != uid {
+		return nil, fmt.Errorf("Pod已被重建: 期望UID %s，实际UID %s", uid, pod.UID)
+	}
+	return pod.Annotations, nil
+}
+
+// RecordPodWarning 在Pod上记录一条Warning事件
+func RecordPodWarning(ctx context.Context, client kubernetes.Interface, namespace, name, uid, reason, message string) error {
+	now := metav1.NewTime(time.Now())
+	event := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: name + ".",
+			Namespace:    namespace,
+		},
+		InvolvedObject: corev1.ObjectReference{
+			Kind:       "Pod",
+			APIVersion: "v1",
+			Namespace:  namespace,
+			Name:       name,
+			UID:        types.UID(uid),
+		},
+		Reason:         reason,
+		Message:        message,
+		Type:           corev1.EventTypeWarning,
+		Source:         corev1.EventSource{Component: "zombie-cleaner", Host: metrics.GetNodeName()},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+	if _, err := client.CoreV1().Events(namespace).Create(ctx, event, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("记录Pod事件失败: %w", err)
+	}
+	return nil
+}
+
+// isDisruptionBudgetError 驱逐被PDB拒绝时API Server返回429
+func isDisruptionBudgetError(err error) bool {
+	return apierrors.IsTooManyRequests(err)
+}
@@ -0,0 +1,192 @@
+package kube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/tiggoins/zombie-cleaner/internal/logger"
+)
+
+var podsResource = schema.GroupResource{Resource: "pods"}
+
+func newTestPod() *corev1.Pod {
+	return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-0", Namespace: "prod", UID: "uid-1"}}
+}
+
+// evictionReactor 让eviction子资源返回指定错误
+func evictionReactor(err error) k8stesting.ReactionFunc {
+	return func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "eviction" {
+			return false, nil, nil
+		}
+		return true, nil, err
+	}
+}
+
+// countActions 统计指定动词和子资源的请求次数
+func countActions(client *fake.Clientset, verb, subresource string) int {
+	count := 0
+	for _, action := range client.Actions() {
+		if action.GetVerb() == verb && action.GetResource().Resource == "pods" && action.GetSubresource() == subresource {
+			count++
+		}
+	}
+	return count
+}
+
+func TestEvictPod(t *testing.T) {
+	tests := []struct {
+		name        string
+		evictionErr error
+		withPod     bool
+		// 为nil时期望成功
+		checkErr    func(error) bool
+		wantDeletes int
+		wantPodGone bool
+	}{
+		{
+			name:    "驱逐成功",
+			withPod: true,
+		},
+		{
+			name:        "PDB拒绝",
+			evictionErr: apierrors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 10),
+			withPod:     true,
+			checkErr:    func(err error) bool { return errors.Is(err, ErrEvictionBlocked) },
+		},
+		{
+			name:        "不支持eviction时回退到删除",
+			evictionErr: apierrors.NewMethodNotSupported(podsResource, "create"),
+			withPod:     true,
+			wantDeletes: 1,
+			wantPodGone: true,
+		},
+		{
+			name:        "Pod不存在时回退到删除并视为成功",
+			withPod:     false,
+			wantDeletes: 1,
+			wantPodGone: true,
+		},
+		{
+			name:        "UID前置条件冲突时跳过",
+			evictionErr: apierrors.NewConflict(podsResource, "web-0", errors.New("precondition failed: UID in precondition: uid-1, UID in object meta: uid-2")),
+			withPod:     true,
+		},
+		{
+			name:        "其他错误",
+			evictionErr: apierrors.NewForbidden(podsResource, "web-0", errors.New("rbac")),
+			withPod:     true,
+			checkErr: func(err error) bool {
+				return apierrors.IsForbidden(err) && !errors.Is(err, ErrEvictionBlocked)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var objects []runtime.Object
+			if tt.withPod {
+				objects = append(objects, newTestPod())
+			}
+			client := fake.NewSimpleClientset(objects...)
+			if tt.evictionErr != nil {
+				client.PrependReactor("create", "pods", evictionReactor(tt.evictionErr))
+			}
+
+			r := NewPodRemediator(client, 30*time.Second, logger.New("error", "text"))
+			err := r.EvictPod(context.Background(), "prod", "web-0", "uid-1")
+
+			if tt.checkErr != nil {
+				if !tt.checkErr(err) {
+					t.Fatalf("EvictPod() 返回了非预期的错误: %v", err)
+				}
+			} else if err != nil {
+				t.Fatalf("EvictPod() 返回错误: %v", err)
+			}
+
+			if got := countActions(client, "create", "eviction"); got != 1 {
+				t.Errorf("eviction请求次数 = %d, 期望1", got)
+			}
+			if got := countActions(client, "delete", ""); got != tt.wantDeletes {
+				t.Errorf("delete请求次数 = %d, 期望 %d", got, tt.wantDeletes)
+			}
+			if tt.wantPodGone {
+				_, getErr := client.CoreV1().Pods("prod").Get(context.Background(), "web-0", metav1.GetOptions{})
+				if !apierrors.IsNotFound(getErr) {
+					t.Errorf("回退删除后Pod仍然存在: %v", getErr)
+				}
+			}
+		})
+	}
+}
+
+func TestEvictPodSendsPreconditionsAndGracePeriod(t *testing.T) {
+	client := fake.NewSimpleClientset(newTestPod())
+	r := NewPodRemediator(client, 45*time.Second, logger.New("error", "text"))
+
+	if err := r.EvictPod(context.Background(), "prod", "web-0", "uid-1"); err != nil {
+		t.Fatalf("EvictPod() 返回错误: %v", err)
+	}
+
+	for _, action := range client.Actions() {
+		create, ok := action.(k8stesting.CreateAction)
+		if !ok || action.GetSubresource() != "eviction" {
+			continue
+		}
+		eviction, ok := create.GetObject().(*policyv1.Eviction)
+		if !ok || eviction.Name != "web-0" || eviction.DeleteOptions == nil {
+			t.Fatalf("eviction对象错误: %#v", create.GetObject())
+		}
+		opts := eviction.DeleteOptions
+		if opts.GracePeriodSeconds == nil || *opts.GracePeriodSeconds != 45 {
+			t.Errorf("GracePeriodSeconds = %v, 期望45", opts.GracePeriodSeconds)
+		}
+		if opts.Preconditions == nil || opts.Preconditions.UID == nil || *opts.Preconditions.UID != "uid-1" {
+			t.Errorf("缺少UID前置条件: %+v", opts.Preconditions)
+		}
+		return
+	}
+	t.Fatal("未发送eviction请求")
+}
+
+func TestIsUnreachable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "连接被拒绝", err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, want: true},
+		{name: "上下文超时", err: context.DeadlineExceeded, want: true},
+		{name: "包装的连接错误", err: fmt.Errorf("驱逐Pod失败: %w", &net.DNSError{Err: "no such host", Name: "kubernetes"}), want: true},
+		{name: "服务器超时", err: apierrors.NewServerTimeout(podsResource, "create", 1), want: true},
+		{name: "网关超时", err: apierrors.NewTimeoutError("timeout", 1), want: true},
+		{name: "服务不可用", err: apierrors.NewServiceUnavailable("unavailable"), want: true},
+		{name: "PDB拒绝", err: apierrors.NewTooManyRequests("pdb", 1), want: false},
+		{name: "EvictPod返回的PDB拒绝", err: fmt.Errorf("%w: %w", ErrEvictionBlocked, apierrors.NewTooManyRequests("pdb", 1)), want: false},
+		{name: "Forbidden", err: apierrors.NewForbidden(podsResource, "web-0", errors.New("rbac")), want: false},
+		{name: "NotFound", err: apierrors.NewNotFound(podsResource, "web-0"), want: false},
+		{name: "包装的Conflict", err: fmt.Errorf("驱逐Pod失败: %w", apierrors.NewConflict(podsResource, "web-0", errors.New("uid"))), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsUnreachable(tt.err); got != tt.want {
+				t.Errorf("IsUnreachable(%v) = %v, 期望 %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
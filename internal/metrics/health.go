@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 单个健康检查的超时时间，需小于readinessProbe的timeoutSeconds
+const healthCheckTimeout = 3 * time.Second
+
+// HealthCheck 健康检查，返回nil表示健康
+type HealthCheck func(ctx context.Context) error
+
+// healthRegistry 按名称保存/health执行的健康检查
+type healthRegistry struct {
+	mu     sync.Mutex
+	checks map[string]HealthCheck
+}
+
+var health = &healthRegistry{checks: make(map[string]HealthCheck)}
+
+// RegisterHealthCheck 注册/health执行的健康检查，同名检查会被替换
+func RegisterHealthCheck(name string, check HealthCheck) {
+	health.register(name, check)
+}
+
+func (h *healthRegistry) register(name string, check HealthCheck) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks[name] = check
+}
+
+// run 并发执行所有健康检查，返回按名称排序的结果
+func (h *healthRegistry) run(ctx context.Context) ([]string, map[string]error) {
+	h.mu.Lock()
+	checks := make(map[string]HealthCheck, len(h.checks))
+	names := make([]string, 0, len(h.checks))
+	for name, check := range h.checks {
+		checks[name] = check
+		names = append(names, name)
+	}
+	h.mu.Unlock()
+	sort.Strings(names)
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]error, len(names))
+	)
+	for _, name := range names {
+		wg.Add(1)
+		go func(name string, check HealthCheck) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+			defer cancel()
+			err := check(checkCtx)
+			mu.Lock()
+			results[name] = err
+			mu.Unlock()
+		}(name, checks[name])
+	}
+	wg.Wait()
+	return names, results
+}
+
+// ServeHTTP 所有检查通过时返回200，否则返回503，响应体逐行列出各检查的结果
+func (h *healthRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	names, results := h.run(r.Context())
+
+	var (
+		body    strings.Builder
+		healthy = true
+	)
+	for _, name := range names {
+		if err := results[name]; err != nil {
+			healthy = false
+			fmt.Fprintf(&body, "%s: %v\n", name, err)
+		} else {
+			fmt.Fprintf(&body, "%s: ok\n", name)
+		}
+	}
+
+	if healthy {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "OK\n%s", body.String())
+		return
+	}
+	w.WriteHeader(http.StatusServiceUnavailable)
+	fmt.Fprintf(w, "UNHEALTHY\n%s", body.String())
+}
@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHealthHandler(t *testing.T) {
+	tests := []struct {
+		name   string
+		checks map[string]HealthCheck
+		// 探针请求已断开
+		canceled   bool
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "没有注册检查",
+			wantStatus: http.StatusOK,
+			wantBody:   "OK\n",
+		},
+		{
+			name: "所有检查通过",
+			checks: map[string]HealthCheck{
+				"runtime/docker":     func(context.Context) error { return nil },
+				"runtime/containerd": func(context.Context) error { return nil },
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   "OK\nruntime/containerd: ok\nruntime/docker: ok\n",
+		},
+		{
+			name: "部分检查失败",
+			checks: map[string]HealthCheck{
+				"runtime/docker":     func(context.Context) error { return errors.New("connection refused") },
+				"runtime/containerd": func(context.Context) error { return nil },
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   "UNHEALTHY\nruntime/containerd: ok\nruntime/docker: connection refused\n",
+		},
+		{
+			name:     "请求断开时停止检查",
+			canceled: true,
+			checks: map[string]HealthCheck{
+				"runtime/cri": func(ctx context.Context) error {
+					<-ctx.Done()
+					return ctx.Err()
+				},
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   "UNHEALTHY\nruntime/cri: context canceled\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &healthRegistry{checks: make(map[string]HealthCheck)}
+			for name, check := range tt.checks {
+				h.register(name, check)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.canceled {
+				cancel()
+			}
+			req := httptest.NewRequest(http.MethodGet, "/health", nil).WithContext(ctx)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("状态码 = %d, 期望 %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Body.String(); got != tt.wantBody {
+				t.Errorf("响应 = %q, 期望 %q", got, tt.wantBody)
+			}
+		})
+	}
+}
@@ -22,6 +22,97 @@ var (
 		[]string{"node"},
 	)
 
+	// 僵尸进程存在时长
+	ZombieAge = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "zombie_cleaner_zombie_age_seconds",
+			Help:    "僵尸进程从首次被发现（或事件记录的退出时间）到被回收的存在时长",
+			Buckets: []float64{30, 60, 300, 900, 1800, 3600, 3 * 3600, 6 * 3600, 12 * 3600, 24 * 3600, 3 * 24 * 3600},
+		},
+		[]string{"node"},
+	)
+
+	// 容器的PID耗尽风险评分
+	PIDRiskScore = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "zombie_cleaner_pid_risk_score",
+			Help: "有僵尸进程的容器的PID耗尽风险评分（0-100）",
+		},
+		[]string{"node", "namespace", "pod_name"},
+	)
+
+	// 容器的僵尸进程数量
+	ContainerZombies = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "zombie_cleaner_container_zombie_processes",
+			Help: "有僵尸进程的容器当前的僵尸进程数量",
+		},
+		[]string{"node", "namespace", "pod_name"},
+	)
+
+	// 容器僵尸进程数量的增长速度
+	ZombieGrowthRate = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "zombie_cleaner_zombie_growth_per_minute",
+			Help: "滑动窗口内容器僵尸进程数量每分钟的增长量",
+		},
+		[]string{"node", "namespace", "pod_name"},
+	)
+
+	// 容器僵尸进程数量的趋势，当前趋势取值为1
+	ZombieTrend = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "zombie_cleaner_zombie_trend",
+			Help: "容器僵尸进程数量的趋势（stable/growing/spiking），当前趋势取值为1",
+		},
+		[]string{"node", "namespace", "pod_name", "trend"},
+	)
+
+	// 节点PID使用比例
+	NodePIDUsage = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "zombie_cleaner_node_pid_usage_ratio",
+			Help: "节点进程和线程总数占kernel.pid_max和kernel.threads-max中较小者的比例",
+		},
+		[]string{"node"},
+	)
+
+	// 容器init进程不回收僵尸进程的告警次数
+	MissingInitReaper = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "zombie_cleaner_missing_init_reaper_total",
+			Help: "确认容器的init进程不回收僵尸进程（缺少init reaper）的次数",
+		},
+		[]string{"node", "namespace", "pod_name"},
+	)
+
+	// 宿主机僵尸进程数量
+	HostZombies = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "zombie_cleaner_host_zombie_processes",
+			Help: "不属于任何容器的僵尸进程数量，按父进程名称和父进程所属的systemd unit分组",
+		},
+		[]string{"node", "parent", "unit"},
+	)
+
+	// 宿主机僵尸进程处理动作执行次数
+	HostZombieActions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "zombie_cleaner_host_zombie_actions_total",
+			Help: "宿主机僵尸进程处理动作（sigchld_parent、restart_unit）的执行次数",
+		},
+		[]string{"node", "action", "result"},
+	)
+
+	// 处于不可中断睡眠（D状态）的进程数量
+	StuckProcesses = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "zombie_cleaner_stuck_processes",
+			Help: "持续处于不可中断睡眠（D状态）的进程数量，按所属Pod和阻塞所在的内核函数（wchan）分组，宿主机进程的namespace和pod_name为空",
+		},
+		[]string{"node", "namespace", "pod_name", "wchan"},
+	)
+
 	// 容器清理次数
 	ContainersCleaned = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
@@ -67,6 +158,123 @@ var (
 		},
 		[]string{"node"},
 	)
+
+	// 接收到的进程事件数量
+	ProcEventsReceived = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "zombie_cleaner_proc_events_total",
+			Help: "从netlink proc connector接收到的进程事件数量",
+		},
+		[]string{"node", "type"},
+	)
+
+	// 事件跟踪到的已退出但未被回收的子进程数量
+	UnreapedChildren = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "zombie_cleaner_unreaped_children",
+			Help: "事件跟踪到的已退出但未被父进程回收的子进程数量",
+		},
+		[]string{"node"},
+	)
+
+	// 处置步骤执行次数
+	RemediationSteps = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "zombie_cleaner_remediation_steps_total",
+			Help: "处置阶梯各步骤的执行次数",
+		},
+		[]string{"node", "action", "result"},
+	)
+
+	// 通过Kubernetes后端处置的Pod数量
+	PodEvictions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "zombie_cleaner_pod_evictions_total",
+			Help: "通过Kubernetes API驱逐或删除的Pod数量",
+		},
+		[]string{"node", "method"},
+	)
+
+	// 策略决策次数
+	PolicyDecisions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "zombie_cleaner_policy_decisions_total",
+			Help: "按策略规则和处置方式统计的决策次数",
+		},
+		[]string{"node", "rule", "action"},
+	)
+
+	// 处置预算是否耗尽，耗尽时只告警不处置
+	RemediationBudgetExhausted = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "zombie_cleaner_remediation_budget_exhausted",
+			Help: "处置预算是否耗尽（1为耗尽，此时只告警不处置）",
+		},
+		[]string{"node", "scope"},
+	)
+
+	// 因预算耗尽而未执行的处置次数
+	RemediationsThrottled = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "zombie_cleaner_remediations_throttled_total",
+			Help: "因处置预算耗尽而改为只告警的次数",
+		},
+		[]string{"node", "scope"},
+	)
+
+	// 由事件触发的检测次数
+	EventTriggeredChecks = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "zombie_cleaner_event_triggered_checks_total",
+			Help: "由进程事件触发的立即检测次数",
+		},
+		[]string{"node"},
+	)
+
+	// 工作队列中排队、等待重试和执行中的任务数量
+	WorkQueueDepth = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "zombie_cleaner_workqueue_depth",
+			Help: "工作队列中排队、等待重试和执行中的任务数量",
+		},
+		[]string{"node", "queue"},
+	)
+
+	// 工作队列任务重试次数
+	WorkQueueRetries = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "zombie_cleaner_workqueue_retries_total",
+			Help: "工作队列任务失败后的重试次数",
+		},
+		[]string{"node", "queue"},
+	)
+
+	// 配置热加载次数
+	ConfigReloads = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "zombie_cleaner_config_reloads_total",
+			Help: "配置热加载次数（按结果）",
+		},
+		[]string{"node", "result"},
+	)
+
+	// 当前生效配置的摘要，取值固定为1
+	ConfigInfo = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "zombie_cleaner_config_info",
+			Help: "当前生效配置的摘要",
+		},
+		[]string{"node", "hash"},
+	)
+
+	// 容器运行时是否可用，由/health的检查更新
+	RuntimeUp = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "zombie_cleaner_runtime_up",
+			Help: "容器运行时是否可用（1可用，0不可用）",
+		},
+		[]string{"node", "runtime"},
+	)
 )
 
 type Server struct {
@@ -78,11 +286,34 @@ func NewServer(port int, log *logger.Logger) *Server {
 	// 注册指标
 	prometheus.MustRegister(
 		ZombieProcessesFound,
+		ZombieAge,
+		PIDRiskScore,
+		ContainerZombies,
+		ZombieGrowthRate,
+		ZombieTrend,
+		NodePIDUsage,
+		MissingInitReaper,
+		HostZombies,
+		HostZombieActions,
+		StuckProcesses,
 		ContainersCleaned,
 		CleanupFailures,
 		CheckDuration,
 		ContainerOperationTimeouts,
 		TrackedContainers,
+		ProcEventsReceived,
+		UnreapedChildren,
+		EventTriggeredChecks,
+		RemediationSteps,
+		PodEvictions,
+		PolicyDecisions,
+		RemediationBudgetExhausted,
+		RemediationsThrottled,
+		WorkQueueDepth,
+		WorkQueueRetries,
+		ConfigReloads,
+		ConfigInfo,
+		RuntimeUp,
 	)
 
 	return &Server{
@@ -94,7 +325,10 @@ func NewServer(port int, log *logger.Logger) *Server {
 func (s *Server) Start() error {
 	mux := http.NewServeMux()
 	mux.Handle("/metrics", promhttp.Handler())
-	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+	// /health执行注册的健康检查（如容器运行时连接），用于readinessProbe
+	mux.Handle("/health", health)
+	// /livez只表示进程存活，运行时短暂不可用时不应重启清理器
+	mux.HandleFunc("/livez", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
 	})
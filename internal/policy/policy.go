@@ -0,0 +1,220 @@
+package policy
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/tiggoins/zombie-cleaner/internal/config"
+)
+
+// DefaultRuleName 没有规则匹配时使用的默认决策名称
+const DefaultRuleName = "default"
+
+// MissingReaperRuleName 按孤儿僵尸进程策略决策时的名称
+const MissingReaperRuleName = "missing_init_reaper"
+
+// Input 策略匹配的输入
+type Input struct {
+	Namespace   string
+	Labels      map[string]string
+	Image       string
+	Command     string
+	ZombieCount int
+}
+
+// Decision 策略决策结果
+type Decision struct {
+	// 做出决策的规则名称
+	Rule string
+	// 处置方式
+	Action config.PolicyAction
+	// 确认次数
+	ConfirmCount int
+	// 本次处置使用的阶梯步骤
+	Steps []config.RemediationStep
+	// 覆盖了规则决策的Pod注解，没有覆盖时为空
+	Overrides []string
+}
+
+type compiledRule struct {
+	rule       config.PolicyRule
+	namespaces map[string]bool
+	images     []*regexp.Regexp
+	command    *regexp.Regexp
+	steps      []config.RemediationStep
+}
+
+// Engine 按顺序匹配规则，第一条匹配的规则生效
+type Engine struct {
+	rules        []compiledRule
+	confirmCount int
+	steps        []config.RemediationStep
+	orphans      config.OrphanPolicy
+	orphanSteps  []config.RemediationStep
+}
+
+// New 编译策略规则
+func New(cfg *config.CleanerConfig) (*Engine, error) {
+	e := &Engine{
+		confirmCount: cfg.ConfirmCount,
+		steps:        cfg.RemediationSteps,
+		orphans:      cfg.Orphans,
+		orphanSteps:  cfg.RemediationSteps,
+	}
+	if len(cfg.Orphans.RemediationSteps) > 0 {
+		e.orphanSteps = selectSteps(cfg.RemediationSteps, cfg.Orphans.RemediationSteps)
+	}
+
+	for _, rule := range cfg.Policy.Rules {
+		compiled := compiledRule{
+			rule:  rule,
+			steps: cfg.RemediationSteps,
+		}
+
+		if len(rule.Match.Namespaces) > 0 {
+			compiled.namespaces = make(map[string]bool, len(rule.Match.Namespaces))
+			for _, ns := range rule.Match.Namespaces {
+				compiled.namespaces[ns] = true
+			}
+		}
+
+		for _, pattern := range rule.Match.Images {
+			regex, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("策略规则%s的镜像正则表达式编译失败: %w", rule.Name, err)
+			}
+			compiled.images = append(compiled.images, regex)
+		}
+
+		if rule.Match.Command != "" {
+			regex, err := regexp.Compile(rule.Match.Command)
+			if err != nil {
+				return nil, fmt.Errorf("策略规则%s的命令正则表达式编译失败: %w", rule.Name, err)
+			}
+			compiled.command = regex
+		}
+
+		if len(rule.RemediationSteps) > 0 {
+			compiled.steps = selectSteps(cfg.RemediationSteps, rule.RemediationSteps)
+		}
+
+		e.rules = append(e.rules, compiled)
+	}
+
+	return e, nil
+}
+
+// Evaluate 返回第一条匹配规则的决策，没有匹配时按全局配置处置
+func (e *Engine) Evaluate(input Input) Decision {
+	for _, compiled := range e.rules {
+		if !compiled.matches(input) {
+			continue
+		}
+		decision := Decision{
+			Rule:         compiled.rule.Name,
+			Action:       compiled.rule.Action,
+			ConfirmCount: compiled.rule.ConfirmCount,
+			Steps:        compiled.steps,
+		}
+		if decision.ConfirmCount == 0 {
+			decision.ConfirmCount = e.confirmCount
+		}
+		return decision
+	}
+
+	return Decision{
+		Rule:         DefaultRuleName,
+		Action:       config.PolicyRemediate,
+		ConfirmCount: e.confirmCount,
+		Steps:        e.steps,
+	}
+}
+
+// EvaluateMissingReaper 容器init进程不回收僵尸进程时，按孤儿僵尸进程策略调整decision。
+// 原决策比孤儿策略更保守时保持原决策，否则使用孤儿策略的处置方式；处置步骤只保留孤儿策略和原决策都启用的步骤，
+// 不会超出匹配规则允许的范围。确认次数和注解覆盖沿用原决策
+func (e *Engine) EvaluateMissingReaper(decision Decision) Decision {
+	if actionRank(decision.Action) < actionRank(e.orphans.Action) {
+		return decision
+	}
+	decision.Rule = MissingReaperRuleName
+	decision.Action = e.orphans.Action
+	decision.Steps = intersectSteps(e.orphanSteps, decision.Steps)
+	return decision
+}
+
+// actionRank 处置方式的保守程度，数值越小越保守
+func actionRank(action config.PolicyAction) int {
+	switch action {
+	case config.PolicyIgnore:
+		return 0
+	case config.PolicyAlert:
+		return 1
+	default:
+		return 2
+	}
+}
+
+func (r *compiledRule) matches(input Input) bool {
+	match := r.rule.Match
+
+	if r.namespaces != nil && !r.namespaces[input.Namespace] {
+		return false
+	}
+	for key, value := range match.Labels {
+		if actual, ok := input.Labels[key]; !ok || actual != value {
+			return false
+		}
+	}
+	if len(r.images) > 0 {
+		matched := false
+		for _, regex := range r.images {
+			if regex.MatchString(input.Image) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if r.command != nil && !r.command.MatchString(input.Command) {
+		return false
+	}
+	if input.ZombieCount < match.MinZombies {
+		return false
+	}
+	return true
+}
+
+// selectSteps 按全局阶梯的顺序和超时配置，只启用规则中列出的步骤
+// intersectSteps 返回steps的副本，只保留在allowed中也启用的步骤
+func intersectSteps(steps, allowed []config.RemediationStep) []config.RemediationStep {
+	enabled := make(map[config.RemediationAction]bool, len(allowed))
+	for _, step := range allowed {
+		if step.Enabled {
+			enabled[step.Action] = true
+		}
+	}
+
+	result := make([]config.RemediationStep, len(steps))
+	for i, step := range steps {
+		step.Enabled = step.Enabled && enabled[step.Action]
+		result[i] = step
+	}
+	return result
+}
+
+func selectSteps(all []config.RemediationStep, enabled []config.RemediationAction) []config.RemediationStep {
+	want := make(map[config.RemediationAction]bool, len(enabled))
+	for _, action := range enabled {
+		want[action] = true
+	}
+
+	steps := make([]config.RemediationStep, len(all))
+	for i, step := range all {
+		step.Enabled = want[step.Action]
+		steps[i] = step
+	}
+	return steps
+}
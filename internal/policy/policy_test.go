@@ -0,0 +1,306 @@
+package policy
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/tiggoins/zombie-cleaner/internal/config"
+)
+
+func testSteps() []config.RemediationStep {
+	return []config.RemediationStep{
+		{Action: config.ActionSigchldParent, Enabled: true, Timeout: 10 * time.Second},
+		{Action: config.ActionSignalParent, Enabled: false, Timeout: 30 * time.Second, Signal: "SIGTERM"},
+		{Action: config.ActionStopContainer, Enabled: true, Timeout: 30 * time.Second},
+		{Action: config.ActionRemoveContainer, Enabled: true, Timeout: 10 * time.Second},
+	}
+}
+
+func newTestEngine(t *testing.T, rules ...config.PolicyRule) *Engine {
+	t.Helper()
+	e, err := New(&config.CleanerConfig{
+		ConfirmCount:     3,
+		RemediationSteps: testSteps(),
+		Policy:           config.PolicyConfig{Rules: rules},
+	})
+	if err != nil {
+		t.Fatalf("编译策略失败: %v", err)
+	}
+	return e
+}
+
+func TestEvaluateMatchers(t *testing.T) {
+	tests := []struct {
+		name      string
+		match     config.PolicyMatch
+		input     Input
+		wantMatch bool
+	}{
+		{name: "空条件匹配所有", match: config.PolicyMatch{}, input: Input{Namespace: "any"}, wantMatch: true},
+		{
+			name:      "命名空间命中其一",
+			match:     config.PolicyMatch{Namespaces: []string{"a", "b"}},
+			input:     Input{Namespace: "b"},
+			wantMatch: true,
+		},
+		{
+			name:  "命名空间不匹配",
+			match: config.PolicyMatch{Namespaces: []string{"a"}},
+			input: Input{Namespace: "c"},
+		},
+		{
+			name:      "标签全部相等",
+			match:     config.PolicyMatch{Labels: map[string]string{"app": "web", "tier": "fe"}},
+			input:     Input{Labels: map[string]string{"app": "web", "tier": "fe", "extra": "x"}},
+			wantMatch: true,
+		},
+		{
+			name:  "标签值不同",
+			match: config.PolicyMatch{Labels: map[string]string{"app": "web"}},
+			input: Input{Labels: map[string]string{"app": "api"}},
+		},
+		{
+			name:  "缺少标签",
+			match: config.PolicyMatch{Labels: map[string]string{"app": "web"}},
+			input: Input{Labels: nil},
+		},
+		{
+			name:      "镜像正则命中其一",
+			match:     config.PolicyMatch{Images: []string{"^nginx:", "^registry.example.com/legacy/"}},
+			input:     Input{Image: "registry.example.com/legacy/app:1.0"},
+			wantMatch: true,
+		},
+		{
+			name:  "镜像正则不匹配",
+			match: config.PolicyMatch{Images: []string{"^nginx:"}},
+			input: Input{Image: "redis:7"},
+		},
+		{
+			name:      "命令正则匹配",
+			match:     config.PolicyMatch{Command: "^java "},
+			input:     Input{Command: "java -jar app.jar"},
+			wantMatch: true,
+		},
+		{
+			name:  "命令正则不匹配",
+			match: config.PolicyMatch{Command: "^java "},
+			input: Input{Command: "/bin/sh -c run.sh"},
+		},
+		{
+			name:      "僵尸进程数量达到下限",
+			match:     config.PolicyMatch{MinZombies: 5},
+			input:     Input{ZombieCount: 5},
+			wantMatch: true,
+		},
+		{
+			name:  "僵尸进程数量低于下限",
+			match: config.PolicyMatch{MinZombies: 5},
+			input: Input{ZombieCount: 4},
+		},
+		{
+			name: "所有条件同时满足",
+			match: config.PolicyMatch{
+				Namespaces: []string{"legacy"},
+				Labels:     map[string]string{"app": "batch"},
+				Images:     []string{"legacy"},
+				Command:    "java",
+				MinZombies: 2,
+			},
+			input: Input{
+				Namespace:   "legacy",
+				Labels:      map[string]string{"app": "batch"},
+				Image:       "legacy/batch:1",
+				Command:     "java -jar batch.jar",
+				ZombieCount: 2,
+			},
+			wantMatch: true,
+		},
+		{
+			name: "任一条件不满足",
+			match: config.PolicyMatch{
+				Namespaces: []string{"legacy"},
+				Command:    "java",
+			},
+			input: Input{Namespace: "legacy", Command: "python"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEngine(t, config.PolicyRule{Name: "rule", Match: tt.match, Action: config.PolicyAlert})
+			decision := e.Evaluate(tt.input)
+			if matched := decision.Rule == "rule"; matched != tt.wantMatch {
+				t.Errorf("规则匹配 = %v, 期望 %v (decision: %+v)", matched, tt.wantMatch, decision)
+			}
+		})
+	}
+}
+
+func TestEvaluateFirstMatchWins(t *testing.T) {
+	e := newTestEngine(t,
+		config.PolicyRule{Name: "ignore-system", Match: config.PolicyMatch{Namespaces: []string{"kube-system"}}, Action: config.PolicyIgnore},
+		config.PolicyRule{Name: "alert-all", Action: config.PolicyAlert},
+		config.PolicyRule{Name: "never-reached", Action: config.PolicyRemediate},
+	)
+
+	tests := []struct {
+		namespace  string
+		wantRule   string
+		wantAction config.PolicyAction
+	}{
+		{namespace: "kube-system", wantRule: "ignore-system", wantAction: config.PolicyIgnore},
+		{namespace: "default", wantRule: "alert-all", wantAction: config.PolicyAlert},
+	}
+
+	for _, tt := range tests {
+		decision := e.Evaluate(Input{Namespace: tt.namespace})
+		if decision.Rule != tt.wantRule || decision.Action != tt.wantAction {
+			t.Errorf("namespace %s: decision = %s/%s, 期望 %s/%s",
+				tt.namespace, decision.Rule, decision.Action, tt.wantRule, tt.wantAction)
+		}
+	}
+}
+
+func TestEvaluateDefaultAndConfirmCount(t *testing.T) {
+	e := newTestEngine(t,
+		config.PolicyRule{Name: "custom", Match: config.PolicyMatch{Namespaces: []string{"a"}}, Action: config.PolicyRemediate, ConfirmCount: 7},
+		config.PolicyRule{Name: "inherit", Match: config.PolicyMatch{Namespaces: []string{"b"}}, Action: config.PolicyRemediate},
+	)
+
+	if d := e.Evaluate(Input{Namespace: "a"}); d.ConfirmCount != 7 {
+		t.Errorf("规则指定的确认次数 = %d, 期望7", d.ConfirmCount)
+	}
+	if d := e.Evaluate(Input{Namespace: "b"}); d.ConfirmCount != 3 {
+		t.Errorf("规则未指定时确认次数 = %d, 期望回退到全局3", d.ConfirmCount)
+	}
+
+	d := e.Evaluate(Input{Namespace: "other"})
+	if d.Rule != DefaultRuleName || d.Action != config.PolicyRemediate || d.ConfirmCount != 3 {
+		t.Errorf("默认决策 = %+v", d)
+	}
+	if !reflect.DeepEqual(d.Steps, testSteps()) {
+		t.Errorf("默认决策应使用全局处置阶梯: %+v", d.Steps)
+	}
+}
+
+func TestSelectSteps(t *testing.T) {
+	all := testSteps()
+	got := selectSteps(all, []config.RemediationAction{config.ActionRemoveContainer, config.ActionSignalParent})
+
+	if len(got) != len(all) {
+		t.Fatalf("selectSteps返回%d个步骤, 期望%d个", len(got), len(all))
+	}
+	wantEnabled := map[config.RemediationAction]bool{
+		config.ActionSigchldParent:   false,
+		config.ActionSignalParent:    true,
+		config.ActionStopContainer:   false,
+		config.ActionRemoveContainer: true,
+	}
+	for i, step := range got {
+		// 保持全局阶梯的顺序和超时
+		if step.Action != all[i].Action || step.Timeout != all[i].Timeout || step.Signal != all[i].Signal {
+			t.Errorf("步骤%d = %+v, 期望保留全局配置 %+v", i, step, all[i])
+		}
+		if step.Enabled != wantEnabled[step.Action] {
+			t.Errorf("步骤%s Enabled = %v, 期望 %v", step.Action, step.Enabled, wantEnabled[step.Action])
+		}
+	}
+	// 不能修改全局阶梯
+	if !reflect.DeepEqual(all, testSteps()) {
+		t.Error("selectSteps修改了全局处置阶梯")
+	}
+}
+
+func TestNewRejectsInvalidRegex(t *testing.T) {
+	_, err := New(&config.CleanerConfig{
+		ConfirmCount: 3,
+		Policy: config.PolicyConfig{Rules: []config.PolicyRule{
+			{Name: "bad", Action: config.PolicyAlert, Match: config.PolicyMatch{Images: []string{"("}}},
+		}},
+	})
+	if err == nil {
+		t.Error("无效的镜像正则表达式应返回错误")
+	}
+}
+
+func TestEvaluateMissingReaper(t *testing.T) {
+	remediate := Decision{Rule: "web", Action: config.PolicyRemediate, ConfirmCount: 5, Steps: testSteps()}
+	alert := Decision{Rule: "legacy", Action: config.PolicyAlert, ConfirmCount: 3, Steps: testSteps()}
+	// 规则只允许发送SIGCHLD
+	restricted := Decision{Rule: "db", Action: config.PolicyRemediate, ConfirmCount: 3,
+		Steps: selectSteps(testSteps(), []config.RemediationAction{config.ActionSigchldParent})}
+
+	tests := []struct {
+		name       string
+		orphans    config.OrphanPolicy
+		decision   Decision
+		wantRule   string
+		wantAction config.PolicyAction
+		// 期望启用的处置步骤
+		wantSteps []config.RemediationAction
+	}{
+		{
+			name:       "默认只告警",
+			orphans:    config.OrphanPolicy{Action: config.PolicyAlert},
+			decision:   remediate,
+			wantRule:   MissingReaperRuleName,
+			wantAction: config.PolicyAlert,
+			wantSteps:  []config.RemediationAction{config.ActionSigchldParent, config.ActionStopContainer, config.ActionRemoveContainer},
+		},
+		{
+			name:       "按孤儿策略的步骤处置",
+			orphans:    config.OrphanPolicy{Action: config.PolicyRemediate, RemediationSteps: []config.RemediationAction{config.ActionStopContainer}},
+			decision:   remediate,
+			wantRule:   MissingReaperRuleName,
+			wantAction: config.PolicyRemediate,
+			wantSteps:  []config.RemediationAction{config.ActionStopContainer},
+		},
+		{
+			name:       "未配置孤儿策略步骤时沿用规则限制的步骤",
+			orphans:    config.OrphanPolicy{Action: config.PolicyRemediate},
+			decision:   restricted,
+			wantRule:   MissingReaperRuleName,
+			wantAction: config.PolicyRemediate,
+			wantSteps:  []config.RemediationAction{config.ActionSigchldParent},
+		},
+		{
+			name:       "孤儿策略步骤不超出规则允许的步骤",
+			orphans:    config.OrphanPolicy{Action: config.PolicyRemediate, RemediationSteps: []config.RemediationAction{config.ActionSigchldParent, config.ActionStopContainer}},
+			decision:   restricted,
+			wantRule:   MissingReaperRuleName,
+			wantAction: config.PolicyRemediate,
+			wantSteps:  []config.RemediationAction{config.ActionSigchldParent},
+		},
+		{
+			name:       "不超过原决策允许的范围",
+			orphans:    config.OrphanPolicy{Action: config.PolicyRemediate},
+			decision:   alert,
+			wantRule:   "legacy",
+			wantAction: config.PolicyAlert,
+			wantSteps:  []config.RemediationAction{config.ActionSigchldParent, config.ActionStopContainer, config.ActionRemoveContainer},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := New(&config.CleanerConfig{ConfirmCount: 3, RemediationSteps: testSteps(), Orphans: tt.orphans})
+			if err != nil {
+				t.Fatalf("编译策略失败: %v", err)
+			}
+			got := e.EvaluateMissingReaper(tt.decision)
+			if got.Rule != tt.wantRule || got.Action != tt.wantAction || got.ConfirmCount != tt.decision.ConfirmCount {
+				t.Errorf("决策 = %s/%s/%d, 期望 %s/%s/%d", got.Rule, got.Action, got.ConfirmCount, tt.wantRule, tt.wantAction, tt.decision.ConfirmCount)
+			}
+			var enabled []config.RemediationAction
+			for _, step := range got.Steps {
+				if step.Enabled {
+					enabled = append(enabled, step.Action)
+				}
+			}
+			if !reflect.DeepEqual(enabled, tt.wantSteps) {
+				t.Errorf("启用的步骤 = %v, 期望 %v", enabled, tt.wantSteps)
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+//go:build linux
+
+package process
+
+import (
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+func pidfdOpen(pid int) (int, error) {
+	return unix.PidfdOpen(pid, 0)
+}
+
+func pidfdSendSignal(fd int, sig syscall.Signal) error {
+	return unix.PidfdSendSignal(fd, sig, nil, 0)
+}
+
+func closeFD(fd int) error {
+	return unix.Close(fd)
+}
@@ -0,0 +1,134 @@
+//go:build linux
+
+package process
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// startSleep 启动一个子进程，返回其进程标识和等待退出的通道
+func startSleep(t *testing.T) (Ref, <-chan error) {
+	t.Helper()
+	cmd := exec.Command("sleep", "60")
+	if err := cmd.Start(); err != nil {
+		t.Skipf("无法启动sleep: %v", err)
+	}
+	t.Cleanup(func() { cmd.Process.Kill() })
+	stat, err := ReadStat(DefaultRoot, cmd.Process.Pid)
+	if err != nil {
+		t.Fatalf("读取子进程stat失败: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	return Ref{PID: cmd.Process.Pid, StartTime: stat.StartTime}, done
+}
+
+func waitSignaled(t *testing.T, done <-chan error, want syscall.Signal) {
+	t.Helper()
+	select {
+	case err := <-done:
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			t.Fatalf("子进程退出结果 = %v, 期望被信号终止", err)
+		}
+		if status := exitErr.Sys().(syscall.WaitStatus); !status.Signaled() || status.Signal() != want {
+			t.Fatalf("子进程退出状态 = %v, 期望被%s终止", status, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("子进程未退出")
+	}
+}
+
+func TestSignal(t *testing.T) {
+	tests := []struct {
+		name string
+		// 模拟不支持pidfd的内核
+		noPidfd bool
+	}{
+		{name: "pidfd"},
+		{name: "退回kill", noPidfd: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.noPidfd {
+				openPidfd = func(int) (int, error) { return -1, syscall.ENOSYS }
+				t.Cleanup(func() { openPidfd = pidfdOpen })
+			}
+
+			ref, done := startSleep(t)
+
+			// 启动时间不一致说明PID已被复用，不能发送信号
+			stale := Ref{PID: ref.PID, StartTime: ref.StartTime + 1}
+			if err := Signal(DefaultRoot, stale, syscall.SIGTERM); !errors.Is(err, ErrGone) {
+				t.Fatalf("启动时间不一致时Signal() = %v, 期望ErrGone", err)
+			}
+			select {
+			case <-done:
+				t.Fatal("启动时间不一致时不应向进程发送信号")
+			case <-time.After(50 * time.Millisecond):
+			}
+
+			// 按传入的proc文件系统校验启动时间
+			root := t.TempDir()
+			dir := filepath.Join(root, strconv.Itoa(ref.PID))
+			os.MkdirAll(dir, 0o755)
+			stat := fmt.Sprintf("%d (sleep) S 1 1 1 0 -1 0 0 0 0 0 0 0 0 0 20 0 1 0 %d 0 0\n", ref.PID, ref.StartTime+1)
+			os.WriteFile(filepath.Join(dir, "stat"), []byte(stat), 0o644)
+			if err := Signal(root, ref, syscall.SIGTERM); !errors.Is(err, ErrGone) {
+				t.Fatalf("root中的启动时间不一致时Signal() = %v, 期望ErrGone", err)
+			}
+
+			// 缺少启动时间时只允许发送SIGCHLD
+			unverified := Ref{PID: ref.PID}
+			if err := Signal(DefaultRoot, unverified, syscall.SIGKILL); !errors.Is(err, ErrUnverified) {
+				t.Fatalf("缺少启动时间时Signal(SIGKILL) = %v, 期望ErrUnverified", err)
+			}
+			if err := Signal(DefaultRoot, unverified, syscall.SIGCHLD); err != nil {
+				t.Fatalf("缺少启动时间时Signal(SIGCHLD)返回错误: %v", err)
+			}
+			select {
+			case <-done:
+				t.Fatal("缺少启动时间时不应终止进程")
+			case <-time.After(50 * time.Millisecond):
+			}
+
+			if err := Signal(DefaultRoot, ref, syscall.SIGTERM); err != nil {
+				t.Fatalf("Signal()返回错误: %v", err)
+			}
+			waitSignaled(t, done, syscall.SIGTERM)
+
+			// 进程已被回收
+			if err := Signal(DefaultRoot, ref, syscall.SIGTERM); !errors.Is(err, ErrGone) {
+				t.Errorf("进程退出后Signal() = %v, 期望ErrGone", err)
+			}
+		})
+	}
+}
+
+func TestHandleSurvivesPIDReuse(t *testing.T) {
+	ref, done := startSleep(t)
+	h, err := Open(DefaultRoot, ref)
+	if err != nil {
+		t.Fatalf("Open()返回错误: %v", err)
+	}
+	defer h.Close()
+	if h.pidfd < 0 {
+		t.Skip("内核不支持pidfd")
+	}
+
+	// 进程退出并被回收后，持有的pidfd不会指向复用该PID的新进程
+	syscall.Kill(ref.PID, syscall.SIGKILL)
+	waitSignaled(t, done, syscall.SIGKILL)
+	if err := h.Signal(syscall.SIGTERM); !errors.Is(err, ErrGone) {
+		t.Errorf("进程退出后Handle.Signal() = %v, 期望ErrGone", err)
+	}
+}
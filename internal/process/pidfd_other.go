@@ -0,0 +1,18 @@
+//go:build !linux
+
+package process
+
+import "syscall"
+
+// pidfd仅在Linux上可用，其他平台退回校验启动时间后kill
+func pidfdOpen(pid int) (int, error) {
+	return -1, syscall.ENOSYS
+}
+
+func pidfdSendSignal(fd int, sig syscall.Signal) error {
+	return syscall.ENOSYS
+}
+
+func closeFD(fd int) error {
+	return nil
+}
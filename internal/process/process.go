@@ -0,0 +1,165 @@
+package process
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// DefaultRoot 宿主机的proc文件系统
+const DefaultRoot = "/proc"
+
+// ErrGone 进程已退出或PID已被其他进程复用
+var ErrGone = errors.New("进程已退出或PID已被复用")
+
+// ErrUnverified 缺少启动时间，无法确认PID对应的仍是检测时的进程
+var ErrUnverified = errors.New("缺少启动时间，无法确认进程身份")
+
+// Ref 进程标识。PID可能在检测到处置之间被复用，与启动时间一起才能唯一确定一个进程
+type Ref struct {
+	PID int
+	// /proc/<pid>/stat中的starttime（开机后的时钟周期数），为0时无法校验，只允许发送无害的SIGCHLD
+	StartTime uint64
+}
+
+// Stat /proc/<pid>/stat中用到的字段
+type Stat struct {
+	PID       int
+	PPID      int
+	Comm      string
+	State     string
+	StartTime uint64
+}
+
+// ReadStat 解析root/<pid>/stat
+func ReadStat(root string, pid int) (Stat, error) {
+	path := filepath.Join(root, strconv.Itoa(pid), "stat")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Stat{}, err
+	}
+	// comm可能包含空格和括号，以第一个'('和最后一个')'为界
+	stat := string(data)
+	lparen, rparen := strings.IndexByte(stat, '('), strings.LastIndexByte(stat, ')')
+	if lparen < 0 || rparen < lparen {
+		return Stat{}, fmt.Errorf("无法解析 %s", path)
+	}
+	// 从state（第3个字段）开始，starttime为第22个字段
+	fields := strings.Fields(stat[rparen+1:])
+	if len(fields) < 20 {
+		return Stat{}, fmt.Errorf("无法解析 %s", path)
+	}
+	ppid, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return Stat{}, fmt.Errorf("无法解析 %s: %w", path, err)
+	}
+	startTime, err := strconv.ParseUint(fields[19], 10, 64)
+	if err != nil {
+		return Stat{}, fmt.Errorf("无法解析 %s: %w", path, err)
+	}
+	return Stat{
+		PID:       pid,
+		PPID:      ppid,
+		Comm:      stat[lparen+1 : rparen],
+		State:     fields[0],
+		StartTime: startTime,
+	}, nil
+}
+
+// Handle 已确认身份的进程句柄。内核支持pidfd时持有pidfd，进程退出后PID被复用也不会误伤其他进程；
+// 否则在每次发送信号前重新校验启动时间
+type Handle struct {
+	// 校验启动时间时读取的proc文件系统
+	root string
+	ref  Ref
+	// 不支持pidfd时为-1
+	pidfd int
+}
+
+// openPidfd 便于测试模拟不支持pidfd的内核
+var openPidfd = pidfdOpen
+
+// Open 打开ref对应的进程句柄，按root下的stat校验启动时间，进程已退出或启动时间不一致时返回ErrGone
+func Open(root string, ref Ref) (*Handle, error) {
+	fd, err := openPidfd(ref.PID)
+	switch {
+	case err == nil:
+	case errors.Is(err, syscall.ESRCH):
+		return nil, fmt.Errorf("进程%d: %w", ref.PID, ErrGone)
+	case errors.Is(err, syscall.ENOSYS), errors.Is(err, syscall.EPERM), errors.Is(err, syscall.EINVAL):
+		// 内核早于5.3或被seccomp禁止，退回校验启动时间后kill
+		fd = -1
+	default:
+		return nil, fmt.Errorf("打开进程%d的pidfd失败: %w", ref.PID, err)
+	}
+
+	h := &Handle{root: root, ref: ref, pidfd: fd}
+	// 先打开pidfd再校验：校验通过说明pidfd指向的就是检测时的进程
+	if err := h.verify(); err != nil {
+		h.Close()
+		return nil, err
+	}
+	return h, nil
+}
+
+// verify 校验PID对应的仍是ref中的进程
+func (h *Handle) verify() error {
+	if h.ref.StartTime == 0 {
+		return nil
+	}
+	stat, err := ReadStat(h.root, h.ref.PID)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("进程%d: %w", h.ref.PID, ErrGone)
+	}
+	if err != nil {
+		return err
+	}
+	if stat.StartTime != h.ref.StartTime {
+		return fmt.Errorf("进程%d的启动时间为%d，期望%d: %w", h.ref.PID, stat.StartTime, h.ref.StartTime, ErrGone)
+	}
+	return nil
+}
+
+// Signal 向进程发送信号。无法确认进程身份时拒绝发送SIGCHLD以外的信号，避免误杀复用了PID的进程
+func (h *Handle) Signal(sig syscall.Signal) error {
+	if h.ref.StartTime == 0 && sig != syscall.SIGCHLD {
+		return fmt.Errorf("拒绝向进程%d发送%s: %w", h.ref.PID, sig, ErrUnverified)
+	}
+	var err error
+	if h.pidfd >= 0 {
+		err = pidfdSendSignal(h.pidfd, sig)
+	} else {
+		if err := h.verify(); err != nil {
+			return err
+		}
+		err = syscall.Kill(h.ref.PID, sig)
+	}
+	if errors.Is(err, syscall.ESRCH) {
+		return fmt.Errorf("进程%d: %w", h.ref.PID, ErrGone)
+	}
+	return err
+}
+
+// Close 释放pidfd
+func (h *Handle) Close() error {
+	if h.pidfd < 0 {
+		return nil
+	}
+	err := closeFD(h.pidfd)
+	h.pidfd = -1
+	return err
+}
+
+// Signal 打开进程句柄并发送一次信号
+func Signal(root string, ref Ref, sig syscall.Signal) error {
+	h, err := Open(root, ref)
+	if err != nil {
+		return err
+	}
+	defer h.Close()
+	return h.Signal(sig)
+}
@@ -0,0 +1,53 @@
+package process
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadStat(t *testing.T) {
+	tests := []struct {
+		name    string
+		stat    string
+		want    Stat
+		wantErr bool
+	}{
+		{
+			name: "普通进程",
+			stat: "42 (nginx) S 1 42 42 0 -1 4194560 0 0 0 0 0 0 0 0 20 0 1 0 123456 0 0\n",
+			want: Stat{PID: 42, PPID: 1, Comm: "nginx", State: "S", StartTime: 123456},
+		},
+		{
+			name: "comm包含空格和括号",
+			stat: "42 (a) b (c)) Z 7 42 42 0 -1 4194560 0 0 0 0 0 0 0 0 20 0 1 0 99 0 0\n",
+			want: Stat{PID: 42, PPID: 7, Comm: "a) b (c)", State: "Z", StartTime: 99},
+		},
+		{
+			name:    "字段不足",
+			stat:    "42 (nginx) S 1 42\n",
+			wantErr: true,
+		},
+		{
+			name:    "缺少comm",
+			stat:    "42 nginx S 1\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			os.MkdirAll(filepath.Join(root, "42"), 0o755)
+			os.WriteFile(filepath.Join(root, "42", "stat"), []byte(tt.stat), 0o644)
+
+			got, err := ReadStat(root, 42)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadStat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ReadStat() = %+v, 期望 %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package runtime
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/tiggoins/zombie-cleaner/internal/config"
+	"github.com/tiggoins/zombie-cleaner/internal/logger"
+)
+
+// socketProbe 自动探测时尝试的一个运行时
+type socketProbe struct {
+	name string
+	// socket为空时不检查文件，直接尝试连接（如DOCKER_HOST指向TCP地址）
+	socket string
+	// connect 连接运行时并确认其可用，recorder用于记录inspect超时的容器
+	connect func(recorder timeoutRecorder) (ContainerRuntimeInterface, error)
+}
+
+// NewAutoRuntime 探测cfg中配置的Docker、containerd、CRI-O以及cri.endpoint指定的CRI运行时，
+// 组合所有可用的运行时。同一个socket只使用第一个连接成功的运行时，
+// 如containerd原生接口可用时不再通过CRI连接同一个containerd
+func NewAutoRuntime(log *logger.Logger, cfg config.RuntimesConfig, timeout time.Duration, procRoot string, detector interface {
+	RecordTimeoutContainer(containerID string)
+}) (*MultiRuntime, error) {
+	probes := []socketProbe{
+		{
+			name:   NameDocker,
+			socket: endpointSocket(dockerHost(cfg.Docker)),
+			connect: func(recorder timeoutRecorder) (ContainerRuntimeInterface, error) {
+				return NewDockerRuntime(log, cfg.Docker, timeout, procRoot, recorder)
+			},
+		},
+		{
+			name:   NameContainerd,
+			socket: endpointSocket(cfg.Containerd.Address),
+			connect: func(recorder timeoutRecorder) (ContainerRuntimeInterface, error) {
+				return NewContainerdRuntime(log, cfg.Containerd, timeout, procRoot, recorder)
+			},
+		},
+		{
+			name:   NameCRIO,
+			socket: endpointSocket(cfg.CRIO.Endpoint),
+			connect: func(recorder timeoutRecorder) (ContainerRuntimeInterface, error) {
+				return NewCRIRuntime(log, cfg.CRIO, timeout, procRoot, recorder)
+			},
+		},
+	}
+	if cfg.CRI.Endpoint != "" {
+		probes = append(probes, socketProbe{
+			name:   NameCRI,
+			socket: endpointSocket(cfg.CRI.Endpoint),
+			connect: func(recorder timeoutRecorder) (ContainerRuntimeInterface, error) {
+				return NewCRIRuntime(log, cfg.CRI, timeout, procRoot, recorder)
+			},
+		})
+	}
+	return probeRuntimes(probes, log, detector)
+}
+
+// probeRuntimes 依次尝试probes，把可用的运行时加入MultiRuntime
+func probeRuntimes(probes []socketProbe, log *logger.Logger, detector timeoutRecorder) (*MultiRuntime, error) {
+	multi := NewMultiRuntime(log, detector)
+	used := make(map[string]bool)
+	for _, probe := range probes {
+		if probe.socket != "" {
+			if used[probe.socket] {
+				multi.logger.Debug("socket已被其他运行时使用，跳过", "runtime", probe.name, "socket", probe.socket)
+				continue
+			}
+			if !isSocket(probe.socket) {
+				multi.logger.Debug("未发现运行时socket", "runtime", probe.name, "socket", probe.socket)
+				continue
+			}
+		}
+
+		rt, err := probe.connect(multi.Recorder(probe.name))
+		if err != nil {
+			multi.logger.Warn("发现运行时socket但无法连接", "runtime", probe.name, "socket", probe.socket, "error", err)
+			continue
+		}
+		multi.Add(probe.name, rt)
+		if probe.socket != "" {
+			used[probe.socket] = true
+		}
+		multi.logger.Info("发现容器运行时", "runtime", probe.name, "socket", probe.socket)
+	}
+
+	if len(multi.names) == 0 {
+		return nil, errors.New("未发现可用的容器运行时")
+	}
+	return multi, nil
+}
+
+// endpointSocket 返回endpoint对应的unix socket路径，非unix地址返回空
+func endpointSocket(endpoint string) string {
+	if path, ok := strings.CutPrefix(endpoint, "unix://"); ok {
+		return path
+	}
+	if strings.Contains(endpoint, "://") {
+		return ""
+	}
+	return endpoint
+}
+
+func isSocket(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode()&os.ModeSocket != 0
+}
@@ -4,59 +4,110 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"os/exec"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/containers"
 	"github.com/containerd/containerd/namespaces"
+	"github.com/tiggoins/zombie-cleaner/internal/config"
 	"github.com/tiggoins/zombie-cleaner/internal/logger"
 )
 
+const (
+	// containerd CRI插件写入容器的标签
+	containerdLabelKind   = "io.cri-containerd.kind"
+	containerdLabelPodUID = "io.kubernetes.pod.uid"
+	containerdKindSandbox = "sandbox"
+)
+
 // ContainerdRuntime Containerd运行时实现
 type ContainerdRuntime struct {
-	client   *containerd.Client
-	logger   *logger.Logger
-	timeout  time.Duration
-	detector interface {
+	client      *containerd.Client
+	logger      *logger.Logger
+	timeout     time.Duration
+	dialTimeout time.Duration
+	address     string
+	shims       *ShimResolver
+	// 扫描的containerd命名空间，如k8s.io（Kubernetes）和moby（Docker）
+	namespaces []string
+	detector   interface {
 		RecordTimeoutContainer(containerID string)
 	}
 }
 
-// NewContainerdRuntime 创建Containerd运行时实例
-func NewContainerdRuntime(log *logger.Logger, timeout time.Duration, detector interface {
+// NewContainerdRuntime 创建Containerd运行时实例并确认守护进程可用
+func NewContainerdRuntime(log *logger.Logger, cfg config.ContainerdRuntimeConfig, timeout time.Duration, procRoot string, detector interface {
 	RecordTimeoutContainer(containerID string)
 }) (*ContainerdRuntime, error) {
-	cli, err := containerd.New("/run/containerd/containerd.sock")
+	cli, err := containerd.New(cfg.Address, containerd.WithTimeout(cfg.DialTimeout))
 	if err != nil {
-		return nil, fmt.Errorf("无法连接containerd守护进程: %w", err)
+		return nil, fmt.Errorf("无法连接containerd守护进程 %s: %w", cfg.Address, err)
 	}
 
-	return &ContainerdRuntime{
-		client:   cli,
-		logger:   log.WithComponent("containerd-runtime"),
-		timeout:  timeout,
-		detector: detector,
-	}, nil
+	c := &ContainerdRuntime{
+		client:      cli,
+		logger:      log.WithComponent("containerd-runtime"),
+		timeout:     timeout,
+		dialTimeout: cfg.DialTimeout,
+		address:     cfg.Address,
+		shims:       NewShimResolver(log, procRoot),
+		namespaces:  cfg.Namespaces,
+		detector:    detector,
+	}
+	if err := c.Ping(context.Background()); err != nil {
+		cli.Close()
+		return nil, fmt.Errorf("无法连接containerd守护进程 %s: %w", cfg.Address, err)
+	}
+	return c, nil
 }
 
-// ListContainers 列出所有Containerd容器
+// Ping 检查containerd守护进程是否可用
+func (c *ContainerdRuntime) Ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, c.dialTimeout)
+	defer cancel()
+	_, err := c.client.Version(ctx)
+	return err
+}
+
+// ListContainers 列出所有配置的命名空间中的Containerd容器。
+// 部分命名空间失败时记录日志并返回其余命名空间的容器，全部失败时返回错误
 func (c *ContainerdRuntime) ListContainers(ctx context.Context) ([]ContainerMeta, error) {
-	// 使用k8s.io命名空间
-	nsCtx := namespaces.WithNamespace(ctx, "k8s.io")
+	var (
+		result []ContainerMeta
+		errs   []error
+	)
+	for _, ns := range c.namespaces {
+		containers, err := c.listNamespace(ctx, ns)
+		if err != nil {
+			c.logger.Warn("获取Containerd命名空间的容器列表失败", "namespace", ns, "error", err)
+			errs = append(errs, err)
+			continue
+		}
+		result = append(result, containers...)
+	}
+	if len(errs) > 0 && len(errs) == len(c.namespaces) {
+		return nil, fmt.Errorf("获取Containerd容器列表失败: %w", errors.Join(errs...))
+	}
+	return result, nil
+}
+
+// listNamespace 列出命名空间ns中运行的容器
+func (c *ContainerdRuntime) listNamespace(ctx context.Context, ns string) ([]ContainerMeta, error) {
+	nsCtx := namespaces.WithNamespace(ctx, ns)
 
 	containers, err := c.client.Containers(nsCtx)
 	if err != nil {
-		return nil, fmt.Errorf("获取Containerd容器列表失败: %w", err)
+		return nil, fmt.Errorf("命名空间%s: %w", ns, err)
 	}
 
-	var result []ContainerMeta
+	var (
+		result    []ContainerMeta
+		podLabels = make(map[string]map[string]string)
+	)
 	for _, container := range containers {
-		// 为每个容器设置超时
-		inspectCtx, cancel := context.WithTimeout(nsCtx, c.timeout)
-		info, err := container.Info(inspectCtx)
-		cancel()
-		
+		info, containerPID, comm, err := c.inspect(nsCtx, container)
 		if err != nil {
 			// 检查是否是超时错误
 			if errors.Is(err, context.DeadlineExceeded) {
@@ -71,33 +122,27 @@ func (c *ContainerdRuntime) ListContainers(ctx context.Context) ([]ContainerMeta
 			continue
 		}
 
-		// 获取容器任务以获取PID
-		task, err := container.Task(inspectCtx, nil)
-		if err != nil {
-			// 容器可能没有运行的任务
-			continue
+		// containerd CRI把Pod标签写在sandbox容器上，按Pod UID记录
+		if info.Labels[containerdLabelKind] == containerdKindSandbox {
+			if uid := info.Labels[containerdLabelPodUID]; uid != "" {
+				podLabels[uid] = info.Labels
+			}
 		}
 
-		containerPID := int(task.Pid())
 		if containerPID <= 0 {
 			continue // 容器未运行
 		}
 
-		// 获取容器命令
-		var comm string
-		spec, err := container.Spec(inspectCtx)
-		if err == nil && spec != nil && spec.Process != nil {
-			comm = strings.Join(spec.Process.Args, " ")
-		}
-
 		// 注意：这里不构建PID树，因为这部分逻辑在detector中处理
 		
 		containerMeta := ContainerMeta{
-			ID:        container.ID()[:12], // 短ID
+			// containerd不支持按ID前缀查找，必须保留完整ID供停止/删除使用
+			ID:        container.ID(),
 			PID:       containerPID,
 			Comm:      comm,
 			PIDSet:    make(map[int]bool), // 在detector中填充
 			CreatedAt: info.CreatedAt,
+			Runtime:   NameContainerd,
 		}
 
 		// 解析Pod信息
@@ -114,12 +159,102 @@ func (c *ContainerdRuntime) ListContainers(ctx context.Context) ([]ContainerMeta
 			containerMeta.PodNS = "default"
 		}
 
+		containerMeta.PodUID = labels[containerdLabelPodUID]
+		containerMeta.Image = info.Image
+		containerMeta.Labels = labels
+
 		result = append(result, containerMeta)
 	}
 
+	// sandbox可能排在业务容器之后，列举完成后再合并Pod标签
+	for i := range result {
+		result[i].Labels = mergeLabels(result[i].Labels, podLabels[result[i].PodUID])
+	}
+
 	return result, nil
 }
 
+// inspect 在超时时间内获取容器信息、init进程PID和命令，容器没有运行的任务时PID为0
+func (c *ContainerdRuntime) inspect(ctx context.Context, container containerd.Container) (containers.Container, int, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	info, err := container.Info(ctx)
+	if err != nil {
+		return info, 0, "", err
+	}
+
+	task, err := container.Task(ctx, nil)
+	if err != nil {
+		// 容器可能没有运行的任务
+		return info, 0, "", nil
+	}
+
+	var comm string
+	spec, err := container.Spec(ctx)
+	if err == nil && spec != nil && spec.Process != nil {
+		comm = strings.Join(spec.Process.Args, " ")
+	}
+	return info, int(task.Pid()), comm, nil
+}
+
+// loadContainer 在配置的命名空间中查找容器，返回带容器所在命名空间的context
+func (c *ContainerdRuntime) loadContainer(ctx context.Context, containerID string) (context.Context, containerd.Container, error) {
+	var errs []error
+	for _, ns := range c.namespaces {
+		nsCtx := namespaces.WithNamespace(ctx, ns)
+		container, err := c.client.LoadContainer(nsCtx, containerID)
+		if err == nil {
+			return nsCtx, container, nil
+		}
+		errs = append(errs, fmt.Errorf("命名空间%s: %w", ns, err))
+	}
+	return nil, nil, fmt.Errorf("无法加载Containerd容器: %w", errors.Join(errs...))
+}
+
+// StopContainer 优雅停止Containerd容器：先发送SIGTERM，超时后发送SIGKILL
+func (c *ContainerdRuntime) StopContainer(ctx context.Context, containerID string, timeout time.Duration) error {
+	c.logger.Info("尝试停止Containerd容器", "container_id", containerID)
+
+	nsCtx, container, err := c.loadContainer(ctx, containerID)
+	if err != nil {
+		return err
+	}
+
+	task, err := container.Task(nsCtx, nil)
+	if err != nil {
+		return fmt.Errorf("无法获取Containerd容器任务: %w", err)
+	}
+
+	exitCh, err := task.Wait(nsCtx)
+	if err != nil {
+		return fmt.Errorf("无法等待Containerd容器任务: %w", err)
+	}
+
+	if err := task.Kill(nsCtx, syscall.SIGTERM); err != nil {
+		return fmt.Errorf("发送SIGTERM失败: %w", err)
+	}
+
+	select {
+	case <-exitCh:
+	case <-time.After(timeout):
+		c.logger.Warn("Containerd容器未在超时时间内退出，发送SIGKILL", "container_id", containerID)
+		if err := task.Kill(nsCtx, syscall.SIGKILL); err != nil {
+			return fmt.Errorf("发送SIGKILL失败: %w", err)
+		}
+		select {
+		case <-exitCh:
+		case <-ctx.Done():
+			return fmt.Errorf("等待Containerd容器退出超时: %w", ctx.Err())
+		}
+	case <-ctx.Done():
+		return fmt.Errorf("等待Containerd容器退出超时: %w", ctx.Err())
+	}
+
+	c.logger.Info("成功停止Containerd容器", "container_id", containerID)
+	return nil
+}
+
 // RemoveContainer 删除Containerd容器
 func (c *ContainerdRuntime) RemoveContainer(ctx context.Context, containerID string, timeout time.Duration) error {
 	// 设置超时
@@ -128,13 +263,10 @@ func (c *ContainerdRuntime) RemoveContainer(ctx context.Context, containerID str
 
 	c.logger.Info("尝试删除Containerd容器", "container_id", containerID)
 
-	// 使用k8s.io命名空间
-	nsCtx := namespaces.WithNamespace(timeoutCtx, "k8s.io")
-
-	// 获取容器
-	container, err := c.client.LoadContainer(nsCtx, containerID)
+	// 在配置的命名空间中查找容器
+	nsCtx, container, err := c.loadContainer(timeoutCtx, containerID)
 	if err != nil {
-		return fmt.Errorf("无法加载Containerd容器: %w", err)
+		return err
 	}
 
 	// 获取任务
@@ -168,31 +300,9 @@ func (c *ContainerdRuntime) RecordTimeoutContainer(containerID string) {
 	}
 }
 
-// KillContainerShim 杀死容器的shim进程
-func (c *ContainerdRuntime) KillContainerShim(containerID string) error {
-	c.logger.Info("尝试kill containerd-shim", "container_id", containerID)
-
-	// 查找containerd-shim进程
-	pattern := fmt.Sprintf("containerd-shim.*%s", containerID[:12])
-	cmd := exec.Command("pgrep", "-f", pattern)
-	output, err := cmd.Output()
-	if err != nil {
-		// 如果找不到进程，记录日志但不返回错误
-		c.logger.Debug("查找containerd-shim进程失败", "pattern", pattern, "error", err)
-		return nil
-	}
-
-	pids := strings.Fields(strings.TrimSpace(string(output)))
-	for _, pid := range pids {
-		killCmd := exec.Command("kill", "-9", pid)
-		if err := killCmd.Run(); err != nil {
-			c.logger.Warn("kill containerd-shim进程失败", "pid", pid, "error", err)
-		} else {
-			c.logger.Info("成功kill containerd-shim进程", "pid", pid)
-		}
-	}
-
-	return nil
+// KillContainerShim 清理容器的containerd-shim进程
+func (c *ContainerdRuntime) KillContainerShim(ctx context.Context, container ContainerMeta, opts ShimKillOptions) error {
+	return c.shims.KillShim(ctx, container, c.address, opts)
 }
 
 // Close 关闭Containerd客户端连接
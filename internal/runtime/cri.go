@@ -0,0 +1,321 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+
+	"github.com/tiggoins/zombie-cleaner/internal/config"
+	"github.com/tiggoins/zombie-cleaner/internal/logger"
+)
+
+const (
+	// kubelet写入CRI容器的Pod标签
+	criLabelPodName      = "io.kubernetes.pod.name"
+	criLabelPodNamespace = "io.kubernetes.pod.namespace"
+	criLabelPodUID       = "io.kubernetes.pod.uid"
+
+	// CRI消息大小上限，与kubelet保持一致
+	criMaxMsgSize = 16 * 1024 * 1024
+)
+
+// CRIRuntime 基于Kubernetes CRI RuntimeService的运行时实现，
+// 适用于containerd、CRI-O等任意兼容CRI的运行时
+type CRIRuntime struct {
+	conn        *grpc.ClientConn
+	client      runtimeapi.RuntimeServiceClient
+	logger      *logger.Logger
+	timeout     time.Duration
+	dialTimeout time.Duration
+	endpoint    string
+	shims       *ShimResolver
+	detector    interface {
+		RecordTimeoutContainer(containerID string)
+	}
+}
+
+// criVerboseInfo ContainerStatus verbose信息中我们关心的字段
+type criVerboseInfo struct {
+	Pid         int `json:"pid"`
+	RuntimeSpec struct {
+		Process *struct {
+			Args []string `json:"args"`
+		} `json:"process"`
+	} `json:"runtimeSpec"`
+}
+
+// NewCRIRuntime 创建CRI运行时实例，endpoint支持"unix:///path"或直接的socket路径
+func NewCRIRuntime(log *logger.Logger, cfg config.CRIRuntimeConfig, timeout time.Duration, procRoot string, detector interface {
+	RecordTimeoutContainer(containerID string)
+}) (*CRIRuntime, error) {
+	endpoint := cfg.Endpoint
+	target := endpoint
+	if !strings.Contains(target, "://") {
+		target = "unix://" + target
+	}
+
+	dialCtx, cancel := context.WithTimeout(context.Background(), cfg.DialTimeout)
+	defer cancel()
+
+	conn, err := grpc.DialContext(dialCtx, target,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(criMaxMsgSize)),
+		grpc.WithBlock(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("无法连接CRI运行时 %s: %w", endpoint, err)
+	}
+
+	r := &CRIRuntime{
+		conn:        conn,
+		client:      runtimeapi.NewRuntimeServiceClient(conn),
+		logger:      log.WithComponent("cri-runtime"),
+		timeout:     timeout,
+		dialTimeout: cfg.DialTimeout,
+		endpoint:    endpoint,
+		shims:       NewShimResolver(log, procRoot),
+		detector:    detector,
+	}
+
+	// 通过Version确认对端确实实现了CRI RuntimeService
+	version, err := r.version(context.Background())
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("CRI运行时版本检查失败: %w", err)
+	}
+	r.logger.Info("已连接CRI运行时",
+		"endpoint", endpoint,
+		"runtime_name", version.RuntimeName,
+		"runtime_version", version.RuntimeVersion)
+
+	return r, nil
+}
+
+func (r *CRIRuntime) version(ctx context.Context) (*runtimeapi.VersionResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.dialTimeout)
+	defer cancel()
+	return r.client.Version(ctx, &runtimeapi.VersionRequest{})
+}
+
+// Ping 检查CRI运行时是否可用
+func (r *CRIRuntime) Ping(ctx context.Context) error {
+	_, err := r.version(ctx)
+	return err
+}
+
+// ListContainers 列出所有运行中的CRI容器
+func (r *CRIRuntime) ListContainers(ctx context.Context) ([]ContainerMeta, error) {
+	listCtx, cancel := context.WithTimeout(ctx, r.timeout)
+	resp, err := r.client.ListContainers(listCtx, &runtimeapi.ListContainersRequest{
+		Filter: &runtimeapi.ContainerFilter{
+			State: &runtimeapi.ContainerStateValue{State: runtimeapi.ContainerState_CONTAINER_RUNNING},
+		},
+	})
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("获取CRI容器列表失败: %w", err)
+	}
+
+	// Pod标签和注解保存在PodSandbox上
+	sandboxes := r.listPodSandboxes(ctx)
+
+	var result []ContainerMeta
+	for _, container := range resp.Containers {
+		// 为每个容器设置超时
+		inspectCtx, cancel := context.WithTimeout(ctx, r.timeout)
+		statusResp, err := r.client.ContainerStatus(inspectCtx, &runtimeapi.ContainerStatusRequest{
+			ContainerId: container.Id,
+			Verbose:     true,
+		})
+		cancel()
+
+		if err != nil {
+			// 检查是否是超时错误
+			if errors.Is(err, context.DeadlineExceeded) || status.Code(err) == codes.DeadlineExceeded {
+				r.logger.Warn("CRI容器检查超时", "container_id", container.Id)
+				// 记录超时容器
+				if r.detector != nil {
+					r.detector.RecordTimeoutContainer(container.Id)
+				}
+			} else {
+				r.logger.Warn("CRI容器检查失败", "container_id", container.Id, "error", err)
+			}
+			continue
+		}
+
+		verbose, err := r.parseVerboseInfo(statusResp.Info)
+		if err != nil {
+			r.logger.Warn("无法解析CRI容器PID", "container_id", container.Id, "error", err)
+			continue
+		}
+		containerPID := verbose.Pid
+		if containerPID <= 0 {
+			continue // 容器未运行
+		}
+
+		// 与Docker、containerd后端一致，使用容器进程的启动参数
+		var comm string
+		if verbose.RuntimeSpec.Process != nil {
+			comm = strings.Join(verbose.RuntimeSpec.Process.Args, " ")
+		}
+
+		// 注意：这里不构建PID树，因为这部分逻辑在detector中处理
+
+		containerMeta := ContainerMeta{
+			ID:        shortContainerID(container.Id),
+			PID:       containerPID,
+			Comm:      comm,
+			PIDSet:    make(map[int]bool), // 在detector中填充
+			CreatedAt: time.Unix(0, container.CreatedAt),
+			Runtime:   NameCRI,
+		}
+
+		// 解析Pod信息
+		labels := container.Labels
+		if podName, ok := labels[criLabelPodName]; ok {
+			containerMeta.PodName = podName
+		} else {
+			containerMeta.PodName = container.Id
+		}
+
+		if podNS, ok := labels[criLabelPodNamespace]; ok {
+			containerMeta.PodNS = podNS
+		} else {
+			containerMeta.PodNS = "default"
+		}
+
+		containerMeta.PodUID = labels[criLabelPodUID]
+		if container.Image != nil {
+			containerMeta.Image = container.Image.Image
+		}
+		if sandbox, ok := sandboxes[container.PodSandboxId]; ok {
+			containerMeta.Labels = mergeLabels(labels, sandbox.Labels)
+			containerMeta.Annotations = sandbox.Annotations
+		} else {
+			containerMeta.Labels = labels
+		}
+
+		result = append(result, containerMeta)
+	}
+
+	return result, nil
+}
+
+// listPodSandboxes 获取运行中的PodSandbox，失败时返回空索引，不影响容器列举
+func (r *CRIRuntime) listPodSandboxes(ctx context.Context) map[string]*runtimeapi.PodSandbox {
+	listCtx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	resp, err := r.client.ListPodSandbox(listCtx, &runtimeapi.ListPodSandboxRequest{
+		Filter: &runtimeapi.PodSandboxFilter{
+			State: &runtimeapi.PodSandboxStateValue{State: runtimeapi.PodSandboxState_SANDBOX_READY},
+		},
+	})
+	if err != nil {
+		r.logger.Warn("获取CRI PodSandbox列表失败", "error", err)
+		return nil
+	}
+
+	sandboxes := make(map[string]*runtimeapi.PodSandbox, len(resp.Items))
+	for _, sandbox := range resp.Items {
+		sandboxes[sandbox.Id] = sandbox
+	}
+	return sandboxes
+}
+
+// parseVerboseInfo 从ContainerStatus的verbose信息中解析容器init进程PID和启动参数。
+// containerd和CRI-O都会在info["info"]中返回包含pid和runtimeSpec字段的JSON
+func (r *CRIRuntime) parseVerboseInfo(info map[string]string) (*criVerboseInfo, error) {
+	raw, ok := info["info"]
+	if !ok || raw == "" {
+		return nil, fmt.Errorf("verbose信息中缺少info字段")
+	}
+
+	var verbose criVerboseInfo
+	if err := json.Unmarshal([]byte(raw), &verbose); err != nil {
+		return nil, fmt.Errorf("解析verbose信息失败: %w", err)
+	}
+	return &verbose, nil
+}
+
+// StopContainer 优雅停止CRI容器
+func (r *CRIRuntime) StopContainer(ctx context.Context, containerID string, timeout time.Duration) error {
+	r.logger.Info("尝试停止CRI容器", "container_id", containerID)
+
+	if _, err := r.client.StopContainer(ctx, &runtimeapi.StopContainerRequest{
+		ContainerId: containerID,
+		Timeout:     int64(timeout.Seconds()),
+	}); err != nil {
+		return fmt.Errorf("停止CRI容器失败: %w", err)
+	}
+
+	r.logger.Info("成功停止CRI容器", "container_id", containerID)
+	return nil
+}
+
+// RemoveContainer 删除CRI容器
+func (r *CRIRuntime) RemoveContainer(ctx context.Context, containerID string, timeout time.Duration) error {
+	// 设置超时
+	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	r.logger.Info("尝试删除CRI容器", "container_id", containerID)
+
+	// 先停止容器再删除
+	if _, err := r.client.StopContainer(timeoutCtx, &runtimeapi.StopContainerRequest{
+		ContainerId: containerID,
+		Timeout:     int64(timeout.Seconds()),
+	}); err != nil {
+		r.logger.Debug("停止CRI容器失败", "container_id", containerID, "error", err)
+	}
+
+	// 删除容器
+	if _, err := r.client.RemoveContainer(timeoutCtx, &runtimeapi.RemoveContainerRequest{
+		ContainerId: containerID,
+	}); err != nil {
+		if timeoutCtx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("删除CRI容器超时: %w", err)
+		}
+		return fmt.Errorf("删除CRI容器失败: %w", err)
+	}
+
+	r.logger.Info("成功删除CRI容器", "container_id", containerID)
+	return nil
+}
+
+// RecordTimeoutContainer 记录超时容器
+func (r *CRIRuntime) RecordTimeoutContainer(containerID string) {
+	if r.detector != nil {
+		r.detector.RecordTimeoutContainer(containerID)
+	}
+}
+
+// KillContainerShim 清理容器的shim进程（containerd-shim或CRI-O的conmon）
+func (r *CRIRuntime) KillContainerShim(ctx context.Context, container ContainerMeta, opts ShimKillOptions) error {
+	return r.shims.KillShim(ctx, container, endpointSocket(r.endpoint), opts)
+}
+
+// Close 关闭CRI连接
+func (r *CRIRuntime) Close() error {
+	if r.conn != nil {
+		return r.conn.Close()
+	}
+	return nil
+}
+
+// shortContainerID 返回12位短ID，CRI运行时均支持按ID前缀查找容器
+func shortContainerID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
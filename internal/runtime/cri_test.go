@@ -0,0 +1,209 @@
+package runtime
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+
+	"github.com/tiggoins/zombie-cleaner/internal/config"
+	"github.com/tiggoins/zombie-cleaner/internal/logger"
+)
+
+// fakeRuntimeService 最小化的CRI RuntimeService实现，只覆盖CRIRuntime用到的接口
+type fakeRuntimeService struct {
+	runtimeapi.UnimplementedRuntimeServiceServer
+
+	mu         sync.Mutex
+	containers []*runtimeapi.Container
+	sandboxes  []*runtimeapi.PodSandbox
+	// 容器ID -> verbose信息中的info字段，缺失时不返回info
+	infos map[string]string
+	// ContainerStatus阻塞到超时的容器
+	slow    map[string]bool
+	stopped []string
+	removed []string
+}
+
+func (f *fakeRuntimeService) Version(context.Context, *runtimeapi.VersionRequest) (*runtimeapi.VersionResponse, error) {
+	return &runtimeapi.VersionResponse{RuntimeName: "fake", RuntimeVersion: "0.0.1"}, nil
+}
+
+func (f *fakeRuntimeService) ListContainers(context.Context, *runtimeapi.ListContainersRequest) (*runtimeapi.ListContainersResponse, error) {
+	return &runtimeapi.ListContainersResponse{Containers: f.containers}, nil
+}
+
+func (f *fakeRuntimeService) ListPodSandbox(context.Context, *runtimeapi.ListPodSandboxRequest) (*runtimeapi.ListPodSandboxResponse, error) {
+	return &runtimeapi.ListPodSandboxResponse{Items: f.sandboxes}, nil
+}
+
+func (f *fakeRuntimeService) ContainerStatus(ctx context.Context, req *runtimeapi.ContainerStatusRequest) (*runtimeapi.ContainerStatusResponse, error) {
+	if f.slow[req.ContainerId] {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	resp := &runtimeapi.ContainerStatusResponse{
+		Status: &runtimeapi.ContainerStatus{Id: req.ContainerId},
+		Info:   map[string]string{},
+	}
+	if info, ok := f.infos[req.ContainerId]; ok {
+		resp.Info["info"] = info
+	}
+	return resp, nil
+}
+
+func (f *fakeRuntimeService) StopContainer(_ context.Context, req *runtimeapi.StopContainerRequest) (*runtimeapi.StopContainerResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stopped = append(f.stopped, req.ContainerId)
+	return &runtimeapi.StopContainerResponse{}, nil
+}
+
+func (f *fakeRuntimeService) RemoveContainer(_ context.Context, req *runtimeapi.RemoveContainerRequest) (*runtimeapi.RemoveContainerResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.removed = append(f.removed, req.ContainerId)
+	return &runtimeapi.RemoveContainerResponse{}, nil
+}
+
+type recordingDetector struct {
+	mu       sync.Mutex
+	timeouts []string
+}
+
+func (d *recordingDetector) RecordTimeoutContainer(containerID string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.timeouts = append(d.timeouts, containerID)
+}
+
+// startFakeCRI 在临时unix socket上启动fake CRI服务并返回连接好的CRIRuntime
+func startFakeCRI(t *testing.T, svc *fakeRuntimeService, det *recordingDetector, timeout time.Duration) *CRIRuntime {
+	t.Helper()
+
+	socket := filepath.Join(t.TempDir(), "cri.sock")
+	lis, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("监听unix socket失败: %v", err)
+	}
+	server := grpc.NewServer()
+	runtimeapi.RegisterRuntimeServiceServer(server, svc)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	r, err := NewCRIRuntime(logger.New("error", "text"), config.CRIRuntimeConfig{Endpoint: socket, DialTimeout: timeout}, timeout, t.TempDir(), det)
+	if err != nil {
+		t.Fatalf("连接fake CRI失败: %v", err)
+	}
+	t.Cleanup(func() { r.Close() })
+	return r
+}
+
+func TestCRIRuntimeListContainers(t *testing.T) {
+	const (
+		okID        = "aaaaaaaaaaaaaaaaaaaaaaaa"
+		noInfoID    = "bbbbbbbbbbbbbbbbbbbbbbbb"
+		malformedID = "cccccccccccccccccccccccc"
+		slowID      = "dddddddddddddddddddddddd"
+		stoppedID   = "eeeeeeeeeeeeeeeeeeeeeeee"
+	)
+
+	container := func(id string) *runtimeapi.Container {
+		return &runtimeapi.Container{
+			Id:           id,
+			PodSandboxId: "sandbox-1",
+			Metadata:     &runtimeapi.ContainerMetadata{Name: "app"},
+			Image:        &runtimeapi.ImageSpec{Image: "nginx:1.25"},
+			Labels: map[string]string{
+				criLabelPodName:      "web-0",
+				criLabelPodNamespace: "prod",
+				criLabelPodUID:       "uid-1",
+				"container-label":    "c",
+			},
+		}
+	}
+
+	svc := &fakeRuntimeService{
+		containers: []*runtimeapi.Container{
+			container(okID), container(noInfoID), container(malformedID), container(slowID), container(stoppedID),
+		},
+		sandboxes: []*runtimeapi.PodSandbox{{
+			Id:          "sandbox-1",
+			Labels:      map[string]string{"app": "web"},
+			Annotations: map[string]string{"zombie-cleaner.io/exclude": "true"},
+		}},
+		infos: map[string]string{
+			okID:        `{"pid": 4242, "runtimeSpec": {"process": {"args": ["nginx", "-g", "daemon off;"]}}}`,
+			malformedID: `{"pid": `,
+			stoppedID:   `{"pid": 0}`,
+		},
+		slow: map[string]bool{slowID: true},
+	}
+	det := &recordingDetector{}
+	r := startFakeCRI(t, svc, det, 200*time.Millisecond)
+
+	containers, err := r.ListContainers(context.Background())
+	if err != nil {
+		t.Fatalf("ListContainers返回错误: %v", err)
+	}
+	if len(containers) != 1 {
+		t.Fatalf("期望只返回1个可解析的运行中容器，实际%d个: %+v", len(containers), containers)
+	}
+
+	got := containers[0]
+	if got.ID != okID[:12] {
+		t.Errorf("ID = %q, 期望 %q", got.ID, okID[:12])
+	}
+	if got.PID != 4242 {
+		t.Errorf("PID = %d, 期望 4242", got.PID)
+	}
+	if got.Comm != "nginx -g daemon off;" {
+		t.Errorf("Comm = %q, 期望取自runtimeSpec.process.args", got.Comm)
+	}
+	if got.PodName != "web-0" || got.PodNS != "prod" || got.PodUID != "uid-1" {
+		t.Errorf("Pod信息解析错误: name=%q ns=%q uid=%q", got.PodName, got.PodNS, got.PodUID)
+	}
+	if got.Image != "nginx:1.25" {
+		t.Errorf("Image = %q", got.Image)
+	}
+	if got.Labels["app"] != "web" || got.Labels["container-label"] != "c" {
+		t.Errorf("标签未合并sandbox标签: %v", got.Labels)
+	}
+	if got.Annotations["zombie-cleaner.io/exclude"] != "true" {
+		t.Errorf("注解未取自sandbox: %v", got.Annotations)
+	}
+
+	det.mu.Lock()
+	defer det.mu.Unlock()
+	if len(det.timeouts) != 1 || det.timeouts[0] != slowID {
+		t.Errorf("超时容器记录 = %v, 期望只有 %s", det.timeouts, slowID)
+	}
+}
+
+func TestCRIRuntimeStopAndRemove(t *testing.T) {
+	svc := &fakeRuntimeService{}
+	r := startFakeCRI(t, svc, &recordingDetector{}, time.Second)
+
+	ctx := context.Background()
+	if err := r.StopContainer(ctx, "abc", time.Second); err != nil {
+		t.Fatalf("StopContainer返回错误: %v", err)
+	}
+	if err := r.RemoveContainer(ctx, "def", time.Second); err != nil {
+		t.Fatalf("RemoveContainer返回错误: %v", err)
+	}
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	// RemoveContainer会先停止再删除
+	if len(svc.stopped) != 2 || svc.stopped[0] != "abc" || svc.stopped[1] != "def" {
+		t.Errorf("stopped = %v", svc.stopped)
+	}
+	if len(svc.removed) != 1 || svc.removed[0] != "def" {
+		t.Errorf("removed = %v", svc.removed)
+	}
+}
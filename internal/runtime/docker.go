@@ -4,41 +4,102 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"os/exec"
+	"net"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/client"
+	"github.com/tiggoins/zombie-cleaner/internal/config"
 	"github.com/tiggoins/zombie-cleaner/internal/logger"
 )
 
+const (
+	// dockershim写入容器的标签
+	dockerLabelContainerType   = "io.kubernetes.docker.type"
+	dockerLabelPodUID          = "io.kubernetes.pod.uid"
+	dockerContainerTypeSandbox = "podsandbox"
+	// dockershim以该前缀把Pod注解保存为sandbox容器标签
+	dockerAnnotationPrefix = "annotation."
+)
+
 // DockerRuntime Docker运行时实现
 type DockerRuntime struct {
-	client   *client.Client
-	logger   *logger.Logger
-	timeout  time.Duration
-	detector interface {
+	client      *client.Client
+	logger      *logger.Logger
+	timeout     time.Duration
+	dialTimeout time.Duration
+	shims       *ShimResolver
+	detector    interface {
 		RecordTimeoutContainer(containerID string)
 	}
 }
 
-// NewDockerRuntime 创建Docker运行时实例
-func NewDockerRuntime(log *logger.Logger, timeout time.Duration, detector interface {
+// NewDockerRuntime 创建Docker运行时实例并确认守护进程可用
+func NewDockerRuntime(log *logger.Logger, cfg config.DockerRuntimeConfig, timeout time.Duration, procRoot string, detector interface {
 	RecordTimeoutContainer(containerID string)
 }) (*DockerRuntime, error) {
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithVersion("1.38"))
+	host := dockerHost(cfg)
+	hostURL, err := client.ParseHostURL(host)
+	if err != nil {
+		return nil, fmt.Errorf("Docker守护进程地址无效: %w", err)
+	}
+
+	// FromEnv读取DOCKER_CERT_PATH等环境变量，配置文件中的参数优先
+	opts := []client.Opt{client.FromEnv, client.WithHost(host)}
+	if cfg.TLS.Enabled() {
+		opts = append(opts, client.WithTLSClientConfig(cfg.TLS.CAFile, cfg.TLS.CertFile, cfg.TLS.KeyFile))
+	}
+	if cfg.APIVersion != "" {
+		opts = append(opts, client.WithVersion(cfg.APIVersion))
+	} else {
+		opts = append(opts, client.WithAPIVersionNegotiation())
+	}
+	dialer := &net.Dialer{Timeout: cfg.DialTimeout}
+	opts = append(opts, client.WithDialContext(func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return dialer.DialContext(ctx, hostURL.Scheme, hostURL.Host)
+	}))
+
+	cli, err := client.NewClientWithOpts(opts...)
 	if err != nil {
-		return nil, fmt.Errorf("无法连接Docker守护进程: %w", err)
+		return nil, fmt.Errorf("无法创建Docker客户端: %w", err)
+	}
+
+	d := &DockerRuntime{
+		client:      cli,
+		logger:      log.WithComponent("docker-runtime"),
+		timeout:     timeout,
+		dialTimeout: cfg.DialTimeout,
+		shims:       NewShimResolver(log, procRoot),
+		detector:    detector,
+	}
+	// 创建客户端不会建立连接，启动时确认守护进程可用
+	if err := d.Ping(context.Background()); err != nil {
+		cli.Close()
+		return nil, fmt.Errorf("无法连接Docker守护进程 %s: %w", host, err)
+	}
+	return d, nil
+}
+
+// dockerHost 返回Docker守护进程地址：配置文件 → DOCKER_HOST → 默认socket
+func dockerHost(cfg config.DockerRuntimeConfig) string {
+	if cfg.Host != "" {
+		return cfg.Host
+	}
+	if host := os.Getenv(client.EnvOverrideHost); host != "" {
+		return host
 	}
+	return client.DefaultDockerHost
+}
 
-	return &DockerRuntime{
-		client:   cli,
-		logger:   log.WithComponent("docker-runtime"),
-		timeout:  timeout,
-		detector: detector,
-	}, nil
+// Ping 检查Docker守护进程是否可用
+func (d *DockerRuntime) Ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, d.dialTimeout)
+	defer cancel()
+	_, err := d.client.Ping(ctx)
+	return err
 }
 
 // ListContainers 列出所有Docker容器
@@ -48,6 +109,16 @@ func (d *DockerRuntime) ListContainers(ctx context.Context) ([]ContainerMeta, er
 		return nil, fmt.Errorf("获取Docker容器列表失败: %w", err)
 	}
 
+	// dockershim把Pod标签写在sandbox容器上，按Pod UID建立索引
+	podLabels := make(map[string]map[string]string)
+	for _, container := range containers {
+		if container.Labels[dockerLabelContainerType] == dockerContainerTypeSandbox {
+			if uid := container.Labels[dockerLabelPodUID]; uid != "" {
+				podLabels[uid] = container.Labels
+			}
+		}
+	}
+
 	var result []ContainerMeta
 	for _, container := range containers {
 		// 为每个容器设置超时
@@ -78,10 +149,11 @@ func (d *DockerRuntime) ListContainers(ctx context.Context) ([]ContainerMeta, er
 		// 注意：这里不构建PID树，因为这部分逻辑在detector中处理
 		
 		c := ContainerMeta{
-			ID:     container.ID[:12], // 短ID
-			PID:    containerPID,
-			Comm:   comm,
-			PIDSet: make(map[int]bool), // 在detector中填充
+			ID:      container.ID[:12], // 短ID
+			PID:     containerPID,
+			Comm:    comm,
+			PIDSet:  make(map[int]bool), // 在detector中填充
+			Runtime: NameDocker,
 			CreatedAt: func() time.Time {
 				t, err := time.Parse(time.RFC3339Nano, inspect.Created)
 				if err != nil {
@@ -97,17 +169,35 @@ func (d *DockerRuntime) ListContainers(ctx context.Context) ([]ContainerMeta, er
 		if len(parts) >= 5 {
 			c.PodName = parts[2]
 			c.PodNS = parts[3]
+			c.PodUID = parts[4]
 		} else {
 			c.PodName = name
 			c.PodNS = "-"
 		}
 
+		c.Image = inspect.Config.Image
+		c.Labels = mergeLabels(inspect.Config.Labels, podLabels[container.Labels[dockerLabelPodUID]])
+		c.Annotations = extractDockerAnnotations(podLabels[container.Labels[dockerLabelPodUID]])
+
 		result = append(result, c)
 	}
 
 	return result, nil
 }
 
+// StopContainer 优雅停止Docker容器
+func (d *DockerRuntime) StopContainer(ctx context.Context, containerID string, timeout time.Duration) error {
+	d.logger.Info("尝试停止Docker容器", "container_id", containerID)
+
+	timeoutSeconds := int(timeout.Seconds())
+	if err := d.client.ContainerStop(ctx, containerID, container.StopOptions{Timeout: &timeoutSeconds}); err != nil {
+		return fmt.Errorf("停止Docker容器失败: %w", err)
+	}
+
+	d.logger.Info("成功停止Docker容器", "container_id", containerID)
+	return nil
+}
+
 // RemoveContainer 删除Docker容器
 func (d *DockerRuntime) RemoveContainer(ctx context.Context, containerID string, timeout time.Duration) error {
 	// 设置超时
@@ -144,31 +234,10 @@ func (d *DockerRuntime) RecordTimeoutContainer(containerID string) {
 	}
 }
 
-// KillContainerShim 杀死容器的shim进程
-func (d *DockerRuntime) KillContainerShim(containerID string) error {
-	d.logger.Info("尝试kill docker-containerd-shim", "container_id", containerID)
-
-	// 查找docker-containerd-shim进程
-	pattern := fmt.Sprintf("docker-containerd-shim.*%s", containerID[:12])
-	cmd := exec.Command("pgrep", "-f", pattern)
-	output, err := cmd.Output()
-	if err != nil {
-		// 如果找不到进程，记录日志但不返回错误
-		d.logger.Debug("查找docker-containerd-shim进程失败", "pattern", pattern, "error", err)
-		return nil
-	}
-
-	pids := strings.Fields(strings.TrimSpace(string(output)))
-	for _, pid := range pids {
-		killCmd := exec.Command("kill", "-9", pid)
-		if err := killCmd.Run(); err != nil {
-			d.logger.Warn("kill docker-containerd-shim进程失败", "pid", pid, "error", err)
-		} else {
-			d.logger.Info("成功kill docker-containerd-shim进程", "pid", pid)
-		}
-	}
-
-	return nil
+// KillContainerShim 清理容器的shim进程（docker-containerd-shim或containerd-shim-runc-v2）
+func (d *DockerRuntime) KillContainerShim(ctx context.Context, container ContainerMeta, opts ShimKillOptions) error {
+	// Docker不暴露其containerd的地址，不按-address过滤
+	return d.shims.KillShim(ctx, container, "", opts)
 }
 
 // Close 关闭Docker客户端连接
@@ -193,4 +262,18 @@ func (d *DockerRuntime) getContainerProcess(inspect types.ContainerJSON) string
 		cmdParts = append(cmdParts, argsStr)
 	}
 	return strings.Join(cmdParts, " ")
-}
\ No newline at end of file
+}
+
+// extractDockerAnnotations 从sandbox容器标签中还原Pod注解，非Kubernetes容器返回nil
+func extractDockerAnnotations(sandboxLabels map[string]string) map[string]string {
+	if sandboxLabels == nil {
+		return nil
+	}
+	annotations := make(map[string]string)
+	for k, v := range sandboxLabels {
+		if strings.HasPrefix(k, dockerAnnotationPrefix) {
+			annotations[strings.TrimPrefix(k, dockerAnnotationPrefix)] = v
+		}
+	}
+	return annotations
+}
@@ -0,0 +1,210 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tiggoins/zombie-cleaner/internal/logger"
+)
+
+// 容器从所有运行时的列表中消失超过该时间后，不再记录其所属的运行时。
+// 处置过程中容器可能已被停止而不再出现在列表中，后续步骤仍需转发给原运行时
+const ownerRetention = time.Hour
+
+// timeoutRecorder 接收运行时报告的inspect超时容器
+type timeoutRecorder interface {
+	RecordTimeoutContainer(containerID string)
+}
+
+// containerOwner 容器所属的运行时
+type containerOwner struct {
+	name     string
+	lastSeen time.Time
+}
+
+// MultiRuntime 组合多个运行时：列出所有运行时的容器并以ContainerMeta.Runtime标记所属运行时，
+// 停止、删除容器和kill shim转发给容器所属的运行时。
+// 用于Docker（dockershim）和containerd/CRI-O容器同时存在的迁移中节点
+type MultiRuntime struct {
+	logger   *logger.Logger
+	detector timeoutRecorder
+	// 按加入顺序排列的运行时名称，同一容器被多个运行时列出时以先加入的为准
+	names    []string
+	runtimes map[string]ContainerRuntimeInterface
+
+	mu     sync.Mutex
+	owners map[string]containerOwner
+	// now 便于测试替换
+	now func() time.Time
+}
+
+// NewMultiRuntime 创建空的组合运行时，通过Add加入运行时
+func NewMultiRuntime(log *logger.Logger, detector interface {
+	RecordTimeoutContainer(containerID string)
+}) *MultiRuntime {
+	return &MultiRuntime{
+		logger:   log.WithComponent("multi-runtime"),
+		detector: detector,
+		runtimes: make(map[string]ContainerRuntimeInterface),
+		owners:   make(map[string]containerOwner),
+		now:      time.Now,
+	}
+}
+
+// Add 加入名为name的运行时
+func (m *MultiRuntime) Add(name string, rt ContainerRuntimeInterface) {
+	m.names = append(m.names, name)
+	m.runtimes[name] = rt
+}
+
+// Names 返回已加入的运行时名称
+func (m *MultiRuntime) Names() []string {
+	return append([]string(nil), m.names...)
+}
+
+// Recorder 返回交给名为name的运行时的超时记录器：记录超时容器所属的运行时后转发给detector，
+// 使无法inspect的容器也能把kill shim转发给正确的运行时
+func (m *MultiRuntime) Recorder(name string) interface {
+	RecordTimeoutContainer(containerID string)
+} {
+	return ownerRecorder{multi: m, name: name}
+}
+
+type ownerRecorder struct {
+	multi *MultiRuntime
+	name  string
+}
+
+func (r ownerRecorder) RecordTimeoutContainer(containerID string) {
+	r.multi.setOwner(containerID, r.name)
+	r.multi.RecordTimeoutContainer(containerID)
+}
+
+func (m *MultiRuntime) setOwner(containerID, name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.owners[containerID] = containerOwner{name: name, lastSeen: m.now()}
+}
+
+// ListContainers 列出所有运行时的容器。部分运行时失败时记录日志并返回其余运行时的容器，全部失败时返回错误
+func (m *MultiRuntime) ListContainers(ctx context.Context) ([]ContainerMeta, error) {
+	var (
+		result []ContainerMeta
+		errs   []error
+		// 同一容器的init进程相同，按PID去重
+		seenPIDs = make(map[int]string)
+		owners   = make(map[string]string)
+	)
+	for _, name := range m.names {
+		containers, err := m.runtimes[name].ListContainers(ctx)
+		if err != nil {
+			m.logger.Warn("获取容器列表失败", "runtime", name, "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		for _, container := range containers {
+			if owner, ok := seenPIDs[container.PID]; ok {
+				m.logger.Debug("容器已由其他运行时列出，跳过", "container_id", container.ID, "runtime", name, "owner", owner)
+				continue
+			}
+			seenPIDs[container.PID] = name
+			owners[container.ID] = name
+			container.Runtime = name
+			result = append(result, container)
+		}
+	}
+	if len(errs) > 0 && len(errs) == len(m.names) {
+		return nil, errors.Join(errs...)
+	}
+
+	m.mu.Lock()
+	now := m.now()
+	for id, name := range owners {
+		m.owners[id] = containerOwner{name: name, lastSeen: now}
+	}
+	for id, owner := range m.owners {
+		if now.Sub(owner.lastSeen) > ownerRetention {
+			delete(m.owners, id)
+		}
+	}
+	m.mu.Unlock()
+
+	return result, nil
+}
+
+// owner 返回容器所属的运行时
+func (m *MultiRuntime) owner(containerID string) (string, ContainerRuntimeInterface, error) {
+	m.mu.Lock()
+	owner, ok := m.owners[containerID]
+	m.mu.Unlock()
+	if !ok {
+		return "", nil, fmt.Errorf("未知容器%s所属的运行时", containerID)
+	}
+	return owner.name, m.runtimes[owner.name], nil
+}
+
+// StopContainer 通过容器所属的运行时停止容器
+func (m *MultiRuntime) StopContainer(ctx context.Context, containerID string, timeout time.Duration) error {
+	name, rt, err := m.owner(containerID)
+	if err != nil {
+		return err
+	}
+	m.logger.Debug("转发停止容器请求", "container_id", containerID, "runtime", name)
+	return rt.StopContainer(ctx, containerID, timeout)
+}
+
+// RemoveContainer 通过容器所属的运行时删除容器
+func (m *MultiRuntime) RemoveContainer(ctx context.Context, containerID string, timeout time.Duration) error {
+	name, rt, err := m.owner(containerID)
+	if err != nil {
+		return err
+	}
+	m.logger.Debug("转发删除容器请求", "container_id", containerID, "runtime", name)
+	return rt.RemoveContainer(ctx, containerID, timeout)
+}
+
+// RecordTimeoutContainer 记录超时容器
+func (m *MultiRuntime) RecordTimeoutContainer(containerID string) {
+	if m.detector != nil {
+		m.detector.RecordTimeoutContainer(containerID)
+	}
+}
+
+// KillContainerShim 通过容器所属的运行时清理shim进程
+func (m *MultiRuntime) KillContainerShim(ctx context.Context, container ContainerMeta, opts ShimKillOptions) error {
+	_, rt, err := m.owner(container.ID)
+	if err != nil {
+		return err
+	}
+	return rt.KillContainerShim(ctx, container, opts)
+}
+
+// Runtime 返回名为name的运行时，不存在时返回nil
+func (m *MultiRuntime) Runtime(name string) ContainerRuntimeInterface {
+	return m.runtimes[name]
+}
+
+// Ping 检查所有运行时，返回不可用运行时的错误
+func (m *MultiRuntime) Ping(ctx context.Context) error {
+	var errs []error
+	for _, name := range m.names {
+		if err := m.runtimes[name].Ping(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close 关闭所有运行时的连接
+func (m *MultiRuntime) Close() error {
+	var errs []error
+	for _, name := range m.names {
+		if err := m.runtimes[name].Close(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
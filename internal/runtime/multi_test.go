@@ -0,0 +1,312 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"net"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tiggoins/zombie-cleaner/internal/logger"
+)
+
+// fakeRuntime 记录容器操作的运行时
+type fakeRuntime struct {
+	containers []ContainerMeta
+	listErr    error
+	pingErr    error
+	stopped    []string
+	removed    []string
+	killed     []string
+	closed     bool
+}
+
+func (f *fakeRuntime) ListContainers(context.Context) ([]ContainerMeta, error) {
+	return f.containers, f.listErr
+}
+
+func (f *fakeRuntime) StopContainer(_ context.Context, containerID string, _ time.Duration) error {
+	f.stopped = append(f.stopped, containerID)
+	return nil
+}
+
+func (f *fakeRuntime) RemoveContainer(_ context.Context, containerID string, _ time.Duration) error {
+	f.removed = append(f.removed, containerID)
+	return nil
+}
+
+func (f *fakeRuntime) RecordTimeoutContainer(string) {}
+
+func (f *fakeRuntime) KillContainerShim(_ context.Context, container ContainerMeta, _ ShimKillOptions) error {
+	f.killed = append(f.killed, container.ID)
+	return nil
+}
+
+func (f *fakeRuntime) Ping(context.Context) error {
+	return f.pingErr
+}
+
+func (f *fakeRuntime) Close() error {
+	f.closed = true
+	return nil
+}
+
+func newTestMultiRuntime(det *recordingDetector, runtimes map[string]*fakeRuntime, order ...string) *MultiRuntime {
+	m := NewMultiRuntime(logger.New("error", "text"), det)
+	for _, name := range order {
+		m.Add(name, runtimes[name])
+	}
+	return m
+}
+
+func TestMultiRuntimeListContainers(t *testing.T) {
+	docker := &fakeRuntime{containers: []ContainerMeta{
+		{ID: "d1", PID: 100, Runtime: NameDocker},
+		{ID: "d2", PID: 200, Runtime: NameDocker},
+	}}
+	containerd := &fakeRuntime{containers: []ContainerMeta{
+		{ID: "c1", PID: 300, Runtime: NameContainerd},
+		// 与d2是同一个容器
+		{ID: "c2", PID: 200, Runtime: NameContainerd},
+	}}
+	crio := &fakeRuntime{containers: []ContainerMeta{{ID: "o1", PID: 400, Runtime: NameCRI}}}
+	m := newTestMultiRuntime(nil, map[string]*fakeRuntime{
+		NameDocker: docker, NameContainerd: containerd, NameCRIO: crio,
+	}, NameDocker, NameContainerd, NameCRIO)
+
+	containers, err := m.ListContainers(context.Background())
+	if err != nil {
+		t.Fatalf("ListContainers返回错误: %v", err)
+	}
+	got := make(map[string]string)
+	for _, c := range containers {
+		got[c.ID] = c.Runtime
+	}
+	want := map[string]string{"d1": NameDocker, "d2": NameDocker, "c1": NameContainerd, "o1": NameCRIO}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("容器及所属运行时 = %v, 期望 %v", got, want)
+	}
+}
+
+func TestMultiRuntimeListPartialFailure(t *testing.T) {
+	docker := &fakeRuntime{listErr: errors.New("connection refused")}
+	containerd := &fakeRuntime{containers: []ContainerMeta{{ID: "c1", PID: 300}}}
+	m := newTestMultiRuntime(nil, map[string]*fakeRuntime{NameDocker: docker, NameContainerd: containerd}, NameDocker, NameContainerd)
+
+	containers, err := m.ListContainers(context.Background())
+	if err != nil {
+		t.Fatalf("部分运行时失败时不应返回错误: %v", err)
+	}
+	if len(containers) != 1 || containers[0].ID != "c1" {
+		t.Errorf("容器 = %+v, 期望只有c1", containers)
+	}
+
+	containerd.listErr = errors.New("unavailable")
+	if _, err := m.ListContainers(context.Background()); err == nil {
+		t.Error("所有运行时都失败时应返回错误")
+	}
+}
+
+func TestMultiRuntimeRoutesToOwner(t *testing.T) {
+	det := &recordingDetector{}
+	docker := &fakeRuntime{containers: []ContainerMeta{{ID: "d1", PID: 100}}}
+	containerd := &fakeRuntime{containers: []ContainerMeta{{ID: "c1", PID: 300}}}
+	m := newTestMultiRuntime(det, map[string]*fakeRuntime{NameDocker: docker, NameContainerd: containerd}, NameDocker, NameContainerd)
+
+	ctx := context.Background()
+	if _, err := m.ListContainers(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.StopContainer(ctx, "c1", time.Second); err != nil {
+		t.Fatalf("StopContainer返回错误: %v", err)
+	}
+	if err := m.RemoveContainer(ctx, "d1", time.Second); err != nil {
+		t.Fatalf("RemoveContainer返回错误: %v", err)
+	}
+	if !reflect.DeepEqual(containerd.stopped, []string{"c1"}) || len(docker.stopped) != 0 {
+		t.Errorf("停止请求: docker=%v, containerd=%v, 期望只发往containerd", docker.stopped, containerd.stopped)
+	}
+	if !reflect.DeepEqual(docker.removed, []string{"d1"}) || len(containerd.removed) != 0 {
+		t.Errorf("删除请求: docker=%v, containerd=%v, 期望只发往docker", docker.removed, containerd.removed)
+	}
+
+	// 容器被停止后不再出现在列表中，后续步骤仍发往原运行时
+	containerd.containers = nil
+	if _, err := m.ListContainers(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.KillContainerShim(ctx, ContainerMeta{ID: "c1"}, ShimKillOptions{}); err != nil {
+		t.Fatalf("KillContainerShim返回错误: %v", err)
+	}
+	if !reflect.DeepEqual(containerd.killed, []string{"c1"}) {
+		t.Errorf("kill shim请求 = %v, 期望发往containerd", containerd.killed)
+	}
+
+	// inspect超时的容器不在列表中，通过记录器得知所属运行时
+	m.Recorder(NameDocker).RecordTimeoutContainer("d9")
+	if err := m.KillContainerShim(ctx, ContainerMeta{ID: "d9"}, ShimKillOptions{}); err != nil {
+		t.Fatalf("KillContainerShim返回错误: %v", err)
+	}
+	if !reflect.DeepEqual(docker.killed, []string{"d9"}) {
+		t.Errorf("kill shim请求 = %v, 期望发往docker", docker.killed)
+	}
+	if !reflect.DeepEqual(det.timeouts, []string{"d9"}) {
+		t.Errorf("detector记录的超时容器 = %v, 期望[d9]", det.timeouts)
+	}
+
+	if err := m.StopContainer(ctx, "unknown", time.Second); err == nil {
+		t.Error("未知容器应返回错误")
+	}
+}
+
+func TestMultiRuntimeForgetsStaleOwners(t *testing.T) {
+	docker := &fakeRuntime{containers: []ContainerMeta{{ID: "d1", PID: 100}}}
+	m := newTestMultiRuntime(nil, map[string]*fakeRuntime{NameDocker: docker}, NameDocker)
+	now := time.Now()
+	m.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	m.ListContainers(ctx)
+	docker.containers = nil
+	now = now.Add(ownerRetention + time.Minute)
+	m.ListContainers(ctx)
+
+	if err := m.RemoveContainer(ctx, "d1", time.Second); err == nil {
+		t.Error("长时间未出现的容器应被遗忘")
+	}
+}
+
+func TestMultiRuntimeClose(t *testing.T) {
+	docker, containerd := &fakeRuntime{}, &fakeRuntime{}
+	m := newTestMultiRuntime(nil, map[string]*fakeRuntime{NameDocker: docker, NameContainerd: containerd}, NameDocker, NameContainerd)
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close返回错误: %v", err)
+	}
+	if !docker.closed || !containerd.closed {
+		t.Error("Close应关闭所有运行时")
+	}
+}
+
+func TestMultiRuntimePing(t *testing.T) {
+	docker, containerd := &fakeRuntime{}, &fakeRuntime{}
+	m := newTestMultiRuntime(nil, map[string]*fakeRuntime{NameDocker: docker, NameContainerd: containerd}, NameDocker, NameContainerd)
+	if err := m.Ping(context.Background()); err != nil {
+		t.Fatalf("所有运行时可用时Ping返回错误: %v", err)
+	}
+
+	containerd.pingErr = errors.New("connection refused")
+	err := m.Ping(context.Background())
+	if err == nil || !strings.Contains(err.Error(), NameContainerd) {
+		t.Errorf("Ping() = %v, 期望包含不可用的运行时containerd", err)
+	}
+	if m.Runtime(NameDocker) != docker {
+		t.Error("Runtime应返回对应名称的运行时")
+	}
+}
+
+// listenSocket 在临时目录创建unix socket
+func listenSocket(t *testing.T, name string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	lis, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("监听unix socket失败: %v", err)
+	}
+	t.Cleanup(func() { lis.Close() })
+	return path
+}
+
+func TestProbeRuntimes(t *testing.T) {
+	dockerSock := listenSocket(t, "docker.sock")
+	containerdSock := listenSocket(t, "containerd.sock")
+	missing := filepath.Join(t.TempDir(), "crio.sock")
+
+	var connected []string
+	probe := func(name, socket string, err error) socketProbe {
+		return socketProbe{name: name, socket: socket, connect: func(timeoutRecorder) (ContainerRuntimeInterface, error) {
+			connected = append(connected, name)
+			return &fakeRuntime{}, err
+		}}
+	}
+
+	tests := []struct {
+		name          string
+		probes        []socketProbe
+		wantRuntimes  []string
+		wantConnected []string
+		wantErr       bool
+	}{
+		{
+			name: "使用所有可用的运行时",
+			probes: []socketProbe{
+				probe(NameDocker, dockerSock, nil),
+				probe(NameContainerd, containerdSock, nil),
+				probe(NameCRIO, missing, nil),
+			},
+			wantRuntimes:  []string{NameDocker, NameContainerd},
+			wantConnected: []string{NameDocker, NameContainerd},
+		},
+		{
+			name: "同一socket只使用一次",
+			probes: []socketProbe{
+				probe(NameContainerd, containerdSock, nil),
+				probe(NameCRI, containerdSock, nil),
+			},
+			wantRuntimes:  []string{NameContainerd},
+			wantConnected: []string{NameContainerd},
+		},
+		{
+			name: "连接失败时尝试同一socket的其他接口",
+			probes: []socketProbe{
+				probe(NameContainerd, containerdSock, errors.New("unavailable")),
+				probe(NameCRI, containerdSock, nil),
+			},
+			wantRuntimes:  []string{NameCRI},
+			wantConnected: []string{NameContainerd, NameCRI},
+		},
+		{
+			name:          "没有可用的运行时",
+			probes:        []socketProbe{probe(NameCRIO, missing, nil)},
+			wantConnected: nil,
+			wantErr:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			connected = nil
+			m, err := probeRuntimes(tt.probes, logger.New("error", "text"), nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("probeRuntimes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(connected, tt.wantConnected) {
+				t.Errorf("尝试连接 = %v, 期望 %v", connected, tt.wantConnected)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := m.Names(); !reflect.DeepEqual(got, tt.wantRuntimes) {
+				t.Errorf("使用的运行时 = %v, 期望 %v", got, tt.wantRuntimes)
+			}
+		})
+	}
+}
+
+func TestEndpointSocket(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		want     string
+	}{
+		{endpoint: "unix:///run/containerd/containerd.sock", want: "/run/containerd/containerd.sock"},
+		{endpoint: "/var/run/crio/crio.sock", want: "/var/run/crio/crio.sock"},
+		{endpoint: "tcp://127.0.0.1:2375", want: ""},
+	}
+	for _, tt := range tests {
+		if got := endpointSocket(tt.endpoint); got != tt.want {
+			t.Errorf("endpointSocket(%q) = %q, 期望 %q", tt.endpoint, got, tt.want)
+		}
+	}
+}
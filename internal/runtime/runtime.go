@@ -11,25 +11,65 @@ type ContainerMeta struct {
 	PID       int
 	PodName   string
 	PodNS     string
+	PodUID    string
 	Comm      string
 	PIDSet    map[int]bool
 	CreatedAt time.Time
+	// InitStartTime 容器init进程的启动时间（/proc/<pid>/stat中的starttime），由detector填充，为0时未知。
+	// 处置时用于确认PID仍是检测时的init进程，没有被复用
+	InitStartTime uint64
+	// CgroupPath 容器init进程所在的cgroup路径，由detector根据/proc/<pid>/cgroup填充
+	CgroupPath string
+	// Image 容器镜像
+	Image string
+	// Labels 容器标签，运行时能获取到Pod标签时会合并进来
+	Labels map[string]string
+	// Annotations Pod注解，运行时未暴露时为nil
+	Annotations map[string]string
+	// Runtime 容器所属的运行时，如docker、containerd、crio，停止和删除容器时发往该运行时
+	Runtime string
+}
+
+// 运行时名称，用于ContainerMeta.Runtime、日志和自动探测
+const (
+	NameDocker     = "docker"
+	NameContainerd = "containerd"
+	NameCRIO       = "crio"
+	NameCRI        = "cri"
+)
+
+// mergeLabels 合并容器标签和Pod标签，Pod标签优先
+func mergeLabels(containerLabels, podLabels map[string]string) map[string]string {
+	merged := make(map[string]string, len(containerLabels)+len(podLabels))
+	for k, v := range containerLabels {
+		merged[k] = v
+	}
+	for k, v := range podLabels {
+		merged[k] = v
+	}
+	return merged
 }
 
 // ContainerRuntimeInterface 定义容器运行时接口
 type ContainerRuntimeInterface interface {
 	// ListContainers 列出所有容器
 	ListContainers(ctx context.Context) ([]ContainerMeta, error)
-	
+
+	// StopContainer 优雅停止容器，超时后由运行时强制终止
+	StopContainer(ctx context.Context, containerID string, timeout time.Duration) error
+
 	// RemoveContainer 删除容器
 	RemoveContainer(ctx context.Context, containerID string, timeout time.Duration) error
-	
+
 	// RecordTimeoutContainer 记录超时容器
 	RecordTimeoutContainer(containerID string)
-	
-	// KillContainerShim 杀死容器的shim进程
-	KillContainerShim(containerID string) error
-	
+
+	// KillContainerShim 清理容器的shim进程，container至少需要ID，已知init进程PID时据此定位shim
+	KillContainerShim(ctx context.Context, container ContainerMeta, opts ShimKillOptions) error
+
+	// Ping 检查运行时守护进程是否可用
+	Ping(ctx context.Context) error
+
 	// Close 关闭运行时客户端连接
 	Close() error
-}
\ No newline at end of file
+}
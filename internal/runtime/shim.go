@@ -0,0 +1,336 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/tiggoins/zombie-cleaner/internal/logger"
+	"github.com/tiggoins/zombie-cleaner/internal/process"
+)
+
+const (
+	// 发送SIGTERM后等待shim退出的默认时间
+	defaultShimGracePeriod = 5 * time.Second
+	// 发送SIGKILL后等待shim退出的时间
+	shimKillWait = 5 * time.Second
+	// 等待shim退出时的复查间隔
+	shimPollInterval = 100 * time.Millisecond
+	// 从容器init进程向上查找shim的最大层数
+	maxShimDepth = 32
+)
+
+// shim进程的可执行文件名前缀：containerd-shim、containerd-shim-runc-v1/v2、
+// Docker 18.09之前的docker-containerd-shim以及CRI-O的conmon
+var shimNames = []string{"containerd-shim", "docker-containerd-shim", "conmon"}
+
+// ErrShimShared shim还在为其他运行中的容器服务，未启用force时不清理
+var ErrShimShared = errors.New("shim进程还在为其他容器服务")
+
+// ShimKillOptions 清理shim进程的参数
+type ShimKillOptions struct {
+	// 为true时即使shim还在为其他容器服务（如同一Pod共享的containerd-shim-runc-v2）也清理
+	Force bool
+	// 发送SIGTERM后等待shim退出的时间，超时后发送SIGKILL，为0时使用默认的5s
+	GracePeriod time.Duration
+}
+
+// procEntry /proc中单个进程的信息
+type procEntry struct {
+	process.Stat
+	cmdline []string
+}
+
+// shimProcess 定位到的shim进程
+type shimProcess struct {
+	process.Ref
+	cmdline []string
+	// 仍在运行的其他子进程，即shim服务的其他容器的init进程
+	others []int
+}
+
+// ShimResolver 遍历/proc定位容器的shim进程并清理，替代按命令行模糊匹配的pgrep
+type ShimResolver struct {
+	logger   *logger.Logger
+	procRoot string
+
+	// 以下字段便于测试替换
+	kill         func(ref process.Ref, sig syscall.Signal) error
+	pollInterval time.Duration
+	killWait     time.Duration
+}
+
+// NewShimResolver 创建读取procRoot的shim解析器
+func NewShimResolver(log *logger.Logger, procRoot string) *ShimResolver {
+	r := &ShimResolver{
+		logger:       log.WithComponent("shim-resolver"),
+		procRoot:     procRoot,
+		pollInterval: shimPollInterval,
+		killWait:     shimKillWait,
+	}
+	r.kill = r.sendSignal
+	return r
+}
+
+// sendSignal 打开进程句柄发送信号，按procRoot校验启动时间
+func (r *ShimResolver) sendSignal(ref process.Ref, sig syscall.Signal) error {
+	return process.Signal(r.procRoot, ref, sig)
+}
+
+// KillShim 定位并清理容器的shim进程，找不到shim时返回nil。
+// address为运行时守护进程的socket，按容器ID查找时只匹配-address参数与之一致的shim，为空时不检查
+func (r *ShimResolver) KillShim(ctx context.Context, container ContainerMeta, address string, opts ShimKillOptions) error {
+	log := r.logger.WithContainer(container.ID, container.PodName, container.PodNS)
+
+	shim, err := r.resolve(container, address)
+	if err != nil {
+		return err
+	}
+	if shim == nil {
+		log.Info("未找到容器的shim进程", "init_pid", container.PID)
+		return nil
+	}
+	shimLog := log.With("shim_pid", shim.PID, "shim", strings.Join(shim.cmdline, " "))
+
+	if len(shim.others) > 0 {
+		if !opts.Force {
+			return fmt.Errorf("%w: shim进程%d还有%d个运行中的容器进程%v", ErrShimShared, shim.PID, len(shim.others), shim.others)
+		}
+		shimLog.Warn("shim进程还在为其他容器服务，已启用force，仍然清理", "other_pids", shim.others)
+	}
+
+	grace := opts.GracePeriod
+	if grace <= 0 {
+		grace = defaultShimGracePeriod
+	}
+
+	shimLog.Info("向shim进程发送SIGTERM", "grace_period", grace)
+	if err := r.signal(shim, syscall.SIGTERM); err != nil {
+		return err
+	}
+	exited, err := r.waitExit(ctx, shim, grace)
+	if err != nil {
+		return err
+	}
+	if exited {
+		shimLog.Info("shim进程已退出")
+		return nil
+	}
+
+	shimLog.Warn("shim进程未在宽限期内退出，发送SIGKILL")
+	if err := r.signal(shim, syscall.SIGKILL); err != nil {
+		return err
+	}
+	exited, err = r.waitExit(ctx, shim, r.killWait)
+	if err != nil {
+		return err
+	}
+	if !exited {
+		return fmt.Errorf("shim进程%d在SIGKILL后仍未退出", shim.PID)
+	}
+	shimLog.Info("shim进程已退出")
+	return nil
+}
+
+// resolve 定位容器的shim进程：优先沿init进程的父进程链向上查找，
+// init进程未知、已不存在或启动时间不一致（PID已被复用）时按命令行中的完整容器ID查找
+func (r *ShimResolver) resolve(container ContainerMeta, address string) (*shimProcess, error) {
+	procs, err := r.snapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	containerID := container.ID
+	initPID := 0
+	if init, ok := procs[container.PID]; ok && container.PID > 0 {
+		if container.InitStartTime != 0 && init.StartTime == container.InitStartTime {
+			initPID = container.PID
+		} else {
+			// 处置过程中init进程可能已退出，PID被其他进程（甚至其他容器的init）复用
+			r.logger.Debug("无法确认容器init进程，按容器ID查找shim",
+				"container_id", containerID,
+				"init_pid", container.PID,
+				"init_start_time", container.InitStartTime,
+				"actual_start_time", init.StartTime)
+		}
+	}
+
+	var shim *procEntry
+	if init, ok := procs[initPID]; ok && initPID > 0 {
+		for p, depth := procs[init.PPID], 0; p != nil && p.PID > 1 && depth < maxShimDepth; p, depth = procs[p.PPID], depth+1 {
+			if isShim(p) {
+				shim = p
+				break
+			}
+		}
+	}
+	if shim == nil {
+		var candidates []*procEntry
+		for _, p := range procs {
+			if isShim(p) && referencesContainer(p.cmdline, containerID) && matchesAddress(p.cmdline, address) {
+				candidates = append(candidates, p)
+			}
+		}
+		switch len(candidates) {
+		case 0:
+			return nil, nil
+		case 1:
+			shim = candidates[0]
+		default:
+			pids := make([]int, len(candidates))
+			for i, p := range candidates {
+				pids[i] = p.PID
+			}
+			return nil, fmt.Errorf("容器%s匹配到多个shim进程%v", containerID, pids)
+		}
+	}
+
+	result := &shimProcess{Ref: process.Ref{PID: shim.PID, StartTime: shim.StartTime}, cmdline: shim.cmdline}
+	var live []int
+	for _, p := range procs {
+		if p.PPID == shim.PID && p.State != "Z" && p.State != "X" {
+			live = append(live, p.PID)
+		}
+	}
+	if initPID > 0 {
+		for _, pid := range live {
+			if pid != initPID {
+				result.others = append(result.others, pid)
+			}
+		}
+	} else if len(live) > 1 {
+		// 不知道容器的init进程时，允许shim只剩一个运行中的子进程（即容器自身）
+		result.others = live
+	}
+	return result, nil
+}
+
+// snapshot 读取/proc中所有进程的信息
+func (r *ShimResolver) snapshot() (map[int]*procEntry, error) {
+	entries, err := os.ReadDir(r.procRoot)
+	if err != nil {
+		return nil, fmt.Errorf("无法读取 %s: %w", r.procRoot, err)
+	}
+	procs := make(map[int]*procEntry, len(entries))
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || pid <= 0 {
+			continue
+		}
+		p, err := r.readProc(pid)
+		if err != nil {
+			// 进程可能已经退出
+			continue
+		}
+		procs[pid] = p
+	}
+	return procs, nil
+}
+
+// readProc 读取进程的stat和cmdline
+func (r *ShimResolver) readProc(pid int) (*procEntry, error) {
+	stat, err := process.ReadStat(r.procRoot, pid)
+	if err != nil {
+		return nil, err
+	}
+	p := &procEntry{Stat: stat}
+	data, err := os.ReadFile(filepath.Join(r.procRoot, strconv.Itoa(pid), "cmdline"))
+	if err == nil {
+		p.cmdline = strings.FieldsFunc(string(data), func(c rune) bool { return c == 0 })
+	}
+	return p, nil
+}
+
+// signal 通过进程句柄向shim发送信号，shim已退出或PID已被复用时视为成功
+func (r *ShimResolver) signal(shim *shimProcess, sig syscall.Signal) error {
+	if err := r.kill(shim.Ref, sig); err != nil && !errors.Is(err, process.ErrGone) {
+		return fmt.Errorf("向shim进程%d发送%s失败: %w", shim.PID, sig, err)
+	}
+	return nil
+}
+
+// waitExit 在timeout内等待shim退出
+func (r *ShimResolver) waitExit(ctx context.Context, shim *shimProcess, timeout time.Duration) (bool, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+	for {
+		if r.exited(shim) {
+			return true, nil
+		}
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-timer.C:
+			return r.exited(shim), nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// exited 判断shim是否已退出：进程不存在、PID已被复用或已成为等待回收的僵尸进程
+func (r *ShimResolver) exited(shim *shimProcess) bool {
+	stat, err := process.ReadStat(r.procRoot, shim.PID)
+	if err != nil {
+		return true
+	}
+	return stat.StartTime != shim.StartTime || stat.State == "Z" || stat.State == "X"
+}
+
+// isShim 按可执行文件名判断是否为shim进程，comm最多15个字符，优先使用cmdline
+func isShim(p *procEntry) bool {
+	name := p.Comm
+	if len(p.cmdline) > 0 {
+		name = filepath.Base(p.cmdline[0])
+	}
+	for _, prefix := range shimNames {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// referencesContainer 判断shim命令行是否引用了容器ID，如containerd-shim-runc-v2的"-id <id>"、
+// conmon的"--cid=<id>"或docker-containerd-shim的bundle路径。
+// 参数按'='和'/'切分后逐段比较，containerID可以是完整ID的前缀（至少12位，CRI运行时使用短ID）
+func referencesContainer(cmdline []string, containerID string) bool {
+	if len(containerID) < 12 || len(cmdline) < 2 {
+		return false
+	}
+	for _, arg := range cmdline[1:] {
+		for _, token := range strings.FieldsFunc(arg, func(c rune) bool { return c == '=' || c == '/' }) {
+			if token == containerID || (len(token) == 64 && strings.HasPrefix(token, containerID)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchesAddress 判断shim是否属于address对应的守护进程，没有-address参数的shim（如conmon）总是匹配
+func matchesAddress(cmdline []string, address string) bool {
+	if address == "" {
+		return true
+	}
+	for i, arg := range cmdline {
+		var value string
+		switch {
+		case (arg == "-address" || arg == "--address") && i+1 < len(cmdline):
+			value = cmdline[i+1]
+		case strings.HasPrefix(arg, "-address="), strings.HasPrefix(arg, "--address="):
+			value = arg[strings.IndexByte(arg, '=')+1:]
+		default:
+			continue
+		}
+		return endpointSocket(value) == endpointSocket(address)
+	}
+	return true
+}
@@ -0,0 +1,313 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/tiggoins/zombie-cleaner/internal/logger"
+	"github.com/tiggoins/zombie-cleaner/internal/process"
+)
+
+const (
+	testContainerID = "3f1c9a7be2d84f6a9c0b1d2e3f4a5b6c7d8e9f0a1b2c3d4e5f6a7b8c9d0e1f2a"
+	testSandboxID   = "9e8d7c6b5a4f3e2d1c0b9a8f7e6d5c4b3a2f1e0d9c8b7a6f5e4d3c2b1a0f9e8d"
+	testCRIOID      = "5a5b5c5d5e5f50515253545556575859505a5b5c5d5e5f505152535455565758"
+)
+
+// fakeProc 在临时目录中构造的/proc
+type fakeProc struct {
+	t    *testing.T
+	root string
+}
+
+func newFakeProc(t *testing.T) *fakeProc {
+	return &fakeProc{t: t, root: t.TempDir()}
+}
+
+// add 写入进程的stat和cmdline，comm取cmdline[0]的文件名前15个字符
+func (f *fakeProc) add(pid, ppid int, state string, cmdline ...string) {
+	f.t.Helper()
+	comm := filepath.Base(cmdline[0])
+	if len(comm) > 15 {
+		comm = comm[:15]
+	}
+	dir := filepath.Join(f.root, strconv.Itoa(pid))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		f.t.Fatal(err)
+	}
+	stat := fmt.Sprintf("%d (%s) %s %d 0 0 0 -1 0 0 0 0 0 0 0 0 0 20 0 1 0 %d 0 0\n", pid, comm, state, ppid, 1000+pid)
+	if err := os.WriteFile(filepath.Join(dir, "stat"), []byte(stat), 0o644); err != nil {
+		f.t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "cmdline"), []byte(strings.Join(cmdline, "\x00")+"\x00"), 0o644); err != nil {
+		f.t.Fatal(err)
+	}
+}
+
+func (f *fakeProc) remove(pid int) {
+	os.RemoveAll(filepath.Join(f.root, strconv.Itoa(pid)))
+}
+
+// nodeProcs 构造一个节点：containerd、Kubernetes Pod共享的shim、Docker私有containerd的shim、
+// CRI-O的conmon以及命令行中带有容器ID的普通进程
+func nodeProcs(t *testing.T) *fakeProc {
+	f := newFakeProc(t)
+	f.add(1, 0, "S", "/sbin/init")
+	f.add(100, 1, "S", "/usr/bin/containerd")
+	// Pod共享的shim：-id为sandbox ID，pause和业务容器都是它的子进程
+	f.add(200, 1, "S", "/usr/bin/containerd-shim-runc-v2", "-namespace", "k8s.io", "-id", testSandboxID, "-address", "/run/containerd/containerd.sock")
+	f.add(201, 200, "S", "/pause")
+	f.add(202, 200, "S", "nginx: master process")
+	f.add(203, 202, "Z", "nginx")
+	// Docker私有containerd下的同名容器
+	f.add(300, 1, "S", "/usr/bin/containerd-shim-runc-v2", "-namespace", "moby", "-id", testContainerID, "-address", "/run/docker/containerd/containerd.sock")
+	f.add(301, 300, "S", "sleep", "infinity")
+	// 宿主机containerd下残留的同ID容器
+	f.add(350, 1, "S", "/usr/bin/containerd-shim-runc-v2", "-namespace", "default", "-id", testContainerID, "-address", "/run/containerd/containerd.sock")
+	// CRI-O
+	f.add(400, 1, "S", "/usr/bin/conmon", "-b", "/run/containers/storage/overlay-containers/"+testCRIOID+"/userdata", "-c", testCRIOID)
+	f.add(401, 400, "S", "redis-server")
+	// 命令行包含容器ID但不是shim
+	f.add(500, 1, "S", "tail", "-f", "/var/log/containers/"+testContainerID+".log")
+	return f
+}
+
+func TestShimResolverResolve(t *testing.T) {
+	f := nodeProcs(t)
+	r := NewShimResolver(logger.New("error", "text"), f.root)
+
+	tests := []struct {
+		name        string
+		containerID string
+		initPID     int
+		// 为0时使用nodeProcs中init进程的启动时间
+		initStart  uint64
+		address    string
+		wantPID    int
+		wantOthers []int
+		wantErr    bool
+	}{
+		{
+			name:        "沿init进程的父进程链找到Pod共享的shim",
+			containerID: "0000000000000000000000000000000000000000000000000000000000000000",
+			initPID:     202,
+			wantPID:     200,
+			wantOthers:  []int{201},
+		},
+		{
+			name:        "init进程PID被其他容器的init复用时按容器ID查找",
+			containerID: testContainerID,
+			initPID:     202,
+			initStart:   999,
+			address:     "/run/docker/containerd/containerd.sock",
+			wantPID:     300,
+		},
+		{
+			name:        "init进程已退出时按容器ID查找",
+			containerID: testContainerID,
+			initPID:     309,
+			address:     "/run/docker/containerd/containerd.sock",
+			wantPID:     300,
+		},
+		{
+			name:        "按-id查找sandbox的shim",
+			containerID: testSandboxID,
+			address:     "/run/containerd/containerd.sock",
+			wantPID:     200,
+			// 不知道init进程时，两个运行中的子进程说明shim还服务其他容器
+			wantOthers: []int{201, 202},
+		},
+		{
+			name:        "按-address区分不同containerd的shim",
+			containerID: testContainerID,
+			address:     "unix:///run/docker/containerd/containerd.sock",
+			wantPID:     300,
+		},
+		{
+			name:        "短ID匹配conmon的-c参数",
+			containerID: testCRIOID[:12],
+			address:     "/var/run/crio/crio.sock",
+			wantPID:     400,
+		},
+		{
+			name:        "不限定地址时多个shim引用同一ID返回错误",
+			containerID: testContainerID,
+			wantErr:     true,
+		},
+		{
+			name:        "未找到shim",
+			containerID: "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			container := ContainerMeta{ID: tt.containerID, PID: tt.initPID, InitStartTime: tt.initStart}
+			if tt.initPID > 0 && tt.initStart == 0 {
+				container.InitStartTime = uint64(1000 + tt.initPID)
+			}
+			shim, err := r.resolve(container, tt.address)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolve() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if tt.wantPID == 0 {
+				if shim != nil {
+					t.Errorf("resolve() = %d, 期望未找到", shim.PID)
+				}
+				return
+			}
+			if shim == nil {
+				t.Fatalf("resolve()未找到shim, 期望 %d", tt.wantPID)
+			}
+			if shim.PID != tt.wantPID {
+				t.Errorf("shim = %d, 期望 %d", shim.PID, tt.wantPID)
+			}
+			sort.Ints(shim.others)
+			if !reflect.DeepEqual(shim.others, tt.wantOthers) {
+				t.Errorf("其他容器进程 = %v, 期望 %v", shim.others, tt.wantOthers)
+			}
+		})
+	}
+}
+
+func TestReferencesContainer(t *testing.T) {
+	tests := []struct {
+		name        string
+		cmdline     []string
+		containerID string
+		want        bool
+	}{
+		{"shim v2的-id参数", []string{"containerd-shim-runc-v2", "-id", testContainerID}, testContainerID, true},
+		{"conmon的--cid=参数", []string{"conmon", "--cid=" + testContainerID}, testContainerID, true},
+		{"docker-containerd-shim的bundle路径", []string{"docker-containerd-shim", testContainerID, "/var/run/docker/libcontainerd/" + testContainerID, "docker-runc"}, testContainerID, true},
+		{"短ID", []string{"containerd-shim-runc-v2", "-id", testContainerID}, testContainerID[:12], true},
+		{"ID只是参数的一部分", []string{"containerd-shim", "-workdir", "/data/" + testContainerID + "-backup"}, testContainerID, false},
+		{"短ID不能匹配短参数", []string{"conmon", "-n", testContainerID[:12] + "x"}, testContainerID[:12], false},
+		{"过短的ID", []string{"containerd-shim-runc-v2", "-id", testContainerID}, testContainerID[:6], false},
+	}
+	for _, tt := range tests {
+		if got := referencesContainer(tt.cmdline, tt.containerID); got != tt.want {
+			t.Errorf("%s: referencesContainer() = %v, 期望 %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestShimResolverKillShim(t *testing.T) {
+	tests := []struct {
+		name string
+		// ignore 为true时shim忽略对应信号
+		ignore      map[syscall.Signal]bool
+		force       bool
+		container   ContainerMeta
+		wantSignals []syscall.Signal
+		wantErr     error
+		wantAnyErr  bool
+	}{
+		{
+			name:        "SIGTERM后退出",
+			container:   ContainerMeta{ID: testContainerID, PID: 301, InitStartTime: 1301},
+			wantSignals: []syscall.Signal{syscall.SIGTERM},
+		},
+		{
+			name:        "忽略SIGTERM时发送SIGKILL",
+			ignore:      map[syscall.Signal]bool{syscall.SIGTERM: true},
+			container:   ContainerMeta{ID: testContainerID, PID: 301, InitStartTime: 1301},
+			wantSignals: []syscall.Signal{syscall.SIGTERM, syscall.SIGKILL},
+		},
+		{
+			name:        "SIGKILL后仍未退出",
+			ignore:      map[syscall.Signal]bool{syscall.SIGTERM: true, syscall.SIGKILL: true},
+			container:   ContainerMeta{ID: testContainerID, PID: 301, InitStartTime: 1301},
+			wantSignals: []syscall.Signal{syscall.SIGTERM, syscall.SIGKILL},
+			wantAnyErr:  true,
+		},
+		{
+			name:      "未启用force时不清理共享的shim",
+			container: ContainerMeta{ID: testContainerID, PID: 202, InitStartTime: 1202},
+			wantErr:   ErrShimShared,
+		},
+		{
+			name:        "启用force时清理共享的shim",
+			force:       true,
+			container:   ContainerMeta{ID: testContainerID, PID: 202, InitStartTime: 1202},
+			wantSignals: []syscall.Signal{syscall.SIGTERM},
+		},
+		{
+			name:      "未找到shim",
+			container: ContainerMeta{ID: "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := nodeProcs(t)
+			r := NewShimResolver(logger.New("error", "text"), f.root)
+			r.pollInterval = time.Millisecond
+			r.killWait = 20 * time.Millisecond
+
+			var signals []syscall.Signal
+			r.kill = func(ref process.Ref, sig syscall.Signal) error {
+				if ref.StartTime != uint64(1000+ref.PID) {
+					t.Errorf("进程句柄的启动时间 = %d, 期望 %d", ref.StartTime, 1000+ref.PID)
+				}
+				signals = append(signals, sig)
+				if !tt.ignore[sig] {
+					// shim退出后由containerd回收
+					f.remove(ref.PID)
+				}
+				return nil
+			}
+
+			err := r.KillShim(context.Background(), tt.container, "", ShimKillOptions{Force: tt.force, GracePeriod: 20 * time.Millisecond})
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("KillShim() error = %v, 期望 %v", err, tt.wantErr)
+				}
+			case tt.wantAnyErr:
+				if err == nil {
+					t.Fatal("KillShim()应返回错误")
+				}
+			case err != nil:
+				t.Fatalf("KillShim()返回错误: %v", err)
+			}
+			if !reflect.DeepEqual(signals, tt.wantSignals) {
+				t.Errorf("发送的信号 = %v, 期望 %v", signals, tt.wantSignals)
+			}
+		})
+	}
+}
+
+func TestShimResolverDetectsPIDReuse(t *testing.T) {
+	f := nodeProcs(t)
+	r := NewShimResolver(logger.New("error", "text"), f.root)
+	shim, err := r.resolve(ContainerMeta{ID: testContainerID, PID: 301, InitStartTime: 1301}, "")
+	if err != nil || shim == nil {
+		t.Fatalf("resolve() = %v, %v", shim, err)
+	}
+	if r.exited(shim) {
+		t.Fatal("shim仍在运行时不应视为已退出")
+	}
+
+	// shim退出后PID被新进程复用，启动时间不同
+	f.remove(300)
+	dir := filepath.Join(f.root, "300")
+	os.MkdirAll(dir, 0o755)
+	os.WriteFile(filepath.Join(dir, "stat"), []byte("300 (bash) S 1 0 0 0 -1 0 0 0 0 0 0 0 0 0 20 0 1 0 99999 0 0\n"), 0o644)
+	if !r.exited(shim) {
+		t.Error("PID被复用时应视为shim已退出")
+	}
+}
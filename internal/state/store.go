@@ -0,0 +1,130 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SchemaVersion 状态文件格式版本，格式不兼容变更时递增
+const SchemaVersion = 1
+
+// RemediationRecord 持久化的处置步骤记录
+type RemediationRecord struct {
+	Action    string        `json:"action"`
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration"`
+	Result    string        `json:"result"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// ZombieRecord 持久化的单个僵尸进程确认情况
+type ZombieRecord struct {
+	PID       int       `json:"pid"`
+	StartTime uint64    `json:"start_time"`
+	Count     int       `json:"count"`
+	Since     time.Time `json:"since"`
+}
+
+// ContainerRecord 持久化的容器状态
+type ContainerRecord struct {
+	ContainerID    string              `json:"container_id"`
+	PodName        string              `json:"pod_name"`
+	Namespace      string              `json:"namespace"`
+	DetectionCount int                 `json:"detection_count"`
+	LastDetected   time.Time           `json:"last_detected"`
+	Zombies        []ZombieRecord      `json:"zombies,omitempty"`
+	Remediation    []RemediationRecord `json:"remediation,omitempty"`
+}
+
+// Store 容器状态存储
+type Store interface {
+	// Load 读取全部容器状态，存储为空时返回nil
+	Load() ([]ContainerRecord, error)
+	// Save 整体替换存储的容器状态
+	Save(records []ContainerRecord) error
+}
+
+// snapshot 状态文件内容
+type snapshot struct {
+	Version    int               `json:"version"`
+	SavedAt    time.Time         `json:"saved_at"`
+	Containers []ContainerRecord `json:"containers"`
+}
+
+// FileStore 基于本地文件的状态存储，写入时先写临时文件再rename，保证原子性
+type FileStore struct {
+	path string
+}
+
+// NewFileStore 创建文件状态存储，path通常位于hostPath挂载目录中
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+func (s *FileStore) Load() ([]ContainerRecord, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("读取状态文件失败: %w", err)
+	}
+
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("解析状态文件失败: %w", err)
+	}
+	if snap.Version != SchemaVersion {
+		return nil, fmt.Errorf("状态文件版本不兼容: 期望%d，实际%d", SchemaVersion, snap.Version)
+	}
+	return snap.Containers, nil
+}
+
+func (s *FileStore) Save(records []ContainerRecord) error {
+	data, err := json.Marshal(snapshot{
+		Version:    SchemaVersion,
+		SavedAt:    time.Now(),
+		Containers: records,
+	})
+	if err != nil {
+		return fmt.Errorf("序列化状态失败: %w", err)
+	}
+
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("创建状态目录失败: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(s.path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("创建临时状态文件失败: %w", err)
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName) // rename成功后为空操作
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("写入临时状态文件失败: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("同步临时状态文件失败: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("关闭临时状态文件失败: %w", err)
+	}
+	if err := os.Rename(tmpName, s.path); err != nil {
+		return fmt.Errorf("替换状态文件失败: %w", err)
+	}
+
+	// 同步目录项，确保rename在掉电后依然生效
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
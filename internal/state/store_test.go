@@ -0,0 +1,140 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testRecords() []ContainerRecord {
+	detected := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	return []ContainerRecord{
+		{
+			ContainerID:    "abc123",
+			PodName:        "web-0",
+			Namespace:      "prod",
+			DetectionCount: 2,
+			LastDetected:   detected,
+			Zombies: []ZombieRecord{
+				{PID: 4242, StartTime: 987654, Count: 2, Since: detected.Add(-10 * time.Minute)},
+			},
+			Remediation: []RemediationRecord{
+				{Action: "sigchld_parent", StartedAt: detected, Duration: 10 * time.Second, Result: "unresolved"},
+				{Action: "stop_container", StartedAt: detected.Add(10 * time.Second), Duration: time.Second, Result: "failed", Error: "timeout"},
+			},
+		},
+		{ContainerID: "def456", PodName: "api-0", Namespace: "prod", DetectionCount: 1, LastDetected: detected},
+	}
+}
+
+func TestFileStoreRoundTrip(t *testing.T) {
+	store := NewFileStore(filepath.Join(t.TempDir(), "nested", "state.json"))
+
+	records := testRecords()
+	if err := store.Save(records); err != nil {
+		t.Fatalf("Save返回错误: %v", err)
+	}
+	got, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load返回错误: %v", err)
+	}
+	if !reflect.DeepEqual(got, records) {
+		t.Errorf("Load() = %+v, 期望 %+v", got, records)
+	}
+}
+
+func TestFileStoreLoadMissing(t *testing.T) {
+	store := NewFileStore(filepath.Join(t.TempDir(), "state.json"))
+	got, err := store.Load()
+	if err != nil || got != nil {
+		t.Errorf("文件不存在时Load() = (%v, %v), 期望 (nil, nil)", got, err)
+	}
+}
+
+func TestFileStoreSchemaMismatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	data := `{"version": 999, "containers": [{"container_id": "abc123"}]}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := NewFileStore(path).Load()
+	if err == nil {
+		t.Fatalf("版本不兼容时应返回错误, 实际返回 %+v", got)
+	}
+	if got != nil {
+		t.Errorf("版本不兼容时不应返回记录: %+v", got)
+	}
+}
+
+func TestFileStoreCorrupted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte(`{"version": 1, "containers": [`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFileStore(path).Load(); err == nil {
+		t.Error("文件损坏时应返回错误")
+	}
+}
+
+func TestFileStoreAtomicReplace(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+	store := NewFileStore(path)
+
+	if err := store.Save(testRecords()); err != nil {
+		t.Fatalf("第一次Save返回错误: %v", err)
+	}
+	replacement := []ContainerRecord{{ContainerID: "new", DetectionCount: 5}}
+	if err := store.Save(replacement); err != nil {
+		t.Fatalf("第二次Save返回错误: %v", err)
+	}
+
+	got, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load返回错误: %v", err)
+	}
+	if len(got) != 1 || got[0].ContainerID != "new" {
+		t.Errorf("Load() = %+v, 期望整体替换为新记录", got)
+	}
+
+	// 不能遗留临时文件
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), ".tmp-") {
+			t.Errorf("遗留临时文件: %s", entry.Name())
+		}
+	}
+	if len(entries) != 1 {
+		t.Errorf("目录中有%d个文件, 期望只有状态文件", len(entries))
+	}
+}
+
+func TestFileStoreFailedSaveCleansUp(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+
+	// 状态文件路径被非空目录占用时rename失败
+	if err := os.MkdirAll(filepath.Join(path, "child"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewFileStore(path).Save(testRecords()); err == nil {
+		t.Fatal("目标路径为非空目录时Save应失败")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), ".tmp-") {
+			t.Errorf("失败后遗留临时文件: %s", entry.Name())
+		}
+	}
+}
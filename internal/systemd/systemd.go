@@ -0,0 +1,85 @@
+package systemd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	sdbus "github.com/coreos/go-systemd/v22/dbus"
+	"github.com/godbus/dbus/v5"
+)
+
+// DefaultSocket systemd的私有D-Bus socket，root用户可以直接连接，不经过dbus-daemon
+const DefaultSocket = "/run/systemd/private"
+
+// Manager systemd unit管理，对应D-Bus接口org.freedesktop.systemd1.Manager中用到的方法
+type Manager interface {
+	// RestartUnit 以replace模式提交重启任务，任务提交后即返回，不等待unit重启完成
+	RestartUnit(ctx context.Context, unit string) error
+}
+
+// unitRestarter go-systemd连接中用到的方法
+type unitRestarter interface {
+	RestartUnitContext(ctx context.Context, name string, mode string, ch chan<- string) (int, error)
+	Close()
+}
+
+// Client 通过go-systemd连接systemd私有socket调用systemd1.Manager，每次调用建立一个连接
+type Client struct {
+	socket string
+	// connect 建立到systemd的连接，便于测试替换
+	connect func(ctx context.Context) (unitRestarter, error)
+}
+
+// NewClient 创建连接到socket的客户端，socket为空时使用DefaultSocket
+func NewClient(socket string) *Client {
+	if socket == "" {
+		socket = DefaultSocket
+	}
+	c := &Client{socket: socket}
+	c.connect = c.dial
+	return c
+}
+
+// ValidUnitName 检查unit名称是否是可以重启的service
+func ValidUnitName(unit string) bool {
+	if strings.ContainsAny(unit, "/ \t\n") {
+		return false
+	}
+	return strings.HasSuffix(unit, ".service") && len(unit) > len(".service")
+}
+
+func (c *Client) RestartUnit(ctx context.Context, unit string) error {
+	if !ValidUnitName(unit) {
+		return fmt.Errorf("无效的systemd unit名称: %q", unit)
+	}
+
+	conn, err := c.connect(ctx)
+	if err != nil {
+		return fmt.Errorf("连接systemd失败: %w", err)
+	}
+	defer conn.Close()
+
+	// 不传入任务完成通道：任务提交后即返回
+	if _, err := conn.RestartUnitContext(ctx, unit, "replace", nil); err != nil {
+		return fmt.Errorf("重启%s失败: %w", unit, err)
+	}
+	return nil
+}
+
+// dial 连接私有socket并以EXTERNAL方式认证。直接与systemd通信时不调用Hello
+func (c *Client) dial(ctx context.Context) (unitRestarter, error) {
+	return sdbus.NewConnection(func() (*dbus.Conn, error) {
+		conn, err := dbus.Dial("unix:path="+c.socket, dbus.WithContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+		if err := conn.Auth([]dbus.Auth{dbus.AuthExternal(strconv.Itoa(os.Getuid()))}); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		return conn, nil
+	})
+}
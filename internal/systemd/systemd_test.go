@@ -0,0 +1,95 @@
+package systemd
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// fakeConn 记录RestartUnit请求的systemd连接
+type fakeConn struct {
+	err    error
+	calls  [][2]string
+	closed bool
+}
+
+func (c *fakeConn) RestartUnitContext(_ context.Context, name string, mode string, _ chan<- string) (int, error) {
+	c.calls = append(c.calls, [2]string{name, mode})
+	if c.err != nil {
+		return 0, c.err
+	}
+	return 42, nil
+}
+
+func (c *fakeConn) Close() { c.closed = true }
+
+func TestRestartUnit(t *testing.T) {
+	tests := []struct {
+		name       string
+		unit       string
+		dialErr    error
+		restartErr error
+		wantErr    string
+		// 期望提交的重启请求数
+		wantCalls int
+	}{
+		{name: "提交成功", unit: "foo.service", wantCalls: 1},
+		{
+			name:       "unit不存在",
+			unit:       "foo.service",
+			restartErr: errors.New("Unit foo.service not found."),
+			wantErr:    "重启foo.service失败: Unit foo.service not found.",
+			wantCalls:  1,
+		},
+		{name: "连接失败", unit: "foo.service", dialErr: errors.New("permission denied"), wantErr: "连接systemd失败"},
+		{name: "不是service", unit: "session-3.scope", wantErr: "无效的systemd unit名称"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := &fakeConn{err: tt.restartErr}
+			c := NewClient("")
+			c.connect = func(context.Context) (unitRestarter, error) {
+				if tt.dialErr != nil {
+					return nil, tt.dialErr
+				}
+				return conn, nil
+			}
+
+			err := c.RestartUnit(context.Background(), tt.unit)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("RestartUnit返回错误: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("RestartUnit() error = %v, 期望包含 %q", err, tt.wantErr)
+			}
+			if len(conn.calls) != tt.wantCalls {
+				t.Fatalf("提交了%d次重启请求, 期望 %d", len(conn.calls), tt.wantCalls)
+			}
+			if tt.wantCalls > 0 {
+				if conn.calls[0] != [2]string{tt.unit, "replace"} {
+					t.Errorf("请求 = %v, 期望 [%s replace]", conn.calls[0], tt.unit)
+				}
+				if !conn.closed {
+					t.Error("调用后未关闭连接")
+				}
+			}
+		})
+	}
+}
+
+func TestValidUnitName(t *testing.T) {
+	for unit, want := range map[string]bool{
+		"kubelet.service":    true,
+		"session-3.scope":    false,
+		".service":           false,
+		"../etc.service":     false,
+		"foo bar.service":    false,
+		"containerd.service": true,
+	} {
+		if got := ValidUnitName(unit); got != want {
+			t.Errorf("ValidUnitName(%q) = %v, 期望 %v", unit, got, want)
+		}
+	}
+}
@@ -0,0 +1,331 @@
+package workqueue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/tiggoins/zombie-cleaner/internal/logger"
+	"github.com/tiggoins/zombie-cleaner/internal/metrics"
+)
+
+// ErrShuttingDown 队列关闭时尚未执行或等待重试的任务以该错误结束
+var ErrShuttingDown = errors.New("工作队列已关闭")
+
+// permanentError 不应重试的错误
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent 包装不应重试的错误，Handler返回该错误时任务直接结束，DoneFunc收到的是原始错误
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// 退避时间上限为基础退避的倍数
+const maxBackoffFactor = 32
+
+// Handler 处理单个任务，返回错误时按退避时间重试
+type Handler[T any] func(ctx context.Context, item T) error
+
+// DoneFunc 任务最终结束时调用：成功、重试次数用尽或因队列关闭被丢弃
+type DoneFunc[T any] func(item T, err error)
+
+// Options 队列配置
+type Options struct {
+	// 队列名称，用于日志和指标
+	Name string
+	// 并发worker数量
+	Workers int
+	// 失败后的最大重试次数，为0时不重试
+	MaxRetries int
+	// 第一次重试前的等待时间，之后每次翻倍
+	Backoff time.Duration
+}
+
+type task[T any] struct {
+	key      string
+	item     T
+	attempts int
+}
+
+// Queue 有界worker池，按key去重，失败任务按指数退避重试。
+// 同一key在排队、等待重试或执行期间不会被重复加入
+type Queue[T any] struct {
+	opts    Options
+	handler Handler[T]
+	done    DoneFunc[T]
+	logger  *logger.Logger
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	queue    []task[T]
+	keys     map[string]bool
+	retries  map[string]*retryEntry[T]
+	shutdown bool
+	// 正在运行的worker数量，大于opts.Workers时多余的worker退出
+	running int
+
+	// worker使用的上下文，独立于调用方，关闭超时后才取消
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+type retryEntry[T any] struct {
+	timer *time.Timer
+	task  task[T]
+}
+
+// New 创建队列并启动worker，done可以为nil
+func New[T any](opts Options, handler Handler[T], done DoneFunc[T], log *logger.Logger) *Queue[T] {
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	q := &Queue[T]{
+		opts:    opts,
+		handler: handler,
+		done:    done,
+		logger:  log.WithComponent("workqueue"),
+		keys:    make(map[string]bool),
+		retries: make(map[string]*retryEntry[T]),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+	q.cond = sync.NewCond(&q.mu)
+
+	q.mu.Lock()
+	q.startWorkersLocked(opts.Workers)
+	q.mu.Unlock()
+	return q
+}
+
+// Reconfigure 调整worker数量和重试参数。减少worker时，多余的worker完成当前任务后退出
+func (q *Queue[T]) Reconfigure(workers, maxRetries int, backoff time.Duration) {
+	if workers <= 0 {
+		workers = 1
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.opts.Workers = workers
+	q.opts.MaxRetries = maxRetries
+	q.opts.Backoff = backoff
+	if q.shutdown {
+		return
+	}
+	if q.running < workers {
+		q.startWorkersLocked(workers - q.running)
+	}
+	// 唤醒空闲worker检查是否需要退出
+	q.cond.Broadcast()
+}
+
+func (q *Queue[T]) startWorkersLocked(n int) {
+	q.running += n
+	q.wg.Add(n)
+	for i := 0; i < n; i++ {
+		go q.worker()
+	}
+}
+
+// Add 加入任务，key已在队列中或队列已关闭时返回false
+func (q *Queue[T]) Add(key string, item T) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.shutdown || q.keys[key] {
+		return false
+	}
+	q.keys[key] = true
+	q.queue = append(q.queue, task[T]{key: key, item: item})
+	q.updateDepthLocked()
+	q.cond.Signal()
+	return true
+}
+
+// Len 返回排队、等待重试和执行中的任务数量
+func (q *Queue[T]) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.keys)
+}
+
+// Shutdown 停止接收任务，丢弃尚未开始和等待重试的任务，等待执行中的任务完成。
+// ctx到期后取消执行中任务的上下文，并等待它们返回
+func (q *Queue[T]) Shutdown(ctx context.Context) {
+	q.mu.Lock()
+	if q.shutdown {
+		q.mu.Unlock()
+		return
+	}
+	q.shutdown = true
+
+	var dropped []task[T]
+	dropped = append(dropped, q.queue...)
+	q.queue = nil
+	for key, entry := range q.retries {
+		// 定时器已触发时由requeue负责丢弃
+		if entry.timer.Stop() {
+			dropped = append(dropped, entry.task)
+		}
+		delete(q.retries, key)
+	}
+	for _, t := range dropped {
+		delete(q.keys, t.key)
+	}
+	q.updateDepthLocked()
+	q.cond.Broadcast()
+	q.mu.Unlock()
+
+	if len(dropped) > 0 {
+		q.logger.Info("丢弃尚未执行的任务", "queue", q.opts.Name, "count", len(dropped))
+	}
+	for _, t := range dropped {
+		q.finish(t, ErrShuttingDown)
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+	case <-ctx.Done():
+		q.logger.Warn("等待执行中的任务超时，取消剩余任务", "queue", q.opts.Name)
+		q.cancel()
+		<-finished
+	}
+	q.cancel()
+}
+
+func (q *Queue[T]) worker() {
+	defer q.wg.Done()
+	for {
+		t, ok := q.get()
+		if !ok {
+			return
+		}
+		q.process(t)
+	}
+}
+
+// get 取出下一个任务。队列关闭且为空或worker数量超过配置时返回false，调用方退出
+func (q *Queue[T]) get() (task[T], bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for {
+		if q.running > q.opts.Workers {
+			q.running--
+			return task[T]{}, false
+		}
+		if len(q.queue) > 0 {
+			break
+		}
+		if q.shutdown {
+			q.running--
+			return task[T]{}, false
+		}
+		q.cond.Wait()
+	}
+	t := q.queue[0]
+	q.queue = q.queue[1:]
+	q.updateDepthLocked()
+	return t, true
+}
+
+func (q *Queue[T]) process(t task[T]) {
+	err := q.handler(q.ctx, t.item)
+	if err == nil {
+		q.release(t, nil)
+		return
+	}
+	var permanent *permanentError
+	if errors.As(err, &permanent) {
+		q.release(t, permanent.err)
+		return
+	}
+
+	q.mu.Lock()
+	if q.shutdown || q.ctx.Err() != nil || t.attempts >= q.opts.MaxRetries {
+		q.mu.Unlock()
+		if q.shutdown {
+			err = errors.Join(err, ErrShuttingDown)
+		}
+		q.release(t, err)
+		return
+	}
+
+	backoff := q.backoff(t.attempts)
+	t.attempts++
+	q.retries[t.key] = &retryEntry[T]{
+		task:  t,
+		timer: time.AfterFunc(backoff, func() { q.requeue(t) }),
+	}
+	q.mu.Unlock()
+
+	metrics.WorkQueueRetries.WithLabelValues(metrics.GetNodeName(), q.opts.Name).Inc()
+	q.logger.Warn("任务执行失败，稍后重试",
+		"queue", q.opts.Name,
+		"key", t.key,
+		"attempt", t.attempts,
+		"max_retries", q.opts.MaxRetries,
+		"backoff", backoff,
+		"error", err)
+}
+
+// requeue 退避时间到期后重新排队，队列已关闭时丢弃
+func (q *Queue[T]) requeue(t task[T]) {
+	q.mu.Lock()
+	if q.shutdown {
+		q.mu.Unlock()
+		q.release(t, ErrShuttingDown)
+		return
+	}
+	delete(q.retries, t.key)
+	q.queue = append(q.queue, t)
+	q.updateDepthLocked()
+	q.cond.Signal()
+	q.mu.Unlock()
+}
+
+// release 释放key并通知任务结束
+func (q *Queue[T]) release(t task[T], err error) {
+	q.mu.Lock()
+	delete(q.keys, t.key)
+	q.updateDepthLocked()
+	q.mu.Unlock()
+	q.finish(t, err)
+}
+
+func (q *Queue[T]) finish(t task[T], err error) {
+	if q.done != nil {
+		q.done(t.item, err)
+	}
+}
+
+// backoff 第attempts次重试前的等待时间
+func (q *Queue[T]) backoff(attempts int) time.Duration {
+	factor := 1
+	for i := 0; i < attempts && factor < maxBackoffFactor; i++ {
+		factor *= 2
+	}
+	return q.opts.Backoff * time.Duration(factor)
+}
+
+func (q *Queue[T]) updateDepthLocked() {
+	metrics.WorkQueueDepth.WithLabelValues(metrics.GetNodeName(), q.opts.Name).Set(float64(len(q.keys)))
+}
@@ -0,0 +1,344 @@
+package workqueue
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tiggoins/zombie-cleaner/internal/logger"
+)
+
+// result 记录任务结束时的结果
+type result struct {
+	item string
+	err  error
+}
+
+// recorder 收集DoneFunc的调用
+type recorder struct {
+	ch chan result
+}
+
+func newRecorder() *recorder {
+	return &recorder{ch: make(chan result, 100)}
+}
+
+func (r *recorder) done(item string, err error) {
+	r.ch <- result{item: item, err: err}
+}
+
+// wait 等待n个任务结束
+func (r *recorder) wait(t *testing.T, n int) []result {
+	t.Helper()
+	var got []result
+	for i := 0; i < n; i++ {
+		select {
+		case res := <-r.ch:
+			got = append(got, res)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("等待任务结束超时, 已结束%d个, 期望%d个", len(got), n)
+		}
+	}
+	return got
+}
+
+func newTestQueue(opts Options, handler Handler[string], r *recorder) *Queue[string] {
+	if opts.Name == "" {
+		opts.Name = "test"
+	}
+	return New(opts, handler, r.done, logger.New("error", "text"))
+}
+
+func TestQueueBoundedConcurrency(t *testing.T) {
+	var running, peak atomic.Int32
+	release := make(chan struct{})
+	r := newRecorder()
+	q := newTestQueue(Options{Workers: 2}, func(ctx context.Context, item string) error {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		<-release
+		running.Add(-1)
+		return nil
+	}, r)
+	defer q.Shutdown(context.Background())
+
+	for i := 0; i < 6; i++ {
+		if !q.Add(strconv.Itoa(i), strconv.Itoa(i)) {
+			t.Fatalf("Add(%d)返回false", i)
+		}
+	}
+	if q.Len() != 6 {
+		t.Errorf("Len() = %d, 期望6", q.Len())
+	}
+	close(release)
+	r.wait(t, 6)
+
+	if got := peak.Load(); got > 2 {
+		t.Errorf("最大并发数 = %d, 超过worker数量2", got)
+	}
+	if q.Len() != 0 {
+		t.Errorf("全部结束后Len() = %d, 期望0", q.Len())
+	}
+}
+
+func TestQueueDeduplicatesByKey(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	r := newRecorder()
+	q := newTestQueue(Options{Workers: 2}, func(ctx context.Context, item string) error {
+		started <- struct{}{}
+		<-release
+		return nil
+	}, r)
+	defer q.Shutdown(context.Background())
+
+	if !q.Add("c1", "first") {
+		t.Fatal("第一次Add应成功")
+	}
+	<-started
+	if q.Add("c1", "second") {
+		t.Error("执行期间相同key不应重复加入")
+	}
+	close(release)
+	if got := r.wait(t, 1); got[0].item != "first" {
+		t.Errorf("结束的任务 = %q, 期望first", got[0].item)
+	}
+
+	// 结束后可以再次加入
+	if !q.Add("c1", "third") {
+		t.Error("任务结束后应能再次加入相同key")
+	}
+	<-started
+	r.wait(t, 1)
+}
+
+func TestQueueRetry(t *testing.T) {
+	errFailed := errors.New("unresolved")
+
+	tests := []struct {
+		name      string
+		failures  int
+		retries   int
+		permanent bool
+		wantCalls int32
+		wantErr   bool
+	}{
+		{name: "一次成功", failures: 0, retries: 2, wantCalls: 1},
+		{name: "重试后成功", failures: 2, retries: 2, wantCalls: 3},
+		{name: "重试次数用尽", failures: 5, retries: 2, wantCalls: 3, wantErr: true},
+		{name: "不重试", failures: 5, retries: 0, wantCalls: 1, wantErr: true},
+		{name: "不可重试的错误", failures: 5, retries: 2, permanent: true, wantCalls: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			r := newRecorder()
+			q := newTestQueue(Options{Workers: 1, MaxRetries: tt.retries, Backoff: time.Millisecond}, func(ctx context.Context, item string) error {
+				if int(calls.Add(1)) <= tt.failures {
+					if tt.permanent {
+						return Permanent(errFailed)
+					}
+					return errFailed
+				}
+				return nil
+			}, r)
+			defer q.Shutdown(context.Background())
+
+			q.Add("c1", "c1")
+			got := r.wait(t, 1)
+			if (got[0].err != nil) != tt.wantErr {
+				t.Errorf("结束错误 = %v, 期望出错 %v", got[0].err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(got[0].err, errFailed) {
+				t.Errorf("结束错误 = %v, 期望包含处理函数返回的错误", got[0].err)
+			}
+			if calls.Load() != tt.wantCalls {
+				t.Errorf("处理函数调用%d次, 期望%d次", calls.Load(), tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestQueueBackoff(t *testing.T) {
+	q := &Queue[string]{opts: Options{Backoff: time.Second}}
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 0, want: time.Second},
+		{attempts: 1, want: 2 * time.Second},
+		{attempts: 3, want: 8 * time.Second},
+		{attempts: 5, want: 32 * time.Second},
+		// 不超过上限
+		{attempts: 20, want: 32 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := q.backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %v, 期望 %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestQueueShutdownDrainsInFlight(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	r := newRecorder()
+	q := newTestQueue(Options{Workers: 1}, func(ctx context.Context, item string) error {
+		started <- struct{}{}
+		<-release
+		return nil
+	}, r)
+
+	q.Add("a", "a")
+	<-started
+	// worker被占用，b和c仍在排队
+	q.Add("b", "b")
+	q.Add("c", "c")
+
+	shutdownDone := make(chan struct{})
+	go func() {
+		q.Shutdown(context.Background())
+		close(shutdownDone)
+	}()
+
+	// 排队中的任务被丢弃
+	dropped := r.wait(t, 2)
+	for _, res := range dropped {
+		if res.item == "a" || !errors.Is(res.err, ErrShuttingDown) {
+			t.Errorf("丢弃的任务 = %+v, 期望b或c且错误为ErrShuttingDown", res)
+		}
+	}
+	select {
+	case <-shutdownDone:
+		t.Fatal("执行中的任务完成前Shutdown不应返回")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+	if got := r.wait(t, 1); got[0].item != "a" || got[0].err != nil {
+		t.Errorf("执行中的任务结果 = %+v, 期望正常完成", got[0])
+	}
+	<-shutdownDone
+
+	if q.Add("d", "d") {
+		t.Error("关闭后Add应返回false")
+	}
+}
+
+func TestQueueShutdownTimeoutCancelsInFlight(t *testing.T) {
+	started := make(chan struct{})
+	r := newRecorder()
+	q := newTestQueue(Options{Workers: 1, MaxRetries: 3, Backoff: time.Millisecond}, func(ctx context.Context, item string) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}, r)
+
+	q.Add("a", "a")
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	q.Shutdown(ctx)
+
+	got := r.wait(t, 1)
+	if !errors.Is(got[0].err, context.Canceled) || !errors.Is(got[0].err, ErrShuttingDown) {
+		t.Errorf("超时取消后结束错误 = %v, 期望包含context.Canceled和ErrShuttingDown", got[0].err)
+	}
+}
+
+func TestQueueShutdownDropsPendingRetry(t *testing.T) {
+	var calls atomic.Int32
+	r := newRecorder()
+	q := newTestQueue(Options{Workers: 1, MaxRetries: 3, Backoff: time.Hour}, func(ctx context.Context, item string) error {
+		calls.Add(1)
+		return errors.New("unresolved")
+	}, r)
+
+	q.Add("a", "a")
+	// 等待第一次执行失败并进入退避
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		q.mu.Lock()
+		waiting := len(q.retries)
+		q.mu.Unlock()
+		if waiting == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("任务未进入重试等待")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	q.Shutdown(context.Background())
+	got := r.wait(t, 1)
+	if !errors.Is(got[0].err, ErrShuttingDown) {
+		t.Errorf("结束错误 = %v, 期望ErrShuttingDown", got[0].err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("处理函数调用%d次, 等待重试的任务不应再执行", calls.Load())
+	}
+	if q.Len() != 0 {
+		t.Errorf("关闭后Len() = %d, 期望0", q.Len())
+	}
+}
+
+func TestQueueReconfigure(t *testing.T) {
+	var running, peak atomic.Int32
+	release := make(chan struct{})
+	r := newRecorder()
+	q := newTestQueue(Options{Workers: 1}, func(ctx context.Context, item string) error {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		<-release
+		running.Add(-1)
+		return nil
+	}, r)
+	defer q.Shutdown(context.Background())
+
+	// 扩容后可以并发执行
+	q.Reconfigure(3, 0, time.Second)
+	for i := 0; i < 3; i++ {
+		q.Add(strconv.Itoa(i), strconv.Itoa(i))
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for running.Load() < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("扩容后并发数 = %d, 期望3", running.Load())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// 缩容后多余的worker完成当前任务后退出
+	q.Reconfigure(1, 0, time.Second)
+	close(release)
+	r.wait(t, 3)
+
+	release = make(chan struct{})
+	peak.Store(0)
+	for i := 3; i < 6; i++ {
+		q.Add(strconv.Itoa(i), strconv.Itoa(i))
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	r.wait(t, 3)
+	if got := peak.Load(); got != 1 {
+		t.Errorf("缩容后最大并发数 = %d, 期望1", got)
+	}
+}
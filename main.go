@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
@@ -15,17 +16,44 @@ import (
 )
 
 var (
-	configFile = flag.String("config", "/etc/zombie-cleaner/config.yaml", "配置文件路径")
+	configFile  = flag.String("config", "/etc/zombie-cleaner/config.yaml", "配置文件路径")
+	printConfig = flag.Bool("print-config", false, "输出合并后的生效配置及每个配置项的来源后退出")
+	// 每个配置项对应的命令行参数
+	flagOverrides = config.RegisterFlags(flag.CommandLine)
 )
 
+// 无法使用inotify时轮询配置文件的间隔
+const configPollInterval = 30 * time.Second
+
 func main() {
 	flag.Parse()
 
-	// 加载配置
-	cfg := config.Load(*configFile)
+	// zombie-cleaner validate-config [文件...]：校验配置文件或包含配置的ConfigMap清单后退出
+	if flag.Arg(0) == "validate-config" {
+		os.Exit(validateConfig(flag.Args()[1:]))
+	}
+
+	// 加载配置：默认值 → 配置文件 → 环境变量 → 命令行参数
+	loader := &config.Loader{Path: *configFile, Flags: flagOverrides()}
+	cfg, sources, err := loader.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "加载配置失败: %v\n", err)
+		os.Exit(1)
+	}
+	if *printConfig {
+		if err := config.PrintConfig(os.Stdout, cfg, sources); err != nil {
+			fmt.Fprintf(os.Stderr, "输出配置失败: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// 初始化日志
 	log := logger.New(cfg.Logger.Level, cfg.Logger.Format)
 	log.Info("启动僵尸进程清理器")
+	if legacy := sources.LegacyEnv(); len(legacy) > 0 {
+		log.Warn("使用了旧版无前缀环境变量，请改用"+config.EnvPrefix+"前缀", "env", legacy)
+	}
 
 	// 初始化指标监控
 	if cfg.Metrics.Enabled {
@@ -50,11 +78,28 @@ func main() {
 	// 启动清理器
 	go zombieCleaner.Start(ctx)
 
-	// 优雅关闭
+	// 监听配置文件变化
+	watcher := config.NewWatcher(*configFile, configPollInterval, log)
+	go watcher.Run(ctx)
+
+	// SIGHUP重新加载配置，SIGINT/SIGTERM优雅关闭
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
 
-	<-sigChan
+	running := true
+	for running {
+		select {
+		case <-watcher.Changes():
+			zombieCleaner.ReloadFrom(loader, "file")
+		case <-hupChan:
+			log.Info("收到SIGHUP信号，重新加载配置")
+			zombieCleaner.ReloadFrom(loader, "sighup")
+		case <-sigChan:
+			running = false
+		}
+	}
 	log.Info("收到关闭信号，开始优雅关闭...")
 
 	// 给清理器一些时间完成当前操作
@@ -66,3 +111,29 @@ func main() {
 
 	log.Info("僵尸进程清理器已关闭")
 }
+
+// validateConfig 校验配置文件，未指定文件时校验--config。全部有效时返回0，否则返回1
+func validateConfig(paths []string) int {
+	if len(paths) == 0 {
+		paths = []string{*configFile}
+	}
+
+	code := 0
+	for _, path := range paths {
+		results, err := config.ValidateFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			code = 1
+			continue
+		}
+		for _, result := range results {
+			if result.Err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", result.Name, result.Err)
+				code = 1
+				continue
+			}
+			fmt.Printf("%s: 配置有效\n", result.Name)
+		}
+	}
+	return code
+}